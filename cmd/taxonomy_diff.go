@@ -0,0 +1,137 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"fybrik.io/fybrik/pkg/taxonomy/compile"
+	"fybrik.io/fybrik/pkg/taxonomy/diff"
+	taxonomyio "fybrik.io/fybrik/pkg/taxonomy/io"
+	"fybrik.io/fybrik/pkg/taxonomy/model"
+	"github.com/spf13/cobra"
+	"helm.sh/helm/v3/cmd/helm/require"
+)
+
+var (
+	taxonomyDiffOldLayers      []string
+	taxonomyDiffNewLayers      []string
+	taxonomyDiffResources      string
+	taxonomyDiffResourceSchema string
+	taxonomyDiffFormat         string
+)
+
+// diffReport is the result of the diff command
+type diffReport struct {
+	Changes          []diff.Change          `json:"changes"`
+	InvalidResources []diff.InvalidResource `json:"invalidResources,omitempty"`
+	Breaking         bool                   `json:"breaking"`
+}
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff OLD NEW [--old-layer <layerFile> ...] [--new-layer <layerFile> ...] [--resources <dir> --resource-schema <schemaFile>] [--format text|json]",
+	Short: "Compare two taxonomies and report the changes that break existing resources",
+	Long: `Compare two taxonomies and report the changes that break existing resources.
+
+OLD and NEW are compiled taxonomy files, or base taxonomies if layers are given for them.
+Changes are classified as additive, breaking or enum-narrowing. The command fails if any change is
+breaking or enum-narrowing, or if any of the resources in the --resources directory is rejected by NEW.`,
+	Args: require.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"yaml", "yml", "json"}, cobra.ShellCompDirectiveDefault
+	},
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if taxonomyDiffFormat != "text" && taxonomyDiffFormat != "json" {
+			return fmt.Errorf("unknown format %s", taxonomyDiffFormat)
+		}
+		if (taxonomyDiffResources == "") != (taxonomyDiffResourceSchema == "") {
+			return errors.New("--resources and --resource-schema have to be used together")
+		}
+		oldDoc, err := readTaxonomy(args[0], taxonomyDiffOldLayers)
+		if err != nil {
+			return err
+		}
+		newDoc, err := readTaxonomy(args[1], taxonomyDiffNewLayers)
+		if err != nil {
+			return err
+		}
+
+		report := diffReport{Changes: diff.Documents(oldDoc, newDoc)}
+		report.Breaking = diff.HasBreakingChanges(report.Changes)
+		if taxonomyDiffResources != "" {
+			report.InvalidResources, err = diff.ValidateResources(newDoc, taxonomyDiffResourceSchema, taxonomyDiffResources)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = printDiffReport(cmd.OutOrStdout(), &report); err != nil {
+			return err
+		}
+		switch {
+		case report.Breaking:
+			return errors.New("the new taxonomy has breaking changes")
+		case len(report.InvalidResources) != 0:
+			return errors.New("the new taxonomy rejects existing resources")
+		}
+		return nil
+	},
+	DisableFlagsInUseLine: true,
+}
+
+// readTaxonomy reads a compiled taxonomy or compiles it if layers are given
+func readTaxonomy(path string, layers []string) (*model.Document, error) {
+	if len(layers) == 0 {
+		return taxonomyio.ReadDocumentFromFile(path)
+	}
+	return compile.Files(path, layers)
+}
+
+func printDiffReport(out io.Writer, report *diffReport) error {
+	if taxonomyDiffFormat == "json" {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(encoded))
+		return err
+	}
+
+	if len(report.Changes) == 0 {
+		fmt.Fprintln(out, "No changes")
+	}
+	for _, change := range report.Changes {
+		fmt.Fprintln(out, change.String())
+	}
+	for _, resource := range report.InvalidResources {
+		fmt.Fprintf(out, "Invalid resource %s:\n", resource.File)
+		for _, desc := range resource.Errors {
+			fmt.Fprintf(out, "- %s\n", desc)
+		}
+	}
+	return nil
+}
+
+func init() {
+	taxonomyCmd.AddCommand(diffCmd)
+
+	diffCmd.Flags().StringArrayVar(&taxonomyDiffOldLayers, "old-layer", nil, "Layer to compile into the old taxonomy")
+	_ = diffCmd.MarkFlagFilename("old-layer", "yaml", "yml", "json")
+	diffCmd.Flags().StringArrayVar(&taxonomyDiffNewLayers, "new-layer", nil, "Layer to compile into the new taxonomy")
+	_ = diffCmd.MarkFlagFilename("new-layer", "yaml", "yml", "json")
+
+	diffCmd.Flags().StringVar(&taxonomyDiffResources, "resources", "",
+		"Directory with existing resources to validate against the new taxonomy")
+	_ = diffCmd.MarkFlagDirname("resources")
+	diffCmd.Flags().StringVar(&taxonomyDiffResourceSchema, "resource-schema", "",
+		"Schema of the resources that refers to taxonomy.json, e.g. fybrik_application.json")
+	_ = diffCmd.MarkFlagFilename("resource-schema", "json")
+
+	diffCmd.Flags().StringVarP(&taxonomyDiffFormat, "format", "f", "text", "Output format: text or json")
+}
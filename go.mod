@@ -37,6 +37,7 @@ require (
 	github.com/onsi/gomega v1.14.0
 	github.com/opencontainers/runc v1.0.0-rc9 // indirect
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.11.0
 	github.com/robfig/cron v1.2.0
 	github.com/rogpeppe/go-internal v1.6.0 // indirect
 	github.com/spf13/cobra v1.2.1
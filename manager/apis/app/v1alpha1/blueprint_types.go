@@ -62,6 +62,22 @@ type WriteModuleArgs struct {
 	Transformations []serde.Arbitrary `json:"transformations,omitempty"`
 }
 
+// TransformModuleArgs define the input parameters for modules that transform the data served by a previous module in the data path
+type TransformModuleArgs struct {
+	// AssetID identifies the asset to be used for accessing the data when it is ready
+	// It is copied from the FybrikApplication resource
+	// +required
+	AssetID string `json:"assetID"`
+
+	// Input is the instance name of the previous module in the data path, whose output is transformed by this module
+	// +required
+	Input string `json:"input"`
+
+	// Transformations are different types of processing that are done to the data
+	// +optional
+	Transformations []serde.Arbitrary `json:"transformations,omitempty"`
+}
+
 // ModuleArguments are the parameters passed to a component that runs in the data path
 // In the future might support output args as well
 // The arguments passed depend on the type of module
@@ -77,6 +93,10 @@ type ModuleArguments struct {
 	// WriteArgs are parameters that are specific to modules that enable an application to write data
 	// +optional
 	Write []WriteModuleArgs `json:"write,omitempty"`
+
+	// TransformArgs are parameters that are specific to modules that transform the data served by another module
+	// +optional
+	Transform []TransformModuleArgs `json:"transform,omitempty"`
 }
 
 // BlueprintModule is a copy of a FybrikModule Custom Resource.  It contains the information necessary
@@ -101,6 +121,10 @@ type BlueprintModule struct {
 	// assetIDs indicate the assets processed by this module.  Included so we can track asset status
 	// as well as module status in the future.
 	AssetIDs []string `json:"assetIds,omitempty"`
+
+	// Scope indicates whether the instance serves a single asset, all the assets of the workload, or all the workloads in the cluster
+	// +optional
+	Scope CapabilityScope `json:"scope,omitempty"`
 }
 
 // BlueprintSpec defines the desired state of Blueprint, which defines the components of the workload's data path
@@ -0,0 +1,39 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+// SecretProvider identifies the system from which modules obtain the credentials of data stores
+// +kubebuilder:validation:Enum=vault;kubernetes;file
+type SecretProvider string
+
+const (
+	// VaultSecretProvider indicates that modules read the credentials from Vault
+	VaultSecretProvider SecretProvider = "vault"
+
+	// KubernetesSecretProvider indicates that the credentials are projected as a Kubernetes Secret into the
+	// namespace of the modules
+	KubernetesSecretProvider SecretProvider = "kubernetes"
+
+	// FileSecretProvider indicates that the credentials are mounted as files into the modules, e.g. by a CSI driver
+	FileSecretProvider SecretProvider = "file"
+)
+
+// CredentialReference is a provider-neutral reference to the credentials of a data store
+type CredentialReference struct {
+	// Provider is the secret provider from which the module obtains the credentials
+	// +required
+	Provider SecretProvider `json:"provider"`
+	// SecretName is the name of the Kubernetes Secret that holds the credentials
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// SecretNamespace is the namespace of the Kubernetes Secret that holds the credentials
+	// +optional
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+	// Path is the path of the directory in which the credentials are mounted into the module (file provider)
+	// +optional
+	Path string `json:"path,omitempty"`
+	// Vault holds the details for retrieving the credentials from Vault (vault provider)
+	// +optional
+	Vault *Vault `json:"vault,omitempty"`
+}
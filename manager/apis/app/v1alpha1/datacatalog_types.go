@@ -19,11 +19,17 @@ const (
 )
 
 // DataStore contains the details for accesing the data that are sent by catalog connectors
-// Credentials for accesing the data are stored in Vault, in the location represented by Vault property.
+// Credentials for accesing the data are referenced by the Credentials property. If they are stored in Vault,
+// the location is also represented by the Vault property.
 type DataStore struct {
 	// Holds details for retrieving credentials by the modules from Vault store.
 	// It is a map so that different credentials can be stored for the different DataStoreActions
-	Vault map[string]Vault `json:"vault"`
+	// +optional
+	Vault map[string]Vault `json:"vault,omitempty"`
+	// Credentials holds provider-neutral references to the credentials of the data store.
+	// It is a map so that different credentials can be stored for the different DataStoreActions
+	// +optional
+	Credentials map[string]CredentialReference `json:"credentials,omitempty"`
 	// Connection has the relevant details for accesing the data (url, table, ssl, etc.)
 	// +required
 	Connection serde.Arbitrary `json:"connection"`
@@ -40,6 +40,12 @@ type DataRequirements struct {
 	// CopyRequrements include the requirements for copying the data
 	// +optional
 	Copy CopyRequirements `json:"copy,omitempty"`
+
+	// Flow indicates whether the workload reads or writes the data.
+	// If not specified, the data is read.
+	// +kubebuilder:validation:Enum=read;write
+	// +optional
+	Flow DataFlow `json:"flow,omitempty"`
 }
 
 // DataContext indicates data set chosen by the Data Scientist to be used by his application,
@@ -99,6 +105,7 @@ const (
 	ModuleNotFound              string = "No module has been registered"
 	InsufficientStorage         string = "No bucket was provisioned for implicit copy"
 	InvalidClusterConfiguration string = "Cluster configuration does not support the requirements."
+	UnsupportedDependencies     string = "The connectors or features required by the module are not available in the clusters."
 )
 
 // Condition indices are static. Conditions always present in the status.
@@ -155,6 +162,33 @@ type DatasetDetails struct {
 	Details serde.Arbitrary `json:"details,omitempty"`
 }
 
+// ModuleSelectionDetails explains why a module has been chosen or rejected for a capability
+type ModuleSelectionDetails struct {
+	// Capability for which the module has been evaluated
+	Capability CapabilityType `json:"capability"`
+	// Module is the name of the evaluated FybrikModule
+	Module string `json:"module"`
+	// Selected is true if the module has been chosen
+	// +optional
+	Selected bool `json:"selected,omitempty"`
+	// Reason explains why the module has been chosen or rejected
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// PolicyDecisionRecord identifies a governance decision that is enforced by the generated resources
+type PolicyDecisionRecord struct {
+	// DataSetID is the identifier of the dataset for which the decision has been made
+	DataSetID string `json:"datasetID"`
+	// Operation is the type of the evaluated access operation (e.g. READ, WRITE)
+	Operation string `json:"operation"`
+	// Destination is the geography of the evaluated access operation
+	// +optional
+	Destination string `json:"destination,omitempty"`
+	// Digest summarizes the enforcement actions of the decision, or the reason for which the operation is not allowed
+	Digest string `json:"digest"`
+}
+
 // AssetState defines the observed state of an asset
 type AssetState struct {
 	// Conditions indicate the asset state (Ready, Deny, Error)
@@ -168,6 +202,10 @@ type AssetState struct {
 	// Endpoint provides the endpoint spec from which the asset will be served to the application
 	// +optional
 	Endpoint EndpointSpec `json:"endpoint,omitempty"`
+
+	// ModuleSelection records the modules that have been evaluated for the asset, and why each of them has been chosen or rejected
+	// +optional
+	ModuleSelection []ModuleSelectionDetails `json:"moduleSelection,omitempty"`
 }
 
 // FybrikApplicationStatus defines the observed state of FybrikApplication.
@@ -189,6 +227,21 @@ type FybrikApplicationStatus struct {
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
+	// ObservedRefresh is the value of the refresh annotation that has been handled by the last reconcile.
+	// A change of the annotation invalidates the cached catalog and policy responses and triggers a reconcile.
+	// +optional
+	ObservedRefresh string `json:"observedRefresh,omitempty"`
+
+	// PolicyEvaluationTime is the last time in which the governance policies of the application have been evaluated.
+	// It is used to determine when the policies should be evaluated again.
+	// +optional
+	PolicyEvaluationTime *metav1.Time `json:"policyEvaluationTime,omitempty"`
+
+	// PolicyDecisions records the governance decisions that are enforced by the generated resources.
+	// The decisions are evaluated again periodically, and the resources are regenerated only if the decisions have changed.
+	// +optional
+	PolicyDecisions []PolicyDecisionRecord `json:"policyDecisions,omitempty"`
+
 	// ValidatedGeneration is the version of the FyrbikApplication that has been validated with the taxonomy defined.
 	// +optional
 	ValidatedGeneration int64 `json:"validatedGeneration,omitempty"`
@@ -244,3 +297,7 @@ const (
 	ApplicationNamespaceLabel = "app.fybrik.io/appNamespace"
 	ApplicationNameLabel      = "app.fybrik.io/appName"
 )
+
+// RefreshAnnotation is an annotation of a FybrikApplication.
+// Changing its value forces a new evaluation of the application, ignoring cached catalog and policy responses.
+const RefreshAnnotation = "app.fybrik.io/refresh"
@@ -11,7 +11,6 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -46,8 +45,6 @@ func (r *FybrikApplication) ValidateDelete() error {
 }
 
 func (r *FybrikApplication) ValidateFybrikApplication(taxonomyFile string) error {
-	var allErrs []*field.Error
-
 	// Convert Fybrik application Go struct to JSON
 	applicationJSON, err := json.Marshal(r)
 	if err != nil {
@@ -55,7 +52,10 @@ func (r *FybrikApplication) ValidateFybrikApplication(taxonomyFile string) error
 	}
 
 	// Validate Fybrik application against taxonomy
-	allErrs = validate.TaxonomyCheck(applicationJSON, taxonomyFile, "Fybrik application")
+	allErrs, err := validate.TaxonomyCheck(applicationJSON, taxonomyFile)
+	if err != nil {
+		return err
+	}
 
 	// Return any error
 	if len(allErrs) == 0 {
@@ -214,6 +214,18 @@ type FybrikModuleSpec struct {
 	// StatusIndicators allow to check status of a non-standard resource that can not be computed by helm/kstatus
 	// +optional
 	StatusIndicators []ResourceStatusIndicator `json:"statusIndicators,omitempty"`
+
+	// Priority is a hint used when several modules fulfill the requirements.
+	// A module with a higher priority is preferred regardless of its cost. The default priority is 0.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Weight is a hint of the cost of running an instance of the module, used when several modules with the same priority
+	// fulfill the requirements. The modules that require the lowest total weight of instances, including their dependencies,
+	// are preferred. The default weight is 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Weight int32 `json:"weight,omitempty"`
 }
 
 // ChartSpec specifies chart name and values
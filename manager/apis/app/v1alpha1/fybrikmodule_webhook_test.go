@@ -0,0 +1,47 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+func readModule(t *testing.T, filename string) *FybrikModule {
+	moduleYaml, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	module := &FybrikModule{}
+	assert.Nil(t, yaml.Unmarshal(moduleYaml, module))
+	return module
+}
+
+func TestValidModuleWithBaseTaxonomy(t *testing.T) {
+	t.Parallel()
+
+	module := readModule(t, "../../../testdata/unittests/module-read-csv.yaml")
+	taxonomyFile := "../../../testdata/unittests/basetaxonomy/fybrik_module.json"
+	validateErr := module.ValidateFybrikModule(taxonomyFile)
+	assert.Nil(t, validateErr, "No error should be found")
+}
+
+func TestValidModuleWithEnhancedTaxonomy(t *testing.T) {
+	t.Parallel()
+
+	module := readModule(t, "../../../testdata/unittests/module-read-csv.yaml")
+	taxonomyFile := "../../../testdata/unittests/sampletaxonomy/fybrik_module.json"
+	validateErr := module.ValidateFybrikModule(taxonomyFile)
+	assert.Nil(t, validateErr, "No error should be found")
+}
+
+func TestInvalidModuleInterfaceWithEnhancedTaxonomy(t *testing.T) {
+	t.Parallel()
+
+	module := readModule(t, "../../../testdata/unittests/fybrikmodule-interfaceErrors.yaml")
+	taxonomyFile := "../../../testdata/unittests/sampletaxonomy/fybrik_module.json"
+	validateErr := module.ValidateFybrikModule(taxonomyFile)
+	assert.NotNil(t, validateErr, "Invalid interface error should be found")
+}
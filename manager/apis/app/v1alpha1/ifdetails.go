@@ -8,6 +8,8 @@ const (
 	S3          string = "s3"
 	Kafka       string = "kafka"
 	JdbcDb2     string = "jdbc-db2"
+	Jdbc        string = "jdbc"
+	File        string = "file"
 	ArrowFlight string = "fybrik-arrow-flight"
 	Arrow       string = "arrow"
 	Parquet     string = "parquet"
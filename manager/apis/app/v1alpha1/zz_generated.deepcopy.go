@@ -42,6 +42,11 @@ func (in *AssetState) DeepCopyInto(out *AssetState) {
 		copy(*out, *in)
 	}
 	out.Endpoint = in.Endpoint
+	if in.ModuleSelection != nil {
+		in, out := &in.ModuleSelection, &out.ModuleSelection
+		*out = make([]ModuleSelectionDetails, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AssetState.
@@ -272,6 +277,26 @@ func (in *CopyRequirements) DeepCopy() *CopyRequirements {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialReference) DeepCopyInto(out *CredentialReference) {
+	*out = *in
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(Vault)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialReference.
+func (in *CredentialReference) DeepCopy() *CredentialReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataContext) DeepCopyInto(out *DataContext) {
 	*out = *in
@@ -315,6 +340,13 @@ func (in *DataStore) DeepCopyInto(out *DataStore) {
 			(*out)[key] = val
 		}
 	}
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = make(map[string]CredentialReference, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	in.Connection.DeepCopyInto(&out.Connection)
 }
 
@@ -471,6 +503,15 @@ func (in *FybrikApplicationStatus) DeepCopyInto(out *FybrikApplicationStatus) {
 			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.PolicyEvaluationTime != nil {
+		in, out := &in.PolicyEvaluationTime, &out.PolicyEvaluationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.PolicyDecisions != nil {
+		in, out := &in.PolicyDecisions, &out.PolicyDecisions
+		*out = make([]PolicyDecisionRecord, len(*in))
+		copy(*out, *in)
+	}
 	if in.Generated != nil {
 		in, out := &in.Generated, &out.Generated
 		*out = new(ResourceReference)
@@ -750,6 +791,13 @@ func (in *ModuleArguments) DeepCopyInto(out *ModuleArguments) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Transform != nil {
+		in, out := &in.Transform, &out.Transform
+		*out = make([]TransformModuleArgs, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleArguments.
@@ -824,6 +872,21 @@ func (in *ModuleInOut) DeepCopy() *ModuleInOut {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ModuleSelectionDetails) DeepCopyInto(out *ModuleSelectionDetails) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ModuleSelectionDetails.
+func (in *ModuleSelectionDetails) DeepCopy() *ModuleSelectionDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(ModuleSelectionDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObservedState) DeepCopyInto(out *ObservedState) {
 	*out = *in
@@ -963,6 +1026,21 @@ func (in *Plugin) DeepCopy() *Plugin {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyDecisionRecord) DeepCopyInto(out *PolicyDecisionRecord) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyDecisionRecord.
+func (in *PolicyDecisionRecord) DeepCopy() *PolicyDecisionRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyDecisionRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReadModuleArgs) DeepCopyInto(out *ReadModuleArgs) {
 	*out = *in
@@ -1047,6 +1125,28 @@ func (in *SupportedAction) DeepCopy() *SupportedAction {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransformModuleArgs) DeepCopyInto(out *TransformModuleArgs) {
+	*out = *in
+	if in.Transformations != nil {
+		in, out := &in.Transformations, &out.Transformations
+		*out = make([]serde.Arbitrary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformModuleArgs.
+func (in *TransformModuleArgs) DeepCopy() *TransformModuleArgs {
+	if in == nil {
+		return nil
+	}
+	out := new(TransformModuleArgs)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Vault) DeepCopyInto(out *Vault) {
 	*out = *in
@@ -8,6 +8,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -31,6 +32,12 @@ type BatchTransferSpec struct {
 	// +optional
 	Spark *Spark `json:"spark,omitempty"`
 
+	// Backend that runs the data movement: a Kubernetes Job that runs Spark in a single pod,
+	// or a SparkApplication of the Spark operator that distributes the work to executor pods.
+	// This property will be defaulted by the webhook if not set.
+	// +optional
+	Backend TransferBackend `json:"backend,omitempty"`
+
 	// Cron schedule if this BatchTransfer job should run on a regular schedule.
 	// Values are specified like cron job schedules.
 	// A good translation to human language can be found here https://crontab.guru/
@@ -62,6 +69,12 @@ type BatchTransferSpec struct {
 	// +optional
 	Suspend bool `json:"suspend,omitempty"`
 
+	// Counter to request another run of a finished one-shot batch job.
+	// Incrementing the counter replaces the finished job with a new one. It is ignored for scheduled batch jobs.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Rerun int64 `json:"rerun,omitempty"`
+
 	// Maximal number of failed retries until the batch job should stop trying.
 	// This property will be defaulted by the webhook if not set.
 	// +optional
@@ -104,6 +117,43 @@ type BatchTransferSpec struct {
 	// Caution: Some write operations are only available for batch and some only for stream.
 	// +optional
 	WriteOperation WriteOperation `json:"writeOperation,omitempty"`
+
+	// Incremental transfer configuration. If set, each run only moves the data after the watermark
+	// recorded by the previous run. The first run moves a full snapshot of the data.
+	// Scheduled incremental transfers require suspended Jobs, i.e. Kubernetes 1.22 or newer,
+	// or Kubernetes 1.21 with the JobSuspend feature gate enabled.
+	// +optional
+	Incremental *IncrementalTransfer `json:"incremental,omitempty"`
+}
+
+// IncrementalTransfer configures how the runs of a BatchTransfer move only the data that is new since the previous run.
+type IncrementalTransfer struct {
+	// Column of the source whose values increase monotonically with new data, e.g. a timestamp or sequence number.
+	// Required unless the source is Kafka, whose topic offsets are used as watermark.
+	// +optional
+	WatermarkColumn string `json:"watermarkColumn,omitempty"`
+
+	// Counter to request a fresh full copy. Incrementing the counter discards the recorded watermark
+	// so that the next run moves a full snapshot of the data again.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Reset int64 `json:"reset,omitempty"`
+}
+
+// Watermark records the position up to which an incremental BatchTransfer has moved the data.
+type Watermark struct {
+	// The last value of the watermark column or the Kafka offsets of the source that have been moved.
+	// An empty value requests a full snapshot in the next run.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// UID of the job that reported the watermark
+	// +optional
+	JobUID types.UID `json:"jobUID,omitempty"`
+
+	// Time when the watermark was recorded
+	// +optional
+	RecordTime *metav1.Time `json:"recordTime,omitempty"`
 }
 
 // A datastore has a name and can be one of the following objects.
@@ -135,10 +185,75 @@ type DataStore struct {
 	// IBM Cloudant. Needs cloudant legacy credentials.
 	// +optional
 	Cloudant *Cloudant `json:"cloudant,omitempty"`
+
+	// Database data store that is accessed with a JDBC driver, e.g. Postgres or MySQL.
+	// +optional
+	JDBC *JDBC `json:"jdbc,omitempty"`
+
+	// File system data store, e.g. a persistent volume claim that is mounted into the transfer pods.
+	// +optional
+	FileSystem *FileSystem `json:"fileSystem,omitempty"`
+}
+
+// A database that is accessed with a JDBC driver, e.g. a Postgres database.
+type JDBC struct {
+	// JDBC connection URL, e.g. jdbc:postgresql://host:5432/database
+	URL string `json:"url"`
+
+	// Class name of the JDBC driver.
+	// This property will be defaulted by the webhook for well-known databases if not set.
+	// +optional
+	Driver string `json:"driver,omitempty"`
+
+	// Table to be read or written. Either a table or a query has to be specified.
+	// +optional
+	Table string `json:"table,omitempty"`
+
+	// Query whose result is read. Can only be specified for a source.
+	// +optional
+	Query string `json:"query,omitempty"`
+
+	// Numeric column by which reading the data is partitioned across the Spark executors.
+	// +optional
+	PartitionColumn string `json:"partitionColumn,omitempty"`
+
+	// Number of partitions in which the data is read. Requires a partition column.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	NumPartitions int `json:"numPartitions,omitempty"`
+
+	// Database user. Can be retrieved from vault if specified in vault parameter and is thus optional.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// Database password. Can be retrieved from vault if specified in vault parameter and is thus optional.
+	// +optional
+	Password string `json:"password,omitempty"`
+
+	// Define a secret import definition.
+	// +optional
+	SecretImport *string `json:"secretImport,omitempty"`
+
+	// Define secrets that are fetched from a Vault instance
+	// +optional
+	Vault *v1alpha1.Vault `json:"vault,omitempty"`
+}
+
+// Files on a file system of the transfer pods, e.g. on a persistent volume.
+type FileSystem struct {
+	// Path of the data. If a persistent volume claim is specified the path is relative to the root of the volume,
+	// which is mounted at /mnt/<claim> in the transfer pods. Otherwise it is an absolute path in the transfer pods.
+	Path string `json:"path"`
+
+	// Persistent volume claim that holds the data.
+	// +optional
+	PersistentVolumeClaim string `json:"persistentVolumeClaim,omitempty"`
+
+	// Data format of the files, e.g. parquet or csv.
+	// +optional
+	DataFormat string `json:"dataFormat,omitempty"`
 }
 
-// A minimalistic database connection definition.
-// Will be extended as needed.
 type Database struct {
 	// URL to Db2 instance in JDBC format
 	// Supported SSL certificates are currently certificates signed with IBM Intermediate CA
@@ -328,8 +443,15 @@ type Transformation struct {
 	Name string `json:"name,omitempty"`
 
 	// Transformation action that should be performed.
+	// Either an action or a plugin has to be specified.
+	// +optional
 	Action Action `json:"action,omitempty"`
 
+	// ID of a registered transformation plugin that performs the transformation, e.g. mask-ID.
+	// The options of the transformation are validated against the schema of the plugin.
+	// +optional
+	Plugin string `json:"plugin,omitempty"`
+
 	// Columns that are involved in this action. This property is optional as for some actions
 	// no columns have to be specified. E.g. filter is a row based transformation.
 	// +optional
@@ -354,6 +476,16 @@ const (
 	FilterRows     Action = "FilterRows"
 )
 
+// +kubebuilder:validation:Enum=Job;SparkApplication
+type TransferBackend string
+
+const (
+	// JobBackend runs the data movement in a Kubernetes Job
+	JobBackend TransferBackend = "Job"
+	// SparkApplicationBackend runs the data movement as a SparkApplication of the Spark operator
+	SparkApplicationBackend TransferBackend = "SparkApplication"
+)
+
 // +kubebuilder:validation:Enum=Batch;Stream
 type DataFlowType string
 
@@ -423,6 +555,28 @@ type Spark struct {
 	// +optional
 	//+kubebuilder:pruning:PreserveUnknownFields
 	AdditionalOptions map[string]string `json:"options,omitempty"`
+
+	// Main class of the transfer application. Only used by the SparkApplication backend.
+	// This property will be defaulted by the webhook if not set.
+	// +optional
+	MainClass string `json:"mainClass,omitempty"`
+
+	// Location of the application file that contains the main class, e.g. local:///app/mover.jar.
+	// Only used by the SparkApplication backend.
+	// This property will be defaulted by the webhook if not set.
+	// +optional
+	MainApplicationFile string `json:"mainApplicationFile,omitempty"`
+
+	// Version of Spark in the image. Only used by the SparkApplication backend.
+	// This property will be defaulted by the webhook if not set.
+	// +optional
+	SparkVersion string `json:"sparkVersion,omitempty"`
+
+	// Service account of the Spark driver, which requires permissions to manage the executor pods.
+	// Only used by the SparkApplication backend.
+	// This property will be defaulted by the webhook if not set.
+	// +optional
+	ServiceAccount string `json:"serviceAccount,omitempty"`
 }
 
 // BatchTransferStatus defines the observed state of BatchTransfer
@@ -461,6 +615,84 @@ type BatchTransferStatus struct {
 	// Information when was the last time the job was successfully scheduled.
 	// +optional
 	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// Number of the last runs of a scheduled BatchTransfer that have failed in a row.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+
+	// Conditions of the BatchTransfer, e.g. whether the failures of a scheduled BatchTransfer exceed MaxFailedRetries.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// The generation of the BatchTransfer spec that has been propagated to the Job, CronJob and configuration secret.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The value of the rerun counter of the spec that has been handled.
+	// +optional
+	ObservedRerun int64 `json:"observedRerun,omitempty"`
+
+	// Watermark of an incremental BatchTransfer that the next run starts from.
+	// +optional
+	Watermark *Watermark `json:"watermark,omitempty"`
+
+	// The value of the reset counter of the incremental configuration that has been handled.
+	// +optional
+	ObservedReset int64 `json:"observedReset,omitempty"`
+
+	// Metrics reported by the last successful run of the BatchTransfer.
+	// +optional
+	LastRunMetrics *TransferMetrics `json:"lastRunMetrics,omitempty"`
+
+	// Progress reported so far by the active run of the BatchTransfer.
+	// +optional
+	Progress *TransferMetrics `json:"progress,omitempty"`
+}
+
+// TransferMetrics are the metrics that a run of a transfer reports to the controller
+type TransferMetrics struct {
+	// The job that reported the metrics
+	// +optional
+	JobUID types.UID `json:"jobUID,omitempty"`
+
+	// Number of records that have been read from the source
+	// +optional
+	RecordsRead int64 `json:"recordsRead,omitempty"`
+
+	// Number of records that have been written to the destination
+	// +optional
+	RecordsWritten int64 `json:"recordsWritten,omitempty"`
+
+	// Number of records that have been removed by transformations
+	// +optional
+	RecordsFiltered int64 `json:"recordsFiltered,omitempty"`
+
+	// Number of bytes that have been written to the destination
+	// +optional
+	BytesMoved int64 `json:"bytesMoved,omitempty"`
+
+	// Duration of the run
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// Number of records that have been changed or removed by each transformation
+	// +optional
+	Transformations []TransformationMetrics `json:"transformations,omitempty"`
+
+	// Time when the metrics have been reported
+	// +optional
+	ReportTime *metav1.Time `json:"reportTime,omitempty"`
+}
+
+// TransformationMetrics are the metrics of a single transformation of a transfer
+type TransformationMetrics struct {
+	// Name of the transformation
+	Name string `json:"name"`
+
+	// Number of records that have been changed or removed by the transformation
+	// +optional
+	RecordsAffected int64 `json:"recordsAffected,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=STARTING;RUNNING;SUCCEEDED;FAILED
@@ -474,6 +706,14 @@ const (
 	Failed    BatchStatus = "FAILED"
 )
 
+// FailedRetriesExceededCondition is true if the consecutive failures of a scheduled BatchTransfer exceed MaxFailedRetries
+const FailedRetriesExceededCondition = "FailedRetriesExceeded"
+
+// SuspendedRunsUnsupportedCondition is true if the runs of a scheduled incremental BatchTransfer are not created suspended
+// because the cluster does not support suspended Jobs. The runs then may start before the watermark of the previous
+// run has been recorded and copy data again.
+const SuspendedRunsUnsupportedCondition = "SuspendedRunsUnsupported"
+
 // the following to annotations are crucial as they allow to update the status of the BatchTransfer CRD
 // as a sub-resource. If not provided, then the controller will fail...
 // limit the scope of this CRD to a namespace. This is the default but we want to make it explicit.
@@ -509,6 +749,7 @@ const (
 	BatchtransferBinary          = "/mover"
 	ConfigSecretVolumeName       = "conf-secret"
 	ConfigSecretMountPath        = "/etc/mover"
+	FileSystemMountRoot          = "/mnt"
 )
 
 // register above definition...
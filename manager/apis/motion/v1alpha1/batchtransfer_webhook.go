@@ -9,10 +9,12 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"fybrik.io/fybrik/pkg/transformations"
 	"github.com/robfig/cron"
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -38,6 +40,11 @@ var _ webhook.Defaulter = &BatchTransfer{}
 const DefaultFailedJobHistoryLimit = 5
 const DefaultSuccessfulJobHistoryLimit = 5
 
+const DefaultSparkMainClass = "io.fybrik.mover.Transfer"
+const DefaultSparkMainApplicationFile = "local:///app/mover.jar"
+const DefaultSparkVersion = "3.0.1"
+const DefaultSparkServiceAccount = "spark"
+
 // Default implements webhook.Defaulter so a webhook will be registered for the type
 func (r *BatchTransfer) Default() {
 	log.Printf("Defaulting batchtransfer %s", r.Name)
@@ -71,6 +78,14 @@ func (r *BatchTransfer) Default() {
 		r.Spec.SuccessfulJobHistoryLimit = DefaultSuccessfulJobHistoryLimit
 	}
 
+	if r.Spec.Backend == "" {
+		r.Spec.Backend = JobBackend
+	}
+
+	if r.Spec.Spark == nil && r.Spec.Backend == SparkApplicationBackend {
+		r.Spec.Spark = &Spark{}
+	}
+
 	if r.Spec.Spark != nil {
 		if r.Spec.Spark.Image == "" {
 			r.Spec.Spark.Image = r.Spec.Image
@@ -79,6 +94,10 @@ func (r *BatchTransfer) Default() {
 		if r.Spec.Spark.ImagePullPolicy == "" {
 			r.Spec.Spark.ImagePullPolicy = r.Spec.ImagePullPolicy
 		}
+
+		if r.Spec.Backend == SparkApplicationBackend {
+			defaultSparkApplication(r.Spec.Spark)
+		}
 	}
 
 	if env, b := os.LookupEnv("NO_FINALIZER"); b {
@@ -93,7 +112,12 @@ func (r *BatchTransfer) Default() {
 	defaultDataStoreDescription(&r.Spec.Destination)
 
 	if r.Spec.WriteOperation == "" {
-		r.Spec.WriteOperation = Overwrite
+		if r.Spec.Incremental != nil {
+			// Incremental runs add to the data moved by the previous runs
+			r.Spec.WriteOperation = Append
+		} else {
+			r.Spec.WriteOperation = Overwrite
+		}
 	}
 
 	if r.Spec.DataFlowType == "" {
@@ -109,6 +133,21 @@ func (r *BatchTransfer) Default() {
 	}
 }
 
+func defaultSparkApplication(spark *Spark) {
+	if spark.MainClass == "" {
+		spark.MainClass = DefaultSparkMainClass
+	}
+	if spark.MainApplicationFile == "" {
+		spark.MainApplicationFile = DefaultSparkMainApplicationFile
+	}
+	if spark.SparkVersion == "" {
+		spark.SparkVersion = DefaultSparkVersion
+	}
+	if spark.ServiceAccount == "" {
+		spark.ServiceAccount = DefaultSparkServiceAccount
+	}
+}
+
 func defaultDataStoreDescription(dataStore *DataStore) {
 	if len(dataStore.Description) == 0 {
 		switch {
@@ -120,8 +159,46 @@ func defaultDataStoreDescription(dataStore *DataStore) {
 			dataStore.Description = "s3://" + dataStore.S3.Bucket + "/" + dataStore.S3.ObjectKey
 		case dataStore.Cloudant != nil:
 			dataStore.Description = "cloudant://" + dataStore.Cloudant.Host + "/" + dataStore.Cloudant.Database
+		case dataStore.JDBC != nil:
+			if len(dataStore.JDBC.Table) != 0 {
+				dataStore.Description = dataStore.JDBC.URL + "/" + dataStore.JDBC.Table
+			} else {
+				dataStore.Description = dataStore.JDBC.URL
+			}
+		case dataStore.FileSystem != nil:
+			if len(dataStore.FileSystem.PersistentVolumeClaim) != 0 {
+				dataStore.Description = "pvc://" + dataStore.FileSystem.PersistentVolumeClaim + "/" + strings.TrimPrefix(dataStore.FileSystem.Path, "/")
+			} else {
+				dataStore.Description = "file://" + dataStore.FileSystem.Path
+			}
 		}
 	}
+	if dataStore.JDBC != nil && len(dataStore.JDBC.Driver) == 0 {
+		dataStore.JDBC.Driver = DefaultJDBCDriver(dataStore.JDBC.URL)
+	}
+}
+
+// jdbcDrivers are the driver classes of well-known databases by the sub-protocol of their JDBC URLs
+var jdbcDrivers = map[string]string{
+	"db2":        "com.ibm.db2.jcc.DB2Driver",
+	"postgresql": "org.postgresql.Driver",
+	"mysql":      "com.mysql.cj.jdbc.Driver",
+	"mariadb":    "org.mariadb.jdbc.Driver",
+	"sqlserver":  "com.microsoft.sqlserver.jdbc.SQLServerDriver",
+	"oracle":     "oracle.jdbc.OracleDriver",
+}
+
+var jdbcURLPattern = regexp.MustCompile("^jdbc:([a-z0-9]+):")
+var jdbcHostPattern = regexp.MustCompile("^jdbc:[a-z0-9]+://([^/:;?]+)")
+
+// DefaultJDBCDriver returns the driver class for the database of the given JDBC URL,
+// or an empty string if the database is not known.
+func DefaultJDBCDriver(jdbcURL string) string {
+	match := jdbcURLPattern.FindStringSubmatch(jdbcURL)
+	if match == nil {
+		return ""
+	}
+	return jdbcDrivers[match[1]]
 }
 
 // TODO(user): change verbs to "verbs=create;update;delete" if you want to enable deletion validation.
@@ -170,6 +247,24 @@ func (r *BatchTransfer) validateBatchTransfer() error {
 		allErrs = append(allErrs, field.Invalid(specField.Child("failedJobHistoryLimit"),
 			r.Spec.FailedJobHistoryLimit, "'failedJobHistoryLimit' has to be between 0 and 20!"))
 	}
+	if r.Spec.Destination.JDBC != nil && len(r.Spec.Destination.JDBC.Query) != 0 {
+		allErrs = append(allErrs, field.Invalid(specField.Child("destination", "jdbc", "query"),
+			r.Spec.Destination.JDBC.Query, "A query cannot be specified for a destination!"))
+	}
+	allErrs = append(allErrs, validateTransformations(specField.Child("transformation"), r.Spec.Transformation)...)
+	if r.Spec.Incremental != nil {
+		allErrs = append(allErrs, validateIncremental(specField, &r.Spec)...)
+	}
+	if r.Spec.Backend == SparkApplicationBackend {
+		if len(r.Spec.Schedule) > 0 {
+			allErrs = append(allErrs, field.Invalid(specField.Child("backend"), r.Spec.Backend,
+				"Scheduled transfers are not supported by the SparkApplication backend!"))
+		}
+		if r.Spec.Source.S3 != nil && r.Spec.Source.S3.DataFormat == "binary" {
+			allErrs = append(allErrs, field.Invalid(specField.Child("backend"), r.Spec.Backend,
+				"Binary copies are not supported by the SparkApplication backend!"))
+		}
+	}
 
 	if len(allErrs) == 0 {
 		return nil
@@ -183,6 +278,10 @@ func (r *BatchTransfer) validateBatchTransfer() error {
 func validateDataStore(path *field.Path, store *DataStore) []*field.Error {
 	var allErrs []*field.Error
 
+	if numDataStores(store) != 1 {
+		allErrs = append(allErrs, field.Invalid(path, store.Description, "Exactly one data store has to be specified!"))
+	}
+
 	if store.Database != nil {
 		var db = store.Database
 		databasePath := path.Child("database")
@@ -271,6 +370,121 @@ func validateDataStore(path *field.Path, store *DataStore) []*field.Error {
 		}
 	}
 
+	if store.JDBC != nil {
+		allErrs = append(allErrs, validateJDBC(path.Child("jdbc"), store.JDBC)...)
+	}
+
+	if store.FileSystem != nil {
+		allErrs = append(allErrs, validateFileSystem(path.Child("fileSystem"), store.FileSystem)...)
+	}
+
+	return allErrs
+}
+
+// Returns the number of data stores that are specified
+func numDataStores(store *DataStore) int {
+	count := 0
+	for _, specified := range []bool{store.Database != nil, store.S3 != nil, store.Kafka != nil,
+		store.Cloudant != nil, store.JDBC != nil, store.FileSystem != nil} {
+		if specified {
+			count++
+		}
+	}
+	return count
+}
+
+func validateJDBC(jdbcPath *field.Path, jdbc *JDBC) []*field.Error {
+	var allErrs []*field.Error
+
+	if !jdbcURLPattern.MatchString(jdbc.URL) {
+		allErrs = append(allErrs, field.Invalid(jdbcPath.Child("url"), jdbc.URL, "Invalid JDBC string!"))
+	} else if host := jdbcHostPattern.FindStringSubmatch(jdbc.URL); host != nil {
+		if msgs := validationutils.IsDNS1123Subdomain(host[1]); len(msgs) != 0 {
+			allErrs = append(allErrs, field.Invalid(jdbcPath.Child("url"), jdbc.URL, "Invalid database host!"))
+		}
+	}
+
+	if len(jdbc.Driver) == 0 {
+		allErrs = append(allErrs, field.Invalid(jdbcPath.Child("driver"), jdbc.Driver,
+			"The driver cannot be derived from the JDBC string and has to be specified!"))
+	}
+
+	if len(jdbc.Table) == 0 && len(jdbc.Query) == 0 {
+		allErrs = append(allErrs, field.Invalid(jdbcPath, jdbc.Table, "Either a table or a query has to be specified!"))
+	}
+	if len(jdbc.Table) != 0 && len(jdbc.Query) != 0 {
+		allErrs = append(allErrs, field.Invalid(jdbcPath, jdbc.Query, "Can only set table or query!"))
+	}
+
+	if jdbc.NumPartitions > 0 && len(jdbc.PartitionColumn) == 0 {
+		allErrs = append(allErrs, field.Invalid(jdbcPath.Child("partitionColumn"), jdbc.PartitionColumn,
+			"A partition column is required to read the data in partitions!"))
+	}
+
+	if len(jdbc.Password) != 0 && jdbc.Vault != nil {
+		allErrs = append(allErrs, field.Invalid(jdbcPath, jdbc.Vault, "Can only set vault or password!"))
+	}
+
+	return allErrs
+}
+
+func validateFileSystem(fileSystemPath *field.Path, fileSystem *FileSystem) []*field.Error {
+	var allErrs []*field.Error
+
+	if len(fileSystem.Path) == 0 {
+		allErrs = append(allErrs, field.Invalid(fileSystemPath.Child("path"), fileSystem.Path, validationutils.EmptyError()))
+	}
+
+	if len(fileSystem.PersistentVolumeClaim) != 0 {
+		if msgs := validationutils.IsDNS1123Subdomain(fileSystem.PersistentVolumeClaim); len(msgs) != 0 {
+			allErrs = append(allErrs, field.Invalid(fileSystemPath.Child("persistentVolumeClaim"),
+				fileSystem.PersistentVolumeClaim, strings.Join(msgs, ", ")))
+		}
+		for _, element := range strings.Split(fileSystem.Path, "/") {
+			if element == ".." {
+				allErrs = append(allErrs, field.Invalid(fileSystemPath.Child("path"), fileSystem.Path,
+					"The path cannot leave the persistent volume!"))
+				break
+			}
+		}
+	} else if len(fileSystem.Path) != 0 && !path.IsAbs(fileSystem.Path) {
+		allErrs = append(allErrs, field.Invalid(fileSystemPath.Child("path"), fileSystem.Path,
+			"The path has to be absolute if no persistent volume claim is specified!"))
+	}
+
+	return allErrs
+}
+
+// Validates that every transformation either has an action or a registered plugin and
+// that its options conform to the schema of the plugin.
+func validateTransformations(path *field.Path, transformationList []Transformation) []*field.Error {
+	var allErrs []*field.Error
+	for i := range transformationList {
+		transformation := &transformationList[i]
+		transformationPath := path.Index(i)
+		if (transformation.Action == "") == (transformation.Plugin == "") {
+			allErrs = append(allErrs, field.Invalid(transformationPath, transformation.Name,
+				"Either an action or a plugin has to be specified!"))
+			continue
+		}
+		id := transformation.Plugin
+		idPath := transformationPath.Child("plugin")
+		if id == "" {
+			id = string(transformation.Action)
+			idPath = transformationPath.Child("action")
+		}
+		if transformations.DefaultRegistry.Get(id) == nil {
+			allErrs = append(allErrs, field.Invalid(idPath, id, "Unknown transformation plugin!"))
+			continue
+		}
+		violations, err := transformations.DefaultRegistry.ValidateOptions(id, transformation.Options)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(transformationPath.Child("options"), transformation.Options, err.Error()))
+		}
+		for _, violation := range violations {
+			allErrs = append(allErrs, field.Invalid(transformationPath.Child("options"), transformation.Options, violation))
+		}
+	}
 	return allErrs
 }
 
@@ -290,6 +504,23 @@ func (r *BatchTransfer) validateBatchTransferSpec() *field.Error {
 	return nil
 }
 
+// Validates the incremental configuration of a BatchTransfer.
+// The watermark has to be derived from a column of the source unless the source is Kafka,
+// and the write operation must keep the data moved by previous runs.
+func validateIncremental(specField *field.Path, spec *BatchTransferSpec) []*field.Error {
+	var allErrs []*field.Error
+	incrementalPath := specField.Child("incremental")
+	if spec.Source.Kafka == nil && len(spec.Incremental.WatermarkColumn) == 0 {
+		allErrs = append(allErrs, field.Invalid(incrementalPath.Child("watermarkColumn"), spec.Incremental.WatermarkColumn,
+			"A watermark column is required for incremental transfers from sources other than Kafka!"))
+	}
+	if spec.WriteOperation == Overwrite {
+		allErrs = append(allErrs, field.Invalid(specField.Child("writeOperation"), spec.WriteOperation,
+			"Incremental transfers cannot overwrite the data of previous runs!"))
+	}
+	return allErrs
+}
+
 func validateScheduleFormat(schedule string, fldPath *field.Path) *field.Error {
 	if _, err := cron.ParseStandard(schedule); err != nil {
 		return field.Invalid(fldPath, schedule, err.Error())
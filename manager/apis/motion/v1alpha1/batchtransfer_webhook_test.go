@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"fybrik.io/fybrik/pkg/transformations"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -279,3 +280,202 @@ func TestDefaultingS3Bucket(t *testing.T) {
 	_ = os.Unsetenv("SECRET_PROVIDER_URL")
 	_ = os.Unsetenv("SECRET_PROVIDER_ROLE")
 }
+
+func TestIncrementalBatchTransfer(t *testing.T) {
+	t.Parallel()
+	batchTransfer := BatchTransfer{
+		Spec: BatchTransferSpec{
+			Source: DataStore{
+				Database: &Database{
+					Db2URL:   "jdbc:db2://host:1234/DB",
+					Table:    "MY.TABLE",
+					User:     "user",
+					Password: "password",
+				},
+			},
+			Destination: DataStore{
+				S3: &S3{
+					Endpoint:   "my.endpoint",
+					Bucket:     "myBucket",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+			Schedule:    "0 * * * *",
+			Incremental: &IncrementalTransfer{},
+		},
+	}
+
+	batchTransfer.Default()
+	assert.Equal(t, Append, batchTransfer.Spec.WriteOperation)
+
+	err := batchTransfer.validateBatchTransfer()
+	assert.NotNil(t, err, "A missing watermark column should be reported")
+	assert.Contains(t, err.Error(), "spec.incremental.watermarkColumn")
+
+	batchTransfer.Spec.Incremental.WatermarkColumn = "updated_at"
+	batchTransfer.Spec.WriteOperation = Overwrite
+	err = batchTransfer.validateBatchTransfer()
+	assert.NotNil(t, err, "Overwriting the data of previous runs should be reported")
+	assert.Contains(t, err.Error(), "spec.writeOperation")
+
+	batchTransfer.Spec.WriteOperation = Append
+	err = batchTransfer.validateBatchTransfer()
+	assert.Nil(t, err, "No error should be found")
+}
+
+func TestSparkApplicationBatchTransfer(t *testing.T) {
+	t.Parallel()
+	batchTransfer := BatchTransfer{
+		Spec: BatchTransferSpec{
+			Source: DataStore{
+				Database: &Database{
+					Db2URL:   "jdbc:db2://host:1234/DB",
+					Table:    "MY.TABLE",
+					User:     "user",
+					Password: "password",
+				},
+			},
+			Destination: DataStore{
+				S3: &S3{
+					Endpoint:   "my.endpoint",
+					Bucket:     "myBucket",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+			Backend: SparkApplicationBackend,
+		},
+	}
+
+	batchTransfer.Default()
+	assert.NotNil(t, batchTransfer.Spec.Spark)
+	assert.Equal(t, batchTransfer.Spec.Image, batchTransfer.Spec.Spark.Image)
+	assert.Equal(t, DefaultSparkMainClass, batchTransfer.Spec.Spark.MainClass)
+	assert.Equal(t, DefaultSparkServiceAccount, batchTransfer.Spec.Spark.ServiceAccount)
+
+	err := batchTransfer.validateBatchTransfer()
+	assert.Nil(t, err, "No error should be found")
+
+	batchTransfer.Spec.Schedule = "0 * * * *"
+	err = batchTransfer.validateBatchTransfer()
+	assert.NotNil(t, err, "A schedule should be reported")
+	assert.Contains(t, err.Error(), "spec.backend")
+}
+
+func TestJDBCDataStore(t *testing.T) {
+	t.Parallel()
+	datastore := DataStore{
+		JDBC: &JDBC{
+			URL:             "jdbc:postgresql://postgres.example.com:5432/sales",
+			Table:           "public.orders",
+			PartitionColumn: "id",
+			NumPartitions:   8,
+		},
+	}
+	path := field.NewPath("spec", "source")
+
+	defaultDataStoreDescription(&datastore)
+	assert.Equal(t, "org.postgresql.Driver", datastore.JDBC.Driver)
+	assert.Equal(t, "jdbc:postgresql://postgres.example.com:5432/sales/public.orders", datastore.Description)
+	assert.Nil(t, validateDataStore(path, &datastore))
+
+	// a table and a query
+	datastore.JDBC.Query = "select * from public.orders"
+	err := validateDataStore(path, &datastore)
+	assert.Len(t, err, 1)
+	assert.Equal(t, "spec.source.jdbc", err[0].Field)
+
+	// unknown database without driver
+	datastore.JDBC.Query = ""
+	datastore.JDBC.URL = "jdbc:unknowndb://host:1234/db"
+	datastore.JDBC.Driver = ""
+	defaultDataStoreDescription(&datastore)
+	err = validateDataStore(path, &datastore)
+	assert.Len(t, err, 1)
+	assert.Equal(t, "spec.source.jdbc.driver", err[0].Field)
+
+	// invalid url and partitioning without column
+	datastore.JDBC.URL = "postgres://host/db"
+	datastore.JDBC.Driver = "org.postgresql.Driver"
+	datastore.JDBC.PartitionColumn = ""
+	err = validateDataStore(path, &datastore)
+	assert.Len(t, err, 2)
+	assert.Equal(t, "spec.source.jdbc.url", err[0].Field)
+	assert.Equal(t, "spec.source.jdbc.partitionColumn", err[1].Field)
+}
+
+func TestFileSystemDataStore(t *testing.T) {
+	t.Parallel()
+	datastore := DataStore{
+		FileSystem: &FileSystem{
+			Path:                  "exports/orders",
+			PersistentVolumeClaim: "data",
+			DataFormat:            "parquet",
+		},
+	}
+	path := field.NewPath("spec", "destination")
+
+	defaultDataStoreDescription(&datastore)
+	assert.Equal(t, "pvc://data/exports/orders", datastore.Description)
+	assert.Nil(t, validateDataStore(path, &datastore))
+
+	// leaving the volume
+	datastore.FileSystem.Path = "../orders"
+	err := validateDataStore(path, &datastore)
+	assert.Len(t, err, 1)
+	assert.Equal(t, "spec.destination.fileSystem.path", err[0].Field)
+
+	// relative local path
+	datastore.FileSystem.PersistentVolumeClaim = ""
+	datastore.FileSystem.Path = "orders"
+	err = validateDataStore(path, &datastore)
+	assert.Len(t, err, 1)
+	assert.Equal(t, "spec.destination.fileSystem.path", err[0].Field)
+
+	datastore.FileSystem.Path = "/tmp/orders"
+	assert.Nil(t, validateDataStore(path, &datastore))
+
+	// multiple data stores
+	datastore.S3 = &S3{Endpoint: "my.endpoint", Bucket: "myBucket", ObjectKey: "obj.parq"}
+	err = validateDataStore(path, &datastore)
+	assert.Len(t, err, 1)
+	assert.Equal(t, "spec.destination", err[0].Field)
+}
+
+func TestTransformationPlugins(t *testing.T) {
+	t.Parallel()
+	path := field.NewPath("spec", "transformation")
+
+	// built-in actions and registered plugins are valid
+	valid := []Transformation{
+		{Name: "redact", Action: RedactColumns, Columns: []string{"name"}},
+		{Name: "remove", Plugin: "removed-ID", Columns: []string{"ssn"}},
+	}
+	assert.Nil(t, validateTransformations(path, valid))
+
+	invalid := []Transformation{
+		{Name: "nothing"},
+		{Name: "both", Action: RemoveColumns, Plugin: "removed-ID"},
+		{Name: "unknown", Plugin: "unknown-ID"},
+	}
+	err := validateTransformations(path, invalid)
+	assert.Len(t, err, 3)
+	assert.Equal(t, "spec.transformation[0]", err[0].Field)
+	assert.Equal(t, "spec.transformation[1]", err[1].Field)
+	assert.Equal(t, "spec.transformation[2].plugin", err[2].Field)
+
+	// options are validated against the schema of the plugin
+	assert.Nil(t, transformations.DefaultRegistry.Register(&transformations.Plugin{
+		ID:     "test-mask-ID",
+		Image:  "registry.example.com/plugins/masking:1.0",
+		Jar:    "masking.jar",
+		Schema: []byte(`{"type":"object","properties":{"maskChar":{"type":"string","maxLength":1}}}`),
+	}))
+	masking := []Transformation{{Name: "mask", Plugin: "test-mask-ID", Options: map[string]string{"maskChar": "#"}}}
+	assert.Nil(t, validateTransformations(path, masking))
+	masking[0].Options["maskChar"] = "##"
+	err = validateTransformations(path, masking)
+	assert.Len(t, err, 1)
+	assert.Equal(t, "spec.transformation[0].options", err[0].Field)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"fmt"
+	"path"
+
+	"fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+)
+
+// DataStoreFromCatalog maps a data store as returned by a data catalog connector to a data store of a transfer.
+// The credentials of the data store are fetched from the given Vault location, if any.
+// Db2 and JDBC data stores are accessed with JDBC. The driver of a JDBC data store defaults to the driver of a
+// well-known database if it is not given.
+// Local data stores are mapped to file systems whose path is the name of the data store.
+func DataStoreFromCatalog(store *pb.DataStore, dataFormat string, vault *v1alpha1.Vault) (*DataStore, error) {
+	if store == nil {
+		return nil, fmt.Errorf("no data store specified")
+	}
+	switch store.Type {
+	case pb.DataStore_S3:
+		s3 := store.GetS3()
+		if s3 == nil {
+			return nil, fmt.Errorf("missing details of the S3 data store %s", store.Name)
+		}
+		return &DataStore{S3: &S3{
+			Endpoint:   s3.Endpoint,
+			Region:     s3.Region,
+			Bucket:     s3.Bucket,
+			ObjectKey:  s3.ObjectKey,
+			DataFormat: dataFormat,
+			Vault:      vault,
+		}}, nil
+	case pb.DataStore_KAFKA:
+		kafka := store.GetKafka()
+		if kafka == nil {
+			return nil, fmt.Errorf("missing details of the Kafka data store %s", store.Name)
+		}
+		return &DataStore{Kafka: &Kafka{
+			KafkaBrokers:          kafka.BootstrapServers,
+			SchemaRegistryURL:     kafka.SchemaRegistry,
+			SecurityProtocol:      kafka.SecurityProtocol,
+			SaslMechanism:         kafka.SaslMechanism,
+			SslTruststore:         kafka.SslTruststore,
+			SslTruststorePassword: kafka.SslTruststorePassword,
+			KafkaTopic:            kafka.TopicName,
+			KeyDeserializer:       kafka.KeyDeserializer,
+			ValueDeserializer:     kafka.ValueDeserializer,
+			DataFormat:            dataFormat,
+			Vault:                 vault,
+		}}, nil
+	case pb.DataStore_DB2:
+		db2 := store.GetDb2()
+		if db2 == nil {
+			return nil, fmt.Errorf("missing details of the Db2 data store %s", store.Name)
+		}
+		jdbcURL := db2JDBCURL(db2)
+		return &DataStore{JDBC: &JDBC{
+			URL:    jdbcURL,
+			Driver: DefaultJDBCDriver(jdbcURL),
+			Table:  db2.Table,
+			Vault:  vault,
+		}}, nil
+	case pb.DataStore_JDBC:
+		jdbc := store.GetJdbc()
+		if jdbc == nil {
+			return nil, fmt.Errorf("missing details of the JDBC data store %s", store.Name)
+		}
+		driver := jdbc.Driver
+		if driver == "" {
+			driver = DefaultJDBCDriver(jdbc.Url)
+		}
+		return &DataStore{JDBC: &JDBC{
+			URL:    jdbc.Url,
+			Driver: driver,
+			Table:  jdbc.Table,
+			Vault:  vault,
+		}}, nil
+	case pb.DataStore_LOCAL:
+		if !path.IsAbs(store.Name) {
+			return nil, fmt.Errorf("the name of the local data store %s is not an absolute path", store.Name)
+		}
+		return &DataStore{FileSystem: &FileSystem{
+			Path:       store.Name,
+			DataFormat: dataFormat,
+		}}, nil
+	}
+	return nil, fmt.Errorf("unsupported data store type %s", store.Type.String())
+}
+
+// Returns the JDBC URL of a Db2 data store
+func db2JDBCURL(db2 *pb.Db2DataStore) string {
+	jdbcURL := "jdbc:db2://" + db2.Url
+	if db2.Port != "" {
+		jdbcURL += ":" + db2.Port
+	}
+	jdbcURL += "/" + db2.Database
+	if db2.Ssl == "true" {
+		jdbcURL += ":sslConnection=true;"
+	}
+	return jdbcURL
+}
@@ -0,0 +1,70 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataStoreFromCatalog(t *testing.T) {
+	t.Parallel()
+	vault := &v1alpha1.Vault{Role: "module", SecretPath: "/v1/secret/data", Address: "http://vault:8200", AuthPath: "/v1/auth/kubernetes/login"}
+
+	// Db2 data store given by its host
+	store, err := DataStoreFromCatalog(&pb.DataStore{
+		Type: pb.DataStore_DB2,
+		Db2:  &pb.Db2DataStore{Url: "db2.example.com", Port: "50000", Database: "BLUDB", Table: "SALES.ORDERS", Ssl: "true"},
+	}, "table", vault)
+	assert.Nil(t, err)
+	assert.NotNil(t, store.JDBC)
+	assert.Equal(t, "jdbc:db2://db2.example.com:50000/BLUDB:sslConnection=true;", store.JDBC.URL)
+	assert.Equal(t, "com.ibm.db2.jcc.DB2Driver", store.JDBC.Driver)
+	assert.Equal(t, "SALES.ORDERS", store.JDBC.Table)
+	assert.Equal(t, vault, store.JDBC.Vault)
+
+	// JDBC data store of a well-known database
+	store, err = DataStoreFromCatalog(&pb.DataStore{
+		Type: pb.DataStore_JDBC,
+		Jdbc: &pb.JdbcDataStore{Url: "jdbc:postgresql://postgres:5432/sales", Table: "orders"},
+	}, "table", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "jdbc:postgresql://postgres:5432/sales", store.JDBC.URL)
+	assert.Equal(t, "org.postgresql.Driver", store.JDBC.Driver)
+	assert.Nil(t, store.JDBC.Vault)
+
+	// JDBC data store with an explicit driver
+	store, err = DataStoreFromCatalog(&pb.DataStore{
+		Type: pb.DataStore_JDBC,
+		Jdbc: &pb.JdbcDataStore{Url: "jdbc:sqlserver://mssql:1433", Table: "orders", Driver: "com.example.Driver"},
+	}, "table", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "com.example.Driver", store.JDBC.Driver)
+
+	_, err = DataStoreFromCatalog(&pb.DataStore{Type: pb.DataStore_JDBC}, "table", nil)
+	assert.NotNil(t, err)
+
+	// S3 data store
+	store, err = DataStoreFromCatalog(&pb.DataStore{
+		Type: pb.DataStore_S3,
+		S3:   &pb.S3DataStore{Endpoint: "s3.eu.cloud-object-storage.appdomain.cloud", Bucket: "data", ObjectKey: "orders.parquet"},
+	}, "parquet", vault)
+	assert.Nil(t, err)
+	assert.Equal(t, "data", store.S3.Bucket)
+	assert.Equal(t, "parquet", store.S3.DataFormat)
+
+	// local data store
+	store, err = DataStoreFromCatalog(&pb.DataStore{Type: pb.DataStore_LOCAL, Name: "/data/orders"}, "csv", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "/data/orders", store.FileSystem.Path)
+
+	_, err = DataStoreFromCatalog(&pb.DataStore{Type: pb.DataStore_LOCAL, Name: "orders"}, "csv", nil)
+	assert.NotNil(t, err)
+
+	_, err = DataStoreFromCatalog(&pb.DataStore{Type: pb.DataStore_UNKNOWN}, "csv", nil)
+	assert.NotNil(t, err)
+}
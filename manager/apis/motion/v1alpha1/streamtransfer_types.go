@@ -75,6 +75,30 @@ type StreamTransferSpec struct {
 	// Caution: Some write operations are only available for batch and some only for stream.
 	// +optional
 	WriteOperation WriteOperation `json:"writeOperation,omitempty"`
+
+	// Thresholds of the lag and the errors of the stream. A stream that exceeds a threshold is failing
+	// and is restarted by the controller.
+	// +optional
+	Health *StreamHealth `json:"health,omitempty"`
+}
+
+// StreamHealth defines when a stream is failing and how often a failing stream is restarted
+type StreamHealth struct {
+	// Maximum number of records that the stream may lag behind the source. The lag is not limited if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxLag int64 `json:"maxLag,omitempty"`
+
+	// Maximum number of micro batches that may fail in a row. The errors are not limited if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxErrors int32 `json:"maxErrors,omitempty"`
+
+	// Maximum number of times that a failing stream is restarted in a row. The delay between restarts
+	// doubles with every restart. A failing stream is not restarted if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxRestarts int32 `json:"maxRestarts,omitempty"`
 }
 
 // StreamTransferStatus defines the observed state of StreamTransfer
@@ -88,6 +112,26 @@ type StreamTransferStatus struct {
 
 	// +optional
 	Error string `json:"error,omitempty"`
+
+	// Number of records that the stream lags behind the source as last reported by the stream
+	// +optional
+	Lag *int64 `json:"lag,omitempty"`
+
+	// Number of records per second that the stream has moved as last reported by the stream
+	// +optional
+	RecordsPerSecond int64 `json:"recordsPerSecond,omitempty"`
+
+	// Time of the last report of the stream
+	// +optional
+	LastReportTime *metav1.Time `json:"lastReportTime,omitempty"`
+
+	// Number of times that the failing stream has been restarted in a row
+	// +optional
+	Restarts int32 `json:"restarts,omitempty"`
+
+	// Time of the last restart of the failing stream
+	// +optional
+	LastRestartTime *metav1.Time `json:"lastRestartTime,omitempty"`
 }
 
 // +kubebuilder:validation:Enum=STARTING;RUNNING;STOPPED;FAILING
@@ -106,6 +150,7 @@ const (
 // +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.spec.source.description`
 // +kubebuilder:printcolumn:name="Destination",type=string,JSONPath=`.spec.destination.description`
 // +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.status`
+// +kubebuilder:printcolumn:name="Lag",type=integer,JSONPath=`.status.lag`
 // +kubebuilder:resource:scope=Namespaced
 
 // StreamTransfer is the Schema for the streamtransfers API
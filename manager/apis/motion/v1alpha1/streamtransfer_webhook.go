@@ -118,6 +118,7 @@ func (r *StreamTransfer) validateStreamTransfer() error {
 	if err := validateDataStore(specField.Child("destination"), &r.Spec.Destination); err != nil {
 		allErrs = append(allErrs, err...)
 	}
+	allErrs = append(allErrs, validateTransformations(specField.Child("transformation"), r.Spec.Transformation)...)
 
 	if len(allErrs) == 0 {
 		return nil
@@ -10,6 +10,7 @@ package v1alpha1
 import (
 	appv1alpha1 "fybrik.io/fybrik/manager/apis/app/v1alpha1"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -89,6 +90,11 @@ func (in *BatchTransferSpec) DeepCopyInto(out *BatchTransferSpec) {
 		*out = new(Spark)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Incremental != nil {
+		in, out := &in.Incremental, &out.Incremental
+		*out = new(IncrementalTransfer)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BatchTransferSpec.
@@ -131,6 +137,28 @@ func (in *BatchTransferStatus) DeepCopyInto(out *BatchTransferStatus) {
 		in, out := &in.LastScheduleTime, &out.LastScheduleTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Watermark != nil {
+		in, out := &in.Watermark, &out.Watermark
+		*out = new(Watermark)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LastRunMetrics != nil {
+		in, out := &in.LastRunMetrics, &out.LastRunMetrics
+		*out = new(TransferMetrics)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Progress != nil {
+		in, out := &in.Progress, &out.Progress
+		*out = new(TransferMetrics)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BatchTransferStatus.
@@ -191,6 +219,16 @@ func (in *DataStore) DeepCopyInto(out *DataStore) {
 		*out = new(Cloudant)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.JDBC != nil {
+		in, out := &in.JDBC, &out.JDBC
+		*out = new(JDBC)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FileSystem != nil {
+		in, out := &in.FileSystem, &out.FileSystem
+		*out = new(FileSystem)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataStore.
@@ -228,6 +266,61 @@ func (in *Database) DeepCopy() *Database {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileSystem) DeepCopyInto(out *FileSystem) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileSystem.
+func (in *FileSystem) DeepCopy() *FileSystem {
+	if in == nil {
+		return nil
+	}
+	out := new(FileSystem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IncrementalTransfer) DeepCopyInto(out *IncrementalTransfer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IncrementalTransfer.
+func (in *IncrementalTransfer) DeepCopy() *IncrementalTransfer {
+	if in == nil {
+		return nil
+	}
+	out := new(IncrementalTransfer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *JDBC) DeepCopyInto(out *JDBC) {
+	*out = *in
+	if in.SecretImport != nil {
+		in, out := &in.SecretImport, &out.SecretImport
+		*out = new(string)
+		**out = **in
+	}
+	if in.Vault != nil {
+		in, out := &in.Vault, &out.Vault
+		*out = new(appv1alpha1.Vault)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JDBC.
+func (in *JDBC) DeepCopy() *JDBC {
+	if in == nil {
+		return nil
+	}
+	out := new(JDBC)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Kafka) DeepCopyInto(out *Kafka) {
 	*out = *in
@@ -309,6 +402,21 @@ func (in *Spark) DeepCopy() *Spark {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StreamHealth) DeepCopyInto(out *StreamHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamHealth.
+func (in *StreamHealth) DeepCopy() *StreamHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(StreamHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StreamTransfer) DeepCopyInto(out *StreamTransfer) {
 	*out = *in
@@ -380,6 +488,11 @@ func (in *StreamTransferSpec) DeepCopyInto(out *StreamTransferSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(StreamHealth)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamTransferSpec.
@@ -400,6 +513,19 @@ func (in *StreamTransferStatus) DeepCopyInto(out *StreamTransferStatus) {
 		*out = new(v1.ObjectReference)
 		**out = **in
 	}
+	if in.Lag != nil {
+		in, out := &in.Lag, &out.Lag
+		*out = new(int64)
+		**out = **in
+	}
+	if in.LastReportTime != nil {
+		in, out := &in.LastReportTime, &out.LastReportTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRestartTime != nil {
+		in, out := &in.LastRestartTime, &out.LastRestartTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StreamTransferStatus.
@@ -412,6 +538,35 @@ func (in *StreamTransferStatus) DeepCopy() *StreamTransferStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransferMetrics) DeepCopyInto(out *TransferMetrics) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Transformations != nil {
+		in, out := &in.Transformations, &out.Transformations
+		*out = make([]TransformationMetrics, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReportTime != nil {
+		in, out := &in.ReportTime, &out.ReportTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransferMetrics.
+func (in *TransferMetrics) DeepCopy() *TransferMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(TransferMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Transformation) DeepCopyInto(out *Transformation) {
 	*out = *in
@@ -438,3 +593,37 @@ func (in *Transformation) DeepCopy() *Transformation {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransformationMetrics) DeepCopyInto(out *TransformationMetrics) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformationMetrics.
+func (in *TransformationMetrics) DeepCopy() *TransformationMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(TransformationMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Watermark) DeepCopyInto(out *Watermark) {
+	*out = *in
+	if in.RecordTime != nil {
+		in, out := &in.RecordTime, &out.RecordTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Watermark.
+func (in *Watermark) DeepCopy() *Watermark {
+	if in == nil {
+		return nil
+	}
+	out := new(Watermark)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -9,6 +9,7 @@ import (
 	"fybrik.io/fybrik/manager/controllers"
 	"fybrik.io/fybrik/pkg/environment"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sort"
 	"strings"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 
 	"fybrik.io/fybrik/manager/controllers/utils"
 	"fybrik.io/fybrik/pkg/helm"
+	"fybrik.io/fybrik/pkg/secrets"
 	corev1 "k8s.io/api/core/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
 	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
@@ -41,6 +43,13 @@ type BlueprintReconciler struct {
 	Log    logr.Logger
 	Scheme *runtime.Scheme
 	Helmer helm.Interface
+	// SecretReader reads the Secrets whose credentials are projected into the blueprint namespace for the kubernetes
+	// secret provider. These Secrets are outside the namespaces cached by the manager. The client of the reconciler
+	// is used if it is nil.
+	SecretReader client.Reader
+	// SecretNamespaces are the namespaces, besides the namespace of the application, from which Secrets may be
+	// projected into the blueprint namespace
+	SecretNamespaces []string
 }
 
 // Reconcile receives a Blueprint CRD
@@ -119,7 +128,7 @@ func (r *BlueprintReconciler) reconcileFinalizers(blueprint *app.Blueprint) (ctr
 func (r *BlueprintReconciler) deleteExternalResources(blueprint *app.Blueprint) error {
 	errs := make([]string, 0)
 	for release := range blueprint.Status.Releases {
-		if _, err := r.Helmer.Uninstall(blueprint.Namespace, release); err != nil {
+		if err := r.uninstallRelease(blueprint, release); err != nil {
 			errs = append(errs, err.Error())
 		}
 	}
@@ -129,6 +138,126 @@ func (r *BlueprintReconciler) deleteExternalResources(blueprint *app.Blueprint)
 	return errors.New(strings.Join(errs, "; "))
 }
 
+// uninstallRelease uninstalls the release unless it is a cluster scoped release that is used by another blueprint.
+// A shared release is deployed again with the arguments of the remaining blueprints only.
+func (r *BlueprintReconciler) uninstallRelease(blueprint *app.Blueprint, releaseName string) error {
+	users, err := r.clusterReleaseUsers(blueprint, releaseName, false)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		r.Log.V(0).Info("Release " + releaseName + " is used by other blueprints")
+		return r.applySharedRelease(releaseName, users)
+	}
+	_, err = r.Helmer.Uninstall(blueprint.Namespace, releaseName)
+	return err
+}
+
+// releaseUser is a blueprint that deploys a release as a cluster scoped module
+type releaseUser struct {
+	blueprint *app.Blueprint
+	module    app.BlueprintModule
+}
+
+// clusterReleaseUsers returns the blueprints of the namespace that deploy the given release as a cluster scoped module,
+// ordered by their names. Blueprints that are being deleted are skipped, and so is the given blueprint unless includeSelf
+// is set.
+func (r *BlueprintReconciler) clusterReleaseUsers(blueprint *app.Blueprint, releaseName string, includeSelf bool) ([]releaseUser, error) {
+	var blueprints app.BlueprintList
+	if err := r.List(context.Background(), &blueprints, client.InNamespace(blueprint.Namespace)); err != nil {
+		return nil, err
+	}
+	var users []releaseUser
+	addUser := func(user *app.Blueprint) {
+		for _, module := range user.Spec.Modules {
+			if module.Scope != app.Cluster {
+				continue
+			}
+			if utils.GetReleaseName(user.Labels[app.ApplicationNameLabel], user.Labels[app.ApplicationNamespaceLabel], module) == releaseName {
+				users = append(users, releaseUser{blueprint: user, module: module})
+				return
+			}
+		}
+	}
+	if includeSelf {
+		// the given blueprint may be more recent than the listed one
+		addUser(blueprint)
+	}
+	for i := range blueprints.Items {
+		other := &blueprints.Items[i]
+		if other.Name == blueprint.Name || !other.DeletionTimestamp.IsZero() {
+			continue
+		}
+		addUser(other)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].blueprint.Name < users[j].blueprint.Name })
+	return users, nil
+}
+
+// releaseArguments returns the arguments with which the release of a blueprint module is deployed, after the credentials
+// have been made available in the blueprint namespace.
+// A cluster scoped release serves all the blueprints that use it, thus their arguments are merged.
+func (r *BlueprintReconciler) releaseArguments(blueprint *app.Blueprint, releaseName string, module app.BlueprintModule) (*app.ModuleArguments, error) {
+	if module.Scope != app.Cluster {
+		arguments := module.Arguments.DeepCopy()
+		return arguments, r.projectSecrets(blueprint, releaseName, arguments)
+	}
+	users, err := r.clusterReleaseUsers(blueprint, releaseName, true)
+	if err != nil {
+		return nil, err
+	}
+	return r.mergeReleaseArguments(releaseName, users)
+}
+
+// mergeReleaseArguments merges the arguments of the cluster scoped modules of the given blueprints in their order
+func (r *BlueprintReconciler) mergeReleaseArguments(releaseName string, users []releaseUser) (*app.ModuleArguments, error) {
+	merged := &app.ModuleArguments{}
+	for _, user := range users {
+		arguments := user.module.Arguments.DeepCopy()
+		if err := r.projectSecrets(user.blueprint, releaseName, arguments); err != nil {
+			return nil, err
+		}
+		if merged.Copy == nil {
+			merged.Copy = arguments.Copy
+		}
+		merged.Read = append(merged.Read, arguments.Read...)
+		merged.Write = append(merged.Write, arguments.Write...)
+		merged.Transform = append(merged.Transform, arguments.Transform...)
+	}
+	return merged, nil
+}
+
+// applySharedRelease deploys a cluster scoped release with the merged arguments of the given blueprints
+// if they differ from the deployed ones
+func (r *BlueprintReconciler) applySharedRelease(releaseName string, users []releaseUser) error {
+	arguments, err := r.mergeReleaseArguments(releaseName, users)
+	if err != nil {
+		return err
+	}
+	args, err := utils.StructToMap(arguments)
+	if err != nil {
+		return err
+	}
+	owner := users[0]
+	rel, err := r.Helmer.Status(owner.blueprint.Namespace, releaseName)
+	if err == nil && rel != nil && !releaseArgumentsChanged(rel, args) {
+		return nil
+	}
+	_, err = r.applyChartResource(r.Log, owner.module.Chart, args, owner.blueprint, releaseName)
+	return err
+}
+
+// releaseArgumentsChanged checks whether the module arguments with which a release is deployed differ from the given ones
+func releaseArgumentsChanged(rel *release.Release, args map[string]interface{}) bool {
+	// the top level values that hold the module arguments
+	for _, key := range []string{"copy", "read", "write", "transform"} {
+		if !equality.Semantic.DeepEqual(rel.Config[key], args[key]) {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *BlueprintReconciler) applyChartResource(log logr.Logger, chartSpec app.ChartSpec, args map[string]interface{}, blueprint *app.Blueprint, releaseName string) (ctrl.Result, error) {
 	log.Info(fmt.Sprintf("--- Chart Ref ---\n\n%v\n\n", chartSpec.Name))
 	kubeNamespace := blueprint.Namespace
@@ -223,26 +352,36 @@ func (r *BlueprintReconciler) reconcile(ctx context.Context, log logr.Logger, bl
 	// count the overall number of Helm releases and how many of them are ready
 	numReleases, numReady := 0, 0
 	for _, module := range blueprint.Spec.Modules {
+		releaseName := utils.GetReleaseName(blueprint.Labels[app.ApplicationNameLabel], blueprint.Labels[app.ApplicationNamespaceLabel], module)
+		arguments, err := r.releaseArguments(blueprint, releaseName, module)
+		if err != nil {
+			return ctrl.Result{}, errors.WithMessage(err, "Blueprint step credentials are unavailable")
+		}
 		// Get arguments by type
 		var args map[string]interface{}
-		args, err := utils.StructToMap(module.Arguments)
+		args, err = utils.StructToMap(arguments)
 		if err != nil {
 			return ctrl.Result{}, errors.WithMessage(err, "Blueprint step arguments are invalid")
 		}
-		releaseName := utils.GetReleaseName(blueprint.Labels[app.ApplicationNameLabel], blueprint.Labels[app.ApplicationNamespaceLabel], module)
 		log.V(0).Info("Release name: " + releaseName)
 		numReleases++
 		// check the release status
 		rel, err := r.Helmer.Status(blueprint.Namespace, releaseName)
+		// a cluster scoped release is shared by several blueprints, thus it is upgraded when their merged arguments change
+		// rather than when a specific blueprint changes
+		forceUpdate := updateRequired
+		if module.Scope == app.Cluster {
+			forceUpdate = rel != nil && releaseArgumentsChanged(rel, args)
+		}
 		// unexisting release or a failed release - re-apply the chart
-		if updateRequired || err != nil || rel == nil || rel.Info.Status == release.StatusFailed {
+		if forceUpdate || err != nil || rel == nil || rel.Info.Status == release.StatusFailed {
 			// Process templates with arguments
 			chart := module.Chart
 			if _, err := r.applyChartResource(log, chart, args, blueprint, releaseName); err != nil {
 				blueprint.Status.ObservedState.Error += errors.Wrap(err, "ChartDeploymentFailure: ").Error() + "\n"
 			}
 		} else if rel.Info.Status == release.StatusDeployed {
-			if len(module.Arguments.Read) > 0 {
+			if len(module.Arguments.Read) > 0 || len(module.Arguments.Transform) > 0 {
 				blueprint.Status.ObservedState.DataAccessInstructions += rel.Info.Notes
 			}
 			status, errMsg := r.checkReleaseStatus(releaseName, blueprint.Namespace)
@@ -257,7 +396,7 @@ func (r *BlueprintReconciler) reconcile(ctx context.Context, log logr.Logger, bl
 	// clean-up
 	for release, version := range blueprint.Status.Releases {
 		if version != blueprint.Status.ObservedGeneration {
-			_, err := r.Helmer.Uninstall(blueprint.Namespace, release)
+			err := r.uninstallRelease(blueprint, release)
 			if err != nil {
 				log.V(0).Info("Error uninstalling release " + release + " : " + err.Error())
 			} else {
@@ -282,11 +421,13 @@ func (r *BlueprintReconciler) reconcile(ctx context.Context, log logr.Logger, bl
 // NewBlueprintReconciler creates a new reconciler for Blueprint resources
 func NewBlueprintReconciler(mgr ctrl.Manager, name string, helmer helm.Interface) *BlueprintReconciler {
 	return &BlueprintReconciler{
-		Client: mgr.GetClient(),
-		Name:   name,
-		Log:    ctrl.Log.WithName("controllers").WithName(name),
-		Scheme: mgr.GetScheme(),
-		Helmer: helmer,
+		Client:           mgr.GetClient(),
+		Name:             name,
+		Log:              ctrl.Log.WithName("controllers").WithName(name),
+		Scheme:           mgr.GetScheme(),
+		Helmer:           helmer,
+		SecretReader:     mgr.GetAPIReader(),
+		SecretNamespaces: secrets.AllowedNamespaces(),
 	}
 }
 
@@ -137,3 +137,63 @@ func TestLongReleaseName(t *testing.T) {
 	g.Expect(relName2).To(gomega.Equal("my-app-default-ohandnottoforgettheflowstepnamet-a7569"))
 	g.Expect(relName2).To(gomega.HaveLen(53))
 }
+
+// This test checks that a cluster scoped release is deployed with the merged arguments of the blueprints that use it,
+// and that the arguments of a deleted blueprint are removed from it
+func TestSharedReleaseArguments(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	newBlueprint := func(appName string, assetID string) *app.Blueprint {
+		return &app.Blueprint{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default-" + appName,
+				Namespace: BlueprintNamespace,
+				Labels: map[string]string{
+					app.ApplicationNameLabel:      appName,
+					app.ApplicationNamespaceLabel: "default",
+				},
+			},
+			Spec: app.BlueprintSpec{
+				Cluster: "cluster1",
+				Modules: []app.BlueprintModule{{
+					Name:         "arrow-flight-module",
+					InstanceName: "arrow-flight-module",
+					Scope:        app.Cluster,
+					Chart:        app.ChartSpec{Name: "ghcr.io/fybrik/arrow-flight-module-chart:0.1.0"},
+					Arguments:    app.ModuleArguments{Read: []app.ReadModuleArgs{{AssetID: assetID}}},
+				}},
+			},
+		}
+	}
+	first := newBlueprint("notebook", "asset1")
+	second := newBlueprint("spark", "asset2")
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, []runtime.Object{first, second}...)
+	helmer := helm.NewEmptyFake()
+	r := &BlueprintReconciler{
+		Client: cl,
+		Name:   "BlueprintTestController",
+		Log:    ctrl.Log.WithName("test-blueprint-controller"),
+		Scheme: s,
+		Helmer: helmer,
+	}
+	assetIDs := func() []string {
+		rel, err := helmer.Status(BlueprintNamespace, "arrow-flight-module")
+		g.Expect(err).To(gomega.BeNil())
+		ids := []string{}
+		for _, arg := range rel.Config["read"].([]interface{}) {
+			ids = append(ids, arg.(map[string]interface{})["assetID"].(string))
+		}
+		return ids
+	}
+
+	_, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: client.ObjectKeyFromObject(first)})
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(assetIDs()).To(gomega.Equal([]string{"asset1", "asset2"}))
+
+	// the release keeps serving the remaining blueprint
+	g.Expect(cl.Delete(context.Background(), second)).To(gomega.Succeed())
+	g.Expect(r.uninstallRelease(second, "arrow-flight-module")).To(gomega.Succeed())
+	g.Expect(assetIDs()).To(gomega.Equal([]string{"asset1"}))
+}
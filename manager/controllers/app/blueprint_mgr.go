@@ -10,30 +10,39 @@ import (
 	// Temporary - shouldn't have something specific to implicit copies
 )
 
-// RefineInstances collects all instances of the same read/write module and creates a new instance instead, with accumulated arguments.
+// RefineInstances collects all instances of the same read/write/transform module and creates a new instance instead, with accumulated arguments.
+// Instances are unified according to the module scope: workload and cluster scoped modules serve all the assets,
+// while asset scoped modules serve a single asset.
 // Copy modules are left unchanged.
 func (r *FybrikApplicationReconciler) RefineInstances(instances []modules.ModuleInstanceSpec) []modules.ModuleInstanceSpec {
 	newInstances := make([]modules.ModuleInstanceSpec, 0)
 	// map instances to be unified, according to the cluster and module
+	// the order of the instances is preserved, so that the stages of the data path remain in order
 	instanceMap := make(map[string]modules.ModuleInstanceSpec)
+	keys := []string{}
 	for _, moduleInstance := range instances {
 		if moduleInstance.Args.Copy != nil {
 			newInstances = append(newInstances, moduleInstance)
 			continue
 		}
 		key := moduleInstance.Module.GetName() + "," + moduleInstance.ClusterName
+		if moduleInstance.Scope == app.Asset {
+			key += "," + moduleInstance.AssetID
+		}
 		if instance, ok := instanceMap[key]; !ok {
 			instanceMap[key] = moduleInstance
+			keys = append(keys, key)
 		} else {
 			instance.Args.Read = append(instance.Args.Read, moduleInstance.Args.Read...)
 			instance.Args.Write = append(instance.Args.Write, moduleInstance.Args.Write...)
+			instance.Args.Transform = append(instance.Args.Transform, moduleInstance.Args.Transform...)
 			// AssetID is used for step name generation
 			instance.AssetID += "," + moduleInstance.AssetID
 			instanceMap[key] = instance
 		}
 	}
-	for _, moduleInstance := range instanceMap {
-		newInstances = append(newInstances, moduleInstance)
+	for _, key := range keys {
+		newInstances = append(newInstances, instanceMap[key])
 	}
 	return newInstances
 }
@@ -69,12 +78,46 @@ func (r *FybrikApplicationReconciler) GenerateBlueprint(instances []modules.Modu
 
 		var blueprintModule app.BlueprintModule
 		blueprintModule.Name = modulename
-		blueprintModule.InstanceName = utils.CreateStepName(modulename, moduleInstance.AssetID) // Need unique name for each module so include ids for dataset
-		blueprintModule.Arguments = *moduleInstance.Args
+		if moduleInstance.Scope == app.Cluster {
+			// a single instance is shared by all the workloads in the cluster
+			blueprintModule.InstanceName = modulename
+		} else {
+			blueprintModule.InstanceName = utils.CreateStepName(modulename, moduleInstance.AssetID) // Need unique name for each module so include ids for dataset
+		}
+		blueprintModule.Scope = moduleInstance.Scope
+		blueprintModule.Arguments = *moduleInstance.Args.DeepCopy()
 		blueprintModule.Chart = moduleInstance.Module.Spec.Chart
 		blueprintModules = append(blueprintModules, blueprintModule)
 	}
 
+	// wire transform modules to the instances whose output they transform
+	for i := range blueprintModules {
+		for j := range blueprintModules[i].Arguments.Transform {
+			arg := &blueprintModules[i].Arguments.Transform[j]
+			arg.Input = getInputInstanceName(blueprintModules, arg.Input, arg.AssetID)
+		}
+	}
+
 	spec.Modules = blueprintModules
 	return spec
 }
+
+// getInputInstanceName returns the instance name of the given module that serves the given asset
+func getInputInstanceName(blueprintModules []app.BlueprintModule, moduleName string, assetID string) string {
+	for _, blueprintModule := range blueprintModules {
+		if blueprintModule.Name != moduleName {
+			continue
+		}
+		for _, arg := range blueprintModule.Arguments.Read {
+			if arg.AssetID == assetID {
+				return blueprintModule.InstanceName
+			}
+		}
+		for _, arg := range blueprintModule.Arguments.Transform {
+			if arg.AssetID == assetID {
+				return blueprintModule.InstanceName
+			}
+		}
+	}
+	return moduleName
+}
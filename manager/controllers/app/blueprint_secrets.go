@@ -0,0 +1,97 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+
+	"emperror.dev/errors"
+	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/manager/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// moduleDataStores returns the data stores that are handed to a module
+func moduleDataStores(args *app.ModuleArguments) []*app.DataStore {
+	var dataStores []*app.DataStore
+	if args.Copy != nil {
+		dataStores = append(dataStores, &args.Copy.Source, &args.Copy.Destination)
+	}
+	for i := range args.Read {
+		dataStores = append(dataStores, &args.Read[i].Source)
+	}
+	for i := range args.Write {
+		dataStores = append(dataStores, &args.Write[i].Destination)
+	}
+	return dataStores
+}
+
+// projectSecrets copies the Kubernetes Secrets that hold the credentials of the data stores of a module release
+// into the namespace of the blueprint, where the module can mount them, and points the credential references in the
+// given arguments to the copies. The copies are owned by the blueprint and are removed together with it.
+// Only credentials of the kubernetes secret provider are projected, and only from the namespace of the application,
+// from the system namespace, which holds the Secrets of the storage accounts for provisioned buckets, or from one of
+// the allowed secret namespaces, since the Secret is named by the data catalog.
+func (r *BlueprintReconciler) projectSecrets(blueprint *app.Blueprint, releaseName string, args *app.ModuleArguments) error {
+	reader := r.SecretReader
+	if reader == nil {
+		reader = r.Client
+	}
+	for _, dataStore := range moduleDataStores(args) {
+		for flow, reference := range dataStore.Credentials {
+			if reference.Provider != app.KubernetesSecretProvider || reference.SecretNamespace == blueprint.Namespace {
+				continue
+			}
+			key := types.NamespacedName{Namespace: reference.SecretNamespace, Name: reference.SecretName}
+			if !r.isSecretNamespaceAllowed(blueprint, reference.SecretNamespace) {
+				return errors.Errorf("the credentials in secret %s are not in the namespace of the application, the system namespace or an allowed secret namespace", key.String())
+			}
+			source := &corev1.Secret{}
+			if err := reader.Get(context.Background(), key, source); err != nil {
+				return errors.Wrapf(err, "could not read the credentials in secret %s", key.String())
+			}
+
+			projected := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.K8sConformName(releaseName + "-" + source.Name),
+					Namespace: blueprint.Namespace,
+				},
+			}
+			if _, err := ctrl.CreateOrUpdate(context.Background(), r.Client, projected, func() error {
+				projected.Labels = map[string]string{
+					app.ApplicationNamespaceLabel: blueprint.Labels[app.ApplicationNamespaceLabel],
+					app.ApplicationNameLabel:      blueprint.Labels[app.ApplicationNameLabel],
+					app.BlueprintNameLabel:        blueprint.Name,
+				}
+				projected.Type = source.Type
+				projected.Data = source.Data
+				return ctrlutil.SetOwnerReference(blueprint, projected, r.Scheme)
+			}); err != nil {
+				return errors.Wrapf(err, "could not project the credentials in secret %s", key.String())
+			}
+
+			reference.SecretName = projected.Name
+			reference.SecretNamespace = projected.Namespace
+			dataStore.Credentials[flow] = reference
+		}
+	}
+	return nil
+}
+
+// isSecretNamespaceAllowed returns true if Secrets of the given namespace may be projected for the blueprint
+func (r *BlueprintReconciler) isSecretNamespaceAllowed(blueprint *app.Blueprint, namespace string) bool {
+	if namespace == blueprint.Labels[app.ApplicationNamespaceLabel] || namespace == utils.GetSystemNamespace() {
+		return true
+	}
+	for _, allowed := range r.SecretNamespaces {
+		if namespace == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"testing"
+
+	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/manager/controllers/utils"
+	"fybrik.io/fybrik/pkg/helm"
+	"fybrik.io/fybrik/pkg/secrets"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// This test checks that the Secrets of the kubernetes secret provider are projected into the blueprint namespace
+func TestProjectSecrets(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	blueprint := &app.Blueprint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "notebook-default",
+			Namespace: BlueprintNamespace,
+			UID:       "blueprint-uid",
+			Labels: map[string]string{
+				app.ApplicationNameLabel:      "notebook",
+				app.ApplicationNamespaceLabel: "default",
+			},
+		},
+	}
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "paysim-csv", Namespace: "default"},
+		Data:       map[string][]byte{"access_key": []byte("access"), "secret_key": []byte("secret")},
+	}
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, []runtime.Object{blueprint, source}...)
+	r := &BlueprintReconciler{
+		Client: cl,
+		Name:   "BlueprintTestController",
+		Log:    ctrl.Log.WithName("test-blueprint-controller"),
+		Scheme: s,
+		Helmer: helm.NewEmptyFake(),
+	}
+
+	vaultReference := app.CredentialReference{
+		Provider: app.VaultSecretProvider,
+		Vault:    &app.Vault{SecretPath: "/v1/kubernetes-secrets/other?namespace=default"},
+	}
+	args := &app.ModuleArguments{
+		Read: []app.ReadModuleArgs{{
+			Source: app.DataStore{
+				Credentials: map[string]app.CredentialReference{
+					string(app.ReadFlow): {Provider: app.KubernetesSecretProvider, SecretName: "paysim-csv", SecretNamespace: "default"},
+				},
+			},
+		}},
+		Write: []app.WriteModuleArgs{{
+			Destination: app.DataStore{
+				Credentials: map[string]app.CredentialReference{string(app.WriteFlow): vaultReference},
+			},
+		}},
+	}
+	g.Expect(r.projectSecrets(blueprint, "notebook-default-read", args)).To(gomega.Succeed())
+
+	// the module refers to the copy of the Secret in the blueprint namespace
+	reference := args.Read[0].Source.Credentials[string(app.ReadFlow)]
+	g.Expect(reference.Provider).To(gomega.Equal(app.KubernetesSecretProvider))
+	g.Expect(reference.SecretNamespace).To(gomega.Equal(BlueprintNamespace))
+	projected := &corev1.Secret{}
+	g.Expect(cl.Get(context.Background(), types.NamespacedName{Namespace: BlueprintNamespace, Name: reference.SecretName}, projected)).To(gomega.Succeed())
+	g.Expect(projected.Data).To(gomega.Equal(source.Data))
+	g.Expect(projected.OwnerReferences).To(gomega.HaveLen(1))
+	g.Expect(projected.OwnerReferences[0].Name).To(gomega.Equal(blueprint.Name))
+
+	// credentials of other providers are not projected
+	g.Expect(args.Write[0].Destination.Credentials[string(app.WriteFlow)]).To(gomega.Equal(vaultReference))
+
+	// the projection fails if the Secret does not exist
+	args.Read[0].Source.Credentials[string(app.ReadFlow)] = app.CredentialReference{
+		Provider: app.KubernetesSecretProvider, SecretName: "missing", SecretNamespace: "default",
+	}
+	g.Expect(r.projectSecrets(blueprint, "notebook-default-read", args)).NotTo(gomega.Succeed())
+
+	// Secrets outside the namespace of the application are only projected from allowed namespaces
+	shared := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "shared-csv", Namespace: "shared-credentials"},
+		Data:       map[string][]byte{"access_key": []byte("access"), "secret_key": []byte("secret")},
+	}
+	g.Expect(cl.Create(context.Background(), shared)).To(gomega.Succeed())
+	args.Read[0].Source.Credentials[string(app.ReadFlow)] = app.CredentialReference{
+		Provider: app.KubernetesSecretProvider, SecretName: "shared-csv", SecretNamespace: "shared-credentials",
+	}
+	g.Expect(r.projectSecrets(blueprint, "notebook-default-read", args)).NotTo(gomega.Succeed())
+	r.SecretNamespaces = []string{"shared-credentials"}
+	g.Expect(r.projectSecrets(blueprint, "notebook-default-read", args)).To(gomega.Succeed())
+	g.Expect(args.Read[0].Source.Credentials[string(app.ReadFlow)].SecretNamespace).To(gomega.Equal(BlueprintNamespace))
+}
+
+// This test checks that the Secret of the storage account of a provisioned bucket, which is in the system namespace,
+// is projected for a copy module
+func TestProjectStorageAccountSecret(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	blueprint := &app.Blueprint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "notebook-default",
+			Namespace: BlueprintNamespace,
+			UID:       "blueprint-uid",
+			Labels: map[string]string{
+				app.ApplicationNameLabel:      "notebook",
+				app.ApplicationNamespaceLabel: "default",
+			},
+		},
+	}
+	account := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "bucket-creds", Namespace: utils.GetSystemNamespace()},
+		Data:       map[string][]byte{"access_key": []byte("access"), "secret_key": []byte("secret")},
+	}
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, []runtime.Object{blueprint, account}...)
+	r := &BlueprintReconciler{
+		Client: cl,
+		Name:   "BlueprintTestController",
+		Log:    ctrl.Log.WithName("test-blueprint-controller"),
+		Scheme: s,
+		Helmer: helm.NewEmptyFake(),
+	}
+
+	// the destination of an implicit copy is a bucket that is allocated with a storage account
+	provider := &secrets.KubernetesProvider{}
+	args := &app.ModuleArguments{
+		Copy: &app.CopyModuleArgs{
+			Destination: app.DataStore{
+				Credentials: map[string]app.CredentialReference{
+					string(app.WriteFlow): provider.ForSecret(types.NamespacedName{Namespace: account.Namespace, Name: account.Name}),
+				},
+			},
+		},
+	}
+	g.Expect(r.projectSecrets(blueprint, "notebook-default-copy", args)).To(gomega.Succeed())
+	reference := args.Copy.Destination.Credentials[string(app.WriteFlow)]
+	g.Expect(reference.SecretNamespace).To(gomega.Equal(BlueprintNamespace))
+	projected := &corev1.Secret{}
+	g.Expect(cl.Get(context.Background(), types.NamespacedName{Namespace: BlueprintNamespace, Name: reference.SecretName}, projected)).To(gomega.Succeed())
+	g.Expect(projected.Data).To(gomega.Equal(account.Data))
+}
@@ -43,6 +43,12 @@ func setErrorCondition(application *api.FybrikApplication, assetID string, msg s
 		Message: errMsg}
 }
 
+func clearErrorCondition(application *api.FybrikApplication, assetID string) {
+	if state, found := application.Status.AssetStates[assetID]; found && len(state.Conditions) > api.ErrorConditionIndex {
+		state.Conditions[api.ErrorConditionIndex] = api.Condition{Type: api.ErrorCondition, Status: corev1.ConditionFalse}
+	}
+}
+
 func setDenyCondition(application *api.FybrikApplication, assetID string, msg string) {
 	application.Status.AssetStates[assetID].Conditions[api.DenyConditionIndex] = api.Condition{
 		Type:    api.DenyCondition,
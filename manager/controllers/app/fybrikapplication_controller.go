@@ -20,6 +20,7 @@ import (
 	"github.com/go-logr/logr"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -33,6 +34,7 @@ import (
 	"fybrik.io/fybrik/manager/controllers/app/modules"
 	"fybrik.io/fybrik/manager/controllers/utils"
 	"fybrik.io/fybrik/pkg/multicluster"
+	"fybrik.io/fybrik/pkg/secrets"
 	"fybrik.io/fybrik/pkg/serde"
 	"fybrik.io/fybrik/pkg/storage"
 	"fybrik.io/fybrik/pkg/vault"
@@ -49,6 +51,17 @@ type FybrikApplicationReconciler struct {
 	ResourceInterface ContextInterface
 	ClusterManager    multicluster.ClusterLister
 	Provision         storage.ProvisionInterface
+	// VaultConnection is used to restrict the modules of an application to the credentials of its datasets.
+	// All the modules use the global modules role if it is nil.
+	VaultConnection vault.Interface
+	// VaultPolicyTTL is the TTL of the Vault tokens that modules obtain with the role of their application
+	VaultPolicyTTL string
+	// SecretProvider creates the references with which modules obtain the credentials of data stores.
+	// Modules read the credentials from Vault if it is nil.
+	SecretProvider secrets.Provider
+	// PolicyReevaluationInterval is the interval in which the policies of running applications are evaluated again.
+	// Periodic evaluation is disabled if the interval is not positive.
+	PolicyReevaluationInterval time.Duration
 }
 
 // Reconcile reconciles FybrikApplication CRD
@@ -100,9 +113,15 @@ func (r *FybrikApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	}
 
 	// check if reconcile is required
-	// reconcile is required if the spec has been changed, or the previous reconcile has failed to allocate a Plotter resource
+	// reconcile is required if the spec has been changed, the previous reconcile has failed to allocate a Plotter resource,
+	// or a refresh has been requested
 	generationComplete := r.ResourceInterface.ResourceExists(observedStatus.Generated) && (observedStatus.Generated.AppVersion == appVersion)
-	if (!generationComplete) || (observedStatus.ObservedGeneration != appVersion) {
+	refresh := applicationContext.GetAnnotations()[api.RefreshAnnotation]
+	if refresh != observedStatus.ObservedRefresh {
+		log.V(0).Info("Refresh requested, invalidating cached connector responses")
+		r.invalidateCache(applicationContext)
+	}
+	if (!generationComplete) || (observedStatus.ObservedGeneration != appVersion) || (refresh != observedStatus.ObservedRefresh) {
 		if result, err := r.reconcile(applicationContext); err != nil {
 			// another attempt will be done
 			// users should be informed in case of errors
@@ -113,6 +132,19 @@ func (r *FybrikApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			return result, err
 		}
 		applicationContext.Status.ObservedGeneration = appVersion
+		applicationContext.Status.ObservedRefresh = refresh
+		applicationContext.Status.PolicyEvaluationTime = &metav1.Time{Time: time.Now()}
+	} else if r.policyReevaluationDelay(observedStatus) == 0 {
+		log.V(0).Info("Reevaluating the governance policies")
+		if result, err := r.reevaluatePolicies(applicationContext); err != nil || !result.IsZero() {
+			// the evaluation will be completed by another attempt
+			if !equality.Semantic.DeepEqual(&applicationContext.Status, observedStatus) {
+				// ignore an update error, a new reconcile will be made in any case
+				_ = r.Client.Status().Update(ctx, applicationContext)
+			}
+			return result, err
+		}
+		applicationContext.Status.PolicyEvaluationTime = &metav1.Time{Time: time.Now()}
 	} else {
 		resourceStatus, err := r.ResourceInterface.GetResourceStatus(applicationContext.Status.Generated)
 		if err != nil {
@@ -140,9 +172,147 @@ func (r *FybrikApplicationReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	if !isReady(applicationContext) {
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
+	// trigger a new reconcile when the policies should be evaluated again
+	if delay := r.policyReevaluationDelay(&applicationContext.Status); delay > 0 {
+		return ctrl.Result{RequeueAfter: delay}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
+// policyReevaluationDelay returns the time left until the policies of a running application should be evaluated again.
+// A negative value is returned if the periodic evaluation is disabled or there is nothing to evaluate.
+func (r *FybrikApplicationReconciler) policyReevaluationDelay(status *api.FybrikApplicationStatus) time.Duration {
+	if r.PolicyReevaluationInterval <= 0 || status.Generated == nil {
+		return -1
+	}
+	if status.PolicyEvaluationTime == nil {
+		return 0
+	}
+	delay := time.Until(status.PolicyEvaluationTime.Add(r.PolicyReevaluationInterval))
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// reevaluatePolicies evaluates the governance policies of a running application again, ignoring cached decisions.
+// The decisions are compared with the ones enforced by the generated resources before anything is regenerated:
+// if they have changed, the resources are regenerated, so that the modules of datasets that can not be accessed anymore
+// are removed and these datasets get a Deny condition. Otherwise, the running modules are left unchanged.
+// If the policies can not be evaluated, the evaluation is retried, and the generated resources are removed
+// once the policies could not be evaluated for longer than the evaluation interval.
+func (r *FybrikApplicationReconciler) reevaluatePolicies(applicationContext *api.FybrikApplication) (ctrl.Result, error) {
+	r.invalidateCache(applicationContext)
+	records := applicationContext.Status.PolicyDecisions
+	decisions := r.lookupRecordedDecisions(applicationContext, records)
+	// resources generated without recording the decisions are always regenerated
+	changed := len(records) == 0
+	failures := make(map[string]string)
+	for _, record := range records {
+		decision := decisions[recordedDecisionKey(record)]
+		if policyDecisionDigest(decision) == record.Digest {
+			continue
+		}
+		if decision.Err != nil && !isAccessDenial(decision.Err) {
+			failures[record.DataSetID] = decision.Err.Error()
+			continue
+		}
+		changed = true
+	}
+	if applicationContext.Status.AssetStates == nil {
+		initStatus(applicationContext)
+	}
+	if len(failures) > 0 {
+		evaluationTime := applicationContext.Status.PolicyEvaluationTime
+		if evaluationTime != nil && time.Since(evaluationTime.Time) > 2*r.PolicyReevaluationInterval {
+			r.Log.V(0).Info("Governance policies could not be evaluated for " + applicationContext.Namespace + "/" + applicationContext.Name +
+				", the generated resources are removed")
+			if err := r.deleteExternalResources(applicationContext); err != nil {
+				return ctrl.Result{}, err
+			}
+			initStatus(applicationContext)
+		}
+		for datasetID, message := range failures {
+			setErrorCondition(applicationContext, datasetID, message)
+		}
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+	for _, dataset := range applicationContext.Spec.Data {
+		clearErrorCondition(applicationContext, dataset.DataSetID)
+	}
+	if changed {
+		r.Log.V(0).Info("Governance decisions have changed for " + applicationContext.Namespace + "/" + applicationContext.Name + ", the plotter is regenerated")
+		cataloged := make(map[string]string)
+		for assetID, state := range applicationContext.Status.AssetStates {
+			if state.CatalogedAsset != "" {
+				cataloged[assetID] = state.CatalogedAsset
+			}
+		}
+		if result, err := r.reconcile(applicationContext); err != nil || !result.IsZero() {
+			return result, err
+		}
+		// assets that have been already registered in the catalog should not be registered again
+		for assetID, catalogedAsset := range cataloged {
+			if state, found := applicationContext.Status.AssetStates[assetID]; found {
+				state.CatalogedAsset = catalogedAsset
+				applicationContext.Status.AssetStates[assetID] = state
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+	resourceStatus, err := r.ResourceInterface.GetResourceStatus(applicationContext.Status.Generated)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, r.checkReadiness(applicationContext, resourceStatus)
+}
+
+// lookupRecordedDecisions evaluates again the policy decisions that have been recorded in the application status
+func (r *FybrikApplicationReconciler) lookupRecordedDecisions(applicationContext *api.FybrikApplication,
+	records []api.PolicyDecisionRecord) map[policyDecisionKey]PolicyDecision {
+	type operationKey struct {
+		operation   pb.AccessOperation_AccessType
+		destination string
+	}
+	datasetsPerOperation := make(map[operationKey][]string)
+	for _, record := range records {
+		key := recordedDecisionKey(record)
+		operation := operationKey{operation: key.operation, destination: key.destination}
+		datasetsPerOperation[operation] = append(datasetsPerOperation[operation], key.datasetID)
+	}
+	concurrency := environment.GetEnvAsInt(controllers.DatasetConcurrencyConfiguration, controllers.DefaultDatasetConcurrency)
+	decisions := make(map[policyDecisionKey]PolicyDecision)
+	for operation, datasetIDs := range datasetsPerOperation {
+		op := &pb.AccessOperation{Type: operation.operation, Destination: operation.destination}
+		for datasetID, decision := range LookupPoliciesDecisions(datasetIDs, r.PolicyManager, applicationContext, op, concurrency) {
+			decisions[newPolicyDecisionKey(datasetID, op)] = decision
+		}
+	}
+	return decisions
+}
+
+// recordedDecisionKey identifies the decision of a record in the application status
+func recordedDecisionKey(record api.PolicyDecisionRecord) policyDecisionKey {
+	return policyDecisionKey{
+		datasetID:   record.DataSetID,
+		operation:   pb.AccessOperation_AccessType(pb.AccessOperation_AccessType_value[record.Operation]),
+		destination: record.Destination,
+	}
+}
+
+// invalidateCache removes the cached catalog and policy manager responses related to the application datasets
+func (r *FybrikApplicationReconciler) invalidateCache(applicationContext *api.FybrikApplication) {
+	for _, connector := range []interface{}{r.DataCatalog, r.PolicyManager} {
+		invalidator, ok := connector.(connectors.CacheInvalidator)
+		if !ok {
+			continue
+		}
+		for _, dataset := range applicationContext.Spec.Data {
+			invalidator.Invalidate(dataset.DataSetID)
+		}
+	}
+}
+
 func getBucketResourceRef(name string) *types.NamespacedName {
 	return &types.NamespacedName{Name: name, Namespace: utils.GetSystemNamespace()}
 }
@@ -169,7 +339,8 @@ func (r *FybrikApplicationReconciler) checkReadiness(applicationContext *api.Fyb
 		}
 
 		// register assets if necessary if the ready state has been received
-		if dataCtx.Requirements.Copy.Catalog.CatalogID != "" {
+		// ingested data is always registered
+		if dataCtx.Requirements.Copy.Catalog.CatalogID != "" || IsIngest(applicationContext) {
 			if applicationContext.Status.AssetStates[assetID].CatalogedAsset != "" {
 				// the asset has been already cataloged
 				continue
@@ -255,57 +426,102 @@ func (r *FybrikApplicationReconciler) deleteExternalResources(applicationContext
 		return errors.New(strings.Join(errMsgs, ";"))
 	}
 	// delete the generated resource
-	if applicationContext.Status.Generated == nil {
-		return nil
-	}
-
-	r.Log.V(0).Info("Reconcile: FybrikApplication is deleting the generated " + applicationContext.Status.Generated.Kind)
-	if err := r.ResourceInterface.DeleteResource(applicationContext.Status.Generated); err != nil {
-		return err
+	if applicationContext.Status.Generated != nil {
+		r.Log.V(0).Info("Reconcile: FybrikApplication is deleting the generated " + applicationContext.Status.Generated.Kind)
+		if err := r.ResourceInterface.DeleteResource(applicationContext.Status.Generated); err != nil {
+			return err
+		}
+		applicationContext.Status.Generated = nil
+		applicationContext.Status.PolicyDecisions = nil
 	}
-	applicationContext.Status.Generated = nil
-	return nil
+	// revoke the access of the modules to the credentials
+	return r.deleteVaultPolicy(applicationContext)
 }
 
-// setReadModulesEndpoints populates the ReadEndpointsMap map in the status of the fybrikapplication
-// Current implementation assumes there is only one cluster with read modules (which is the same cluster the user's workload)
-func setReadModulesEndpoints(applicationContext *api.FybrikApplication, blueprintsMap map[string]api.BlueprintSpec, moduleMap map[string]*api.FybrikModule) {
-	readEndpointMap := make(map[string]api.EndpointSpec)
+// setModulesEndpoints populates the endpoints of read and write modules in the status of the fybrikapplication
+// If transform modules are chained after the read module, the endpoint of the last module in the chain is used.
+// Current implementation assumes there is only one cluster with read/write modules (which is the same cluster the user's workload)
+func setModulesEndpoints(applicationContext *api.FybrikApplication, blueprintsMap map[string]api.BlueprintSpec, moduleMap map[string]*api.FybrikModule) {
+	// stages that serve an asset to a transform module rather than to the workload
+	type stage struct {
+		instanceName string
+		assetID      string
+	}
+	inputs := make(map[stage]bool)
 	for _, blueprintSpec := range blueprintsMap {
 		for _, module := range blueprintSpec.Modules {
-			if module.Arguments.Read != nil {
-				releaseName := utils.GetReleaseName(applicationContext.ObjectMeta.Name, applicationContext.ObjectMeta.Namespace, module)
-				moduleName := module.Name
-
-				// Find the read capability section in the module
-				// TODO: What if there are more than one read capability sections?  How do we know which endpoint
-				// to choose?  They could in theory be different, although that's not likely
-				// Currently the last one on the list is used.
-				if hasRead, caps := utils.GetModuleCapabilities(moduleMap[moduleName], api.Read); hasRead {
-					for _, cap := range caps {
-						originalEndpointSpec := cap.API.Endpoint
-						fqdn := utils.GenerateModuleEndpointFQDN(releaseName, BlueprintNamespace)
-						for _, arg := range module.Arguments.Read {
-							readEndpointMap[arg.AssetID] = api.EndpointSpec{
-								Hostname: fqdn,
-								Port:     originalEndpointSpec.Port,
-								Scheme:   originalEndpointSpec.Scheme,
-							}
-						}
-					}
+			for _, arg := range module.Arguments.Transform {
+				inputs[stage{instanceName: arg.Input, assetID: arg.AssetID}] = true
+			}
+		}
+	}
+	endpointMap := make(map[string]api.EndpointSpec)
+	for _, blueprintSpec := range blueprintsMap {
+		for _, module := range blueprintSpec.Modules {
+			releaseName := utils.GetReleaseName(applicationContext.ObjectMeta.Name, applicationContext.ObjectMeta.Namespace, module)
+			fqdn := utils.GenerateModuleEndpointFQDN(releaseName, BlueprintNamespace)
+			var assetIDs []string
+			for _, arg := range module.Arguments.Read {
+				if !inputs[stage{instanceName: module.InstanceName, assetID: arg.AssetID}] {
+					assetIDs = append(assetIDs, arg.AssetID)
 				}
 			}
+			if len(assetIDs) > 0 {
+				addModuleEndpoints(endpointMap, moduleMap[module.Name], api.Read, fqdn, assetIDs)
+			}
+			assetIDs = []string{}
+			for _, arg := range module.Arguments.Transform {
+				if !inputs[stage{instanceName: module.InstanceName, assetID: arg.AssetID}] {
+					assetIDs = append(assetIDs, arg.AssetID)
+				}
+			}
+			if len(assetIDs) > 0 {
+				addModuleEndpoints(endpointMap, moduleMap[module.Name], api.Transform, fqdn, assetIDs)
+			}
+			assetIDs = []string{}
+			for _, arg := range module.Arguments.Write {
+				assetIDs = append(assetIDs, arg.AssetID)
+			}
+			if len(assetIDs) > 0 {
+				addModuleEndpoints(endpointMap, moduleMap[module.Name], api.Write, fqdn, assetIDs)
+			}
 		}
 	}
 	// populate endpoints in application status
 	for _, asset := range applicationContext.Spec.Data {
 		id := utils.CreateDataSetIdentifier(asset.DataSetID)
 		state := applicationContext.Status.AssetStates[asset.DataSetID]
-		state.Endpoint = readEndpointMap[id]
+		state.Endpoint = endpointMap[id]
 		applicationContext.Status.AssetStates[asset.DataSetID] = state
 	}
 }
 
+// addModuleEndpoints maps the given assets to the endpoint of the given module capability
+func addModuleEndpoints(endpointMap map[string]api.EndpointSpec, module *api.FybrikModule, capability api.CapabilityType, fqdn string, assetIDs []string) {
+	if module == nil {
+		return
+	}
+	// Find the capability section in the module
+	// TODO: What if there are more than one capability sections?  How do we know which endpoint
+	// to choose?  They could in theory be different, although that's not likely
+	// Currently the last one on the list is used.
+	if hasCapability, caps := utils.GetModuleCapabilities(module, capability); hasCapability {
+		for _, cap := range caps {
+			if cap.API == nil {
+				continue
+			}
+			originalEndpointSpec := cap.API.Endpoint
+			for _, assetID := range assetIDs {
+				endpointMap[assetID] = api.EndpointSpec{
+					Hostname: fqdn,
+					Port:     originalEndpointSpec.Port,
+					Scheme:   originalEndpointSpec.Scheme,
+				}
+			}
+		}
+	}
+}
+
 // reconcile receives either FybrikApplication CRD
 // or a status update from the generated resource
 func (r *FybrikApplicationReconciler) reconcile(applicationContext *api.FybrikApplication) (ctrl.Result, error) {
@@ -330,14 +546,22 @@ func (r *FybrikApplicationReconciler) reconcile(applicationContext *api.FybrikAp
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	// the catalog and the policy manager are queried concurrently for the datasets
+	concurrency := environment.GetEnvAsInt(controllers.DatasetConcurrencyConfiguration, controllers.DefaultDatasetConcurrency)
 	// create a list of requirements for creating a data flow (actions, interface to app, data format) per a single data set
-	var requirements []modules.DataInfo
-	for _, dataset := range applicationContext.Spec.Data {
-		req := modules.DataInfo{
-			Context: dataset.DeepCopy(),
+	numDatasets := len(applicationContext.Spec.Data)
+	dataInfos := make([]modules.DataInfo, numDatasets)
+	dataInfoErrors := make([]error, numDatasets)
+	utils.RunConcurrently(numDatasets, concurrency, func(i int) {
+		dataInfos[i] = modules.DataInfo{
+			Context: applicationContext.Spec.Data[i].DeepCopy(),
 		}
-		if err := r.constructDataInfo(&req, applicationContext, clusters); err != nil {
-			AnalyzeError(applicationContext, req.Context.DataSetID, err)
+		dataInfoErrors[i] = r.constructDataInfo(&dataInfos[i], applicationContext, clusters)
+	})
+	var requirements []modules.DataInfo
+	for i, req := range dataInfos {
+		if dataInfoErrors[i] != nil {
+			AnalyzeError(applicationContext, req.Context.DataSetID, dataInfoErrors[i])
 			continue
 		}
 		requirements = append(requirements, req)
@@ -362,6 +586,15 @@ func (r *FybrikApplicationReconciler) reconcile(applicationContext *api.FybrikAp
 		PolicyManager:      r.PolicyManager,
 		Provision:          r.Provision,
 		ProvisionedStorage: make(map[string]NewAssetInfo),
+		SelectionDetails:   make(map[string][]api.ModuleSelectionDetails),
+		SecretProvider:     r.SecretProvider,
+		Concurrency:        concurrency,
+	}
+	if moduleManager.SecretProvider == nil {
+		moduleManager.SecretProvider = &secrets.VaultProvider{Address: utils.GetVaultAddress(), Role: utils.GetModulesRole()}
+	}
+	if err := moduleManager.PrefetchPolicyDecisions(requirements, applicationContext); err != nil {
+		r.Log.V(0).Info("Could not prefetch the policy decisions: " + err.Error())
 	}
 	instances := make([]modules.ModuleInstanceSpec, 0)
 	for _, item := range requirements {
@@ -372,6 +605,13 @@ func (r *FybrikApplicationReconciler) reconcile(applicationContext *api.FybrikAp
 		}
 		instances = append(instances, instancesPerDataset...)
 	}
+	// record the module selection in the status
+	for datasetID, details := range moduleManager.SelectionDetails {
+		if state, found := applicationContext.Status.AssetStates[datasetID]; found {
+			state.ModuleSelection = details
+			applicationContext.Status.AssetStates[datasetID] = state
+		}
+	}
 	// check if can proceed
 	if getErrorMessages(applicationContext) != "" {
 		return ctrl.Result{}, nil
@@ -418,7 +658,11 @@ func (r *FybrikApplicationReconciler) reconcile(applicationContext *api.FybrikAp
 	}
 	// generate blueprint specifications (per cluster)
 	blueprintPerClusterMap := r.GenerateBlueprints(instances, applicationContext)
-	setReadModulesEndpoints(applicationContext, blueprintPerClusterMap, moduleMap)
+	setModulesEndpoints(applicationContext, blueprintPerClusterMap, moduleMap)
+	if err := r.reconcileVaultPolicy(applicationContext, blueprintPerClusterMap, clusters); err != nil {
+		r.Log.V(0).Info("Error writing the Vault policy: " + err.Error())
+		return ctrl.Result{}, err
+	}
 	ownerRef := &api.ResourceReference{Name: applicationContext.Name, Namespace: applicationContext.Namespace, AppVersion: applicationContext.GetGeneration()}
 	resourceRef := r.ResourceInterface.CreateResourceReference(ownerRef)
 	if err := r.ResourceInterface.CreateOrUpdateResource(ownerRef, resourceRef, blueprintPerClusterMap); err != nil {
@@ -430,6 +674,7 @@ func (r *FybrikApplicationReconciler) reconcile(applicationContext *api.FybrikAp
 		return ctrl.Result{}, err
 	}
 	applicationContext.Status.Generated = resourceRef
+	applicationContext.Status.PolicyDecisions = moduleManager.PolicyDecisionRecords()
 	r.Log.V(0).Info("Created " + resourceRef.Kind + " successfully!")
 	return ctrl.Result{}, nil
 }
@@ -481,6 +726,8 @@ func NewFybrikApplicationReconciler(mgr ctrl.Manager, name string,
 		ClusterManager:    cm,
 		Provision:         provision,
 		DataCatalog:       catalog,
+		PolicyReevaluationInterval: environment.GetEnvAsDuration(controllers.PolicyReevaluationIntervalConfiguration,
+			controllers.DefaultPolicyReevaluationInterval),
 	}
 }
 
@@ -521,11 +768,20 @@ func AnalyzeError(application *api.FybrikApplication, assetID string, err error)
 	if err == nil {
 		return
 	}
+	if isAccessDenial(err) {
+		setDenyCondition(application, assetID, err.Error())
+	} else {
+		setErrorCondition(application, assetID, err.Error())
+	}
+}
+
+// isAccessDenial returns true if the error reports that the governance policies do not allow the access to an asset
+func isAccessDenial(err error) bool {
 	switch err.Error() {
 	case api.InvalidAssetID, api.ReadAccessDenied, api.CopyNotAllowed, api.WriteNotAllowed:
-		setDenyCondition(application, assetID, err.Error())
+		return true
 	default:
-		setErrorCondition(application, assetID, err.Error())
+		return false
 	}
 }
 
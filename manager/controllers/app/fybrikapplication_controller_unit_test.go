@@ -6,6 +6,7 @@ package app
 import (
 	"context"
 	"io/ioutil"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,9 +14,15 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"fybrik.io/fybrik/manager/controllers/app/modules"
 	"fybrik.io/fybrik/manager/controllers/mockup"
+	connectors "fybrik.io/fybrik/pkg/connectors/clients"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	"fybrik.io/fybrik/pkg/multicluster"
 	"fybrik.io/fybrik/pkg/storage"
+	openapiclientmodels "fybrik.io/fybrik/pkg/taxonomy/model/base"
 
+	"emperror.dev/errors"
 	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
 	"github.com/onsi/gomega"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -135,6 +142,17 @@ func TestFybrikApplicationControllerCSVCopyAndRead(t *testing.T) {
 	g.Expect(bpSpec.Modules[0].Arguments.Copy.Source.Format).To(gomega.Equal("csv"))
 	g.Expect(bpSpec.Modules[0].Arguments.Copy.Destination.Format).To(gomega.Equal("csv"))
 	g.Expect(bpSpec.Modules[0].Arguments.Copy.Destination.Format).To(gomega.Equal(bpSpec.Modules[1].Arguments.Read[0].Source.Format))
+	// the connections hold the data stores of the transfer
+	source := copyConnection{}
+	g.Expect(bpSpec.Modules[0].Arguments.Copy.Source.Connection.Into(&source)).To(gomega.Succeed())
+	g.Expect(source.Transfer).NotTo(gomega.BeNil())
+	g.Expect(source.Transfer.S3).NotTo(gomega.BeNil())
+	g.Expect(source.Transfer.S3.Bucket).To(gomega.Equal(source.S3.Bucket))
+	g.Expect(source.Transfer.S3.DataFormat).To(gomega.Equal("csv"))
+	destination := copyConnection{}
+	g.Expect(bpSpec.Modules[0].Arguments.Copy.Destination.Connection.Into(&destination)).To(gomega.Succeed())
+	g.Expect(destination.Transfer).NotTo(gomega.BeNil())
+	g.Expect(destination.Transfer.S3).NotTo(gomega.BeNil())
 }
 
 // This test checks proper reconciliation of FybrikApplication finalizers
@@ -703,6 +721,160 @@ func TestCopyDataNotAllowed(t *testing.T) {
 	g.Expect(getErrorMessages(application)).NotTo(gomega.BeEmpty())
 }
 
+// This test checks the ingest scenario without an explicit copy request
+// The data is copied close to the allowed destination, and the new asset is registered once the copy is ready.
+func TestIngestRegistration(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	assetName := "s3-external/allow-theshire"
+	namespaced := types.NamespacedName{
+		Name:      "ingest",
+		Namespace: "default",
+	}
+	application := &app.FybrikApplication{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/ingest.yaml", application)).NotTo(gomega.HaveOccurred())
+	application.Spec.Data[0].DataSetID = assetName
+	application.Spec.Data[0].Requirements.Copy.Required = false
+	application.SetGeneration(1)
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		application,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := utils.NewScheme(g)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	copyModule := &app.FybrikModule{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/implicit-copy-batch-module-csv.yaml", copyModule)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.TODO(), copyModule)).NotTo(gomega.HaveOccurred(), "the copy module could not be created")
+
+	// Create storage account
+	dummySecret := &corev1.Secret{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/credentials-theshire.yaml", dummySecret)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.TODO(), dummySecret)).NotTo(gomega.HaveOccurred())
+	account := &app.FybrikStorageAccount{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/account-theshire.yaml", account)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.TODO(), account)).NotTo(gomega.HaveOccurred())
+
+	// Create a FybrikApplicationReconciler object with the scheme and fake client.
+	r := createTestFybrikApplicationController(cl, s)
+	req := reconcile.Request{
+		NamespacedName: namespaced,
+	}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+
+	err = cl.Get(context.TODO(), req.NamespacedName, application)
+	g.Expect(err).To(gomega.BeNil(), "Cannot fetch fybrikapplication")
+	g.Expect(application.Status.ProvisionedStorage[assetName].DatasetRef).ToNot(gomega.BeEmpty(), "No storage provisioned")
+	g.Expect(application.Status.Generated).ToNot(gomega.BeNil())
+	plotterObjectKey := types.NamespacedName{
+		Namespace: application.Status.Generated.Namespace,
+		Name:      application.Status.Generated.Name,
+	}
+	plotter := &app.Plotter{}
+	err = cl.Get(context.Background(), plotterObjectKey, plotter)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	// The copy runs close to the destination
+	blueprint, found := plotter.Spec.Blueprints["thegreendragon"]
+	g.Expect(found).To(gomega.BeTrue())
+	g.Expect(blueprint.Modules[0].Arguments.Copy).NotTo(gomega.BeNil())
+
+	// mark the plotter as ready
+	plotter.Status.ObservedState.Ready = true
+	g.Expect(cl.Update(context.Background(), plotter)).NotTo(gomega.HaveOccurred())
+
+	// the new reconcile should register the new asset
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+	err = cl.Get(context.Background(), req.NamespacedName, application)
+	g.Expect(err).To(gomega.BeNil(), "Cannot fetch fybrikapplication")
+	g.Expect(getErrorMessages(application)).To(gomega.BeEmpty())
+	g.Expect(application.Status.AssetStates[assetName].CatalogedAsset).To(gomega.Equal("ingest_test/xxx"))
+	g.Expect(application.Status.Ready).To(gomega.BeTrue())
+}
+
+// This test checks the write scenario
+// A write module is selected to run in the workload cluster, and a new storage is allocated for the written data.
+func TestWriteData(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	assetName := "s3/allow-dataset"
+	namespaced := types.NamespacedName{
+		Name:      "notebook-write",
+		Namespace: "default",
+	}
+	application := &app.FybrikApplication{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/fybrikapplication-write.yaml", application)).NotTo(gomega.HaveOccurred())
+	application.SetGeneration(1)
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		application,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := utils.NewScheme(g)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+	writeModule := &app.FybrikModule{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/module-write-parquet.yaml", writeModule)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.TODO(), writeModule)).NotTo(gomega.HaveOccurred(), "the write module could not be created")
+
+	// Create storage account
+	dummySecret := &corev1.Secret{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/credentials-theshire.yaml", dummySecret)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.TODO(), dummySecret)).NotTo(gomega.HaveOccurred())
+	account := &app.FybrikStorageAccount{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/account-theshire.yaml", account)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.TODO(), account)).NotTo(gomega.HaveOccurred())
+
+	// Create a FybrikApplicationReconciler object with the scheme and fake client.
+	r := createTestFybrikApplicationController(cl, s)
+	req := reconcile.Request{
+		NamespacedName: namespaced,
+	}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+
+	err = cl.Get(context.TODO(), req.NamespacedName, application)
+	g.Expect(err).To(gomega.BeNil(), "Cannot fetch fybrikapplication")
+	g.Expect(getErrorMessages(application)).To(gomega.BeEmpty())
+	// check provisioned storage
+	g.Expect(application.Status.ProvisionedStorage[assetName].SecretRef).To(gomega.Equal("credentials-theshire"), "Incorrect storage was selected")
+	// check the write endpoint
+	g.Expect(application.Status.AssetStates[assetName].Endpoint.Hostname).NotTo(gomega.BeEmpty())
+	g.Expect(application.Status.AssetStates[assetName].Endpoint.Scheme).To(gomega.Equal("grpc"))
+	// check plotter creation
+	g.Expect(application.Status.Generated).ToNot(gomega.BeNil())
+	plotterObjectKey := types.NamespacedName{
+		Namespace: application.Status.Generated.Namespace,
+		Name:      application.Status.Generated.Name,
+	}
+	plotter := &app.Plotter{}
+	err = cl.Get(context.Background(), plotterObjectKey, plotter)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	// There should be a single write module running in the workload cluster
+	g.Expect(len(plotter.Spec.Blueprints)).To(gomega.Equal(1))
+	blueprint := plotter.Spec.Blueprints["thegreendragon"]
+	g.Expect(len(blueprint.Modules)).To(gomega.Equal(1))
+	g.Expect(blueprint.Modules[0].Name).To(gomega.Equal("arrow-flight-write-module"))
+	g.Expect(blueprint.Modules[0].Arguments.Write).To(gomega.HaveLen(1))
+	g.Expect(blueprint.Modules[0].Arguments.Write[0].Destination.Format).To(gomega.Equal("parquet"))
+}
+
 // This test checks that the plotter state propagates into the fybrikapp state
 func TestPlotterUpdate(t *testing.T) {
 	t.Parallel()
@@ -966,3 +1138,283 @@ func TestFybrikApplicationWithInvalidInterface(t *testing.T) {
 	g.Expect(getErrorMessages(newApp)).NotTo(gomega.BeEmpty())
 	g.Expect(newApp.Status.Ready).NotTo(gomega.BeTrue())
 }
+
+// This test checks that capabilities without a scope are asset scoped
+func TestDefaultCapabilityScope(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	module := &app.FybrikModule{Spec: app.FybrikModuleSpec{Capabilities: []app.ModuleCapability{
+		{Capability: app.Read},
+		{Capability: app.Transform, Scope: app.Workload},
+	}}}
+	g.Expect(utils.GetCapabilityScope(module, app.Read)).To(gomega.Equal(app.Asset))
+	g.Expect(utils.GetCapabilityScope(module, app.Transform)).To(gomega.Equal(app.Workload))
+	// a capability that is not declared gets the scope of the first capability
+	g.Expect(utils.GetCapabilityScope(module, app.Copy)).To(gomega.Equal(app.Asset))
+}
+
+// This test checks that module instances are unified according to the capability scope
+func TestGenerateBlueprintsByScope(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	newInstance := func(moduleName string, scope app.CapabilityScope, assetID string) modules.ModuleInstanceSpec {
+		return modules.ModuleInstanceSpec{
+			Module:      &app.FybrikModule{ObjectMeta: metav1.ObjectMeta{Name: moduleName}},
+			Args:        &app.ModuleArguments{Read: []app.ReadModuleArgs{{AssetID: assetID}}},
+			AssetID:     assetID,
+			ClusterName: "thegreendragon",
+			Scope:       scope,
+		}
+	}
+	instances := []modules.ModuleInstanceSpec{
+		newInstance("asset-module", app.Asset, "s3/allow-dataset"),
+		newInstance("asset-module", app.Asset, "s3/redact-dataset"),
+		newInstance("workload-module", app.Workload, "s3/allow-dataset"),
+		newInstance("workload-module", app.Workload, "s3/redact-dataset"),
+		newInstance("cluster-module", app.Cluster, "s3/allow-dataset"),
+		newInstance("cluster-module", app.Cluster, "s3/redact-dataset"),
+	}
+	application := &app.FybrikApplication{ObjectMeta: metav1.ObjectMeta{Name: "scope-test", Namespace: "default"}}
+	r := createTestFybrikApplicationController(nil, nil)
+	blueprint := r.GenerateBlueprints(instances, application)["thegreendragon"]
+
+	instancesPerModule := map[string]int{}
+	releaseNames := map[string]string{}
+	for _, module := range blueprint.Modules {
+		instancesPerModule[module.Name]++
+		releaseNames[module.Name] = utils.GetReleaseName(application.Name, application.Namespace, module)
+		if module.Scope == app.Asset {
+			g.Expect(module.Arguments.Read).To(gomega.HaveLen(1))
+		} else {
+			g.Expect(module.Arguments.Read).To(gomega.HaveLen(2))
+		}
+	}
+	g.Expect(instancesPerModule).To(gomega.Equal(map[string]int{"asset-module": 2, "workload-module": 1, "cluster-module": 1}))
+	// the release of a cluster scoped module does not depend on the application
+	g.Expect(releaseNames["cluster-module"]).To(gomega.Equal("cluster-module"))
+	g.Expect(releaseNames["workload-module"]).To(gomega.HavePrefix("scope-test-default-workload-module"))
+}
+
+// revocablePolicyManager denies the access to all datasets once the access is revoked, and fails while it is unavailable
+type revocablePolicyManager struct {
+	connectors.PolicyManager
+	revoked     bool
+	unavailable bool
+}
+
+func (m *revocablePolicyManager) GetPoliciesDecisions(in *openapiclientmodels.PolicyManagerRequest, creds string) (*openapiclientmodels.PolicyManagerResponse, error) {
+	if m.unavailable {
+		return nil, errors.New("the policy manager is unavailable")
+	}
+	if m.revoked {
+		request := *in
+		request.Resource.Name = "s3/deny-dataset"
+		return m.PolicyManager.GetPoliciesDecisions(&request, creds)
+	}
+	return m.PolicyManager.GetPoliciesDecisions(in, creds)
+}
+
+// This test checks that the policies of a running application are evaluated periodically,
+// and that the modules are removed once the access to the data is revoked
+func TestPolicyReevaluation(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+
+	namespaced := types.NamespacedName{
+		Name:      "reevaluation-test",
+		Namespace: "default",
+	}
+	application := &app.FybrikApplication{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/data-usage.yaml", application)).NotTo(gomega.HaveOccurred())
+	application.SetName(namespaced.Name)
+	application.Spec.Data[0] = app.DataContext{
+		DataSetID:    "s3/allow-dataset",
+		Requirements: app.DataRequirements{Interface: app.InterfaceDetails{Protocol: app.ArrowFlight, DataFormat: app.Arrow}},
+	}
+	application.SetGeneration(1)
+
+	// Objects to track in the fake client.
+	objs := []runtime.Object{
+		application,
+	}
+
+	// Register operator types with the runtime scheme.
+	s := utils.NewScheme(g)
+
+	// Create a fake client to mock API calls.
+	cl := fake.NewFakeClientWithScheme(s, objs...)
+
+	// Read module
+	readModule := &app.FybrikModule{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/module-read-parquet.yaml", readModule)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.Background(), readModule)).NotTo(gomega.HaveOccurred(), "the read module could not be created")
+
+	// Create a FybrikApplicationReconciler object with the scheme and fake client.
+	r := createTestFybrikApplicationController(cl, s)
+	policyManager := &revocablePolicyManager{PolicyManager: &mockup.MockPolicyManager{}}
+	r.PolicyManager = policyManager
+	r.PolicyReevaluationInterval = time.Hour
+	req := reconcile.Request{
+		NamespacedName: namespaced,
+	}
+
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+	g.Expect(application.Status.Generated).ToNot(gomega.BeNil())
+	g.Expect(application.Status.PolicyEvaluationTime).ToNot(gomega.BeNil())
+	plotterObjectKey := types.NamespacedName{
+		Namespace: application.Status.Generated.Namespace,
+		Name:      application.Status.Generated.Name,
+	}
+	plotter := &app.Plotter{}
+	g.Expect(cl.Get(context.Background(), plotterObjectKey, plotter)).To(gomega.Succeed())
+	g.Expect(plotter.Spec.Blueprints).To(gomega.HaveLen(1))
+	plotter.Status.ObservedState.Ready = true
+	g.Expect(cl.Update(context.Background(), plotter)).To(gomega.Succeed())
+
+	// the application becomes ready, and a new evaluation is scheduled
+	res, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(res.RequeueAfter).To(gomega.BeNumerically(">", 0))
+	g.Expect(res.RequeueAfter).To(gomega.BeNumerically("<=", time.Hour))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+	g.Expect(application.Status.Ready).To(gomega.BeTrue())
+
+	expireEvaluation := func(age time.Duration) {
+		g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+		application.Status.PolicyEvaluationTime = &metav1.Time{Time: time.Now().Add(-age)}
+		g.Expect(cl.Status().Update(context.Background(), application)).To(gomega.Succeed())
+	}
+	g.Expect(application.Status.PolicyDecisions).ToNot(gomega.BeEmpty())
+	g.Expect(cl.Get(context.Background(), plotterObjectKey, plotter)).To(gomega.Succeed())
+	plotterVersion := plotter.ResourceVersion
+
+	// the policies have not changed, the application remains ready and the plotter is not regenerated
+	expireEvaluation(90 * time.Minute)
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+	g.Expect(application.Status.Ready).To(gomega.BeTrue())
+	g.Expect(application.Status.PolicyEvaluationTime.Time).To(gomega.BeTemporally("~", time.Now(), time.Minute))
+	g.Expect(cl.Get(context.Background(), plotterObjectKey, plotter)).To(gomega.Succeed())
+	g.Expect(plotter.ResourceVersion).To(gomega.Equal(plotterVersion), "The plotter has been regenerated")
+
+	// the policies can not be evaluated, the modules keep running and the evaluation is retried
+	policyManager.unavailable = true
+	expireEvaluation(90 * time.Minute)
+	res, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(res.RequeueAfter).To(gomega.BeNumerically(">", 0))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+	cond := application.Status.AssetStates["s3/allow-dataset"].Conditions[app.ErrorConditionIndex]
+	g.Expect(cond.Status).To(gomega.BeIdenticalTo(corev1.ConditionTrue), "Error condition is not set")
+	g.Expect(cl.Get(context.Background(), plotterObjectKey, plotter)).To(gomega.Succeed())
+	g.Expect(plotter.ResourceVersion).To(gomega.Equal(plotterVersion), "The plotter has been regenerated")
+
+	// the policy manager is available again
+	policyManager.unavailable = false
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+	g.Expect(application.Status.Ready).To(gomega.BeTrue())
+	cond = application.Status.AssetStates["s3/allow-dataset"].Conditions[app.ErrorConditionIndex]
+	g.Expect(cond.Status).To(gomega.BeIdenticalTo(corev1.ConditionFalse), "Error condition is not cleared")
+
+	// the access is revoked
+	policyManager.revoked = true
+	expireEvaluation(90 * time.Minute)
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+	cond = application.Status.AssetStates["s3/allow-dataset"].Conditions[app.DenyConditionIndex]
+	g.Expect(cond.Status).To(gomega.BeIdenticalTo(corev1.ConditionTrue), "Deny condition is not set")
+	g.Expect(cl.Get(context.Background(), plotterObjectKey, plotter)).To(gomega.Succeed())
+	g.Expect(plotter.Spec.Blueprints).To(gomega.BeEmpty(), "The modules have not been removed")
+
+	// the policies can not be evaluated for longer than the evaluation interval, the plotter is removed
+	policyManager.unavailable = true
+	expireEvaluation(3 * time.Hour)
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+	g.Expect(application.Status.Generated).To(gomega.BeNil())
+	g.Expect(application.Status.Ready).To(gomega.BeFalse())
+	g.Expect(cl.Get(context.Background(), plotterObjectKey, plotter)).NotTo(gomega.Succeed())
+}
+
+// countingPolicyManager counts the calls to the policy manager
+type countingPolicyManager struct {
+	connectors.PolicyManager
+	calls int32
+}
+
+func (m *countingPolicyManager) GetPoliciesDecisions(in *openapiclientmodels.PolicyManagerRequest, creds string) (*openapiclientmodels.PolicyManagerResponse, error) {
+	atomic.AddInt32(&m.calls, 1)
+	return m.PolicyManager.GetPoliciesDecisions(in, creds)
+}
+
+// This test checks that the policies are evaluated once for every fallback geography
+func TestFallbackGeographies(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	application := &app.FybrikApplication{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/data-usage.yaml", application)).NotTo(gomega.HaveOccurred())
+	clusters, err := (&mockup.ClusterLister{}).GetClusters()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	clusters = append(clusters, multicluster.Cluster{Name: "mordor-cluster", Metadata: multicluster.ClusterMetadata{Region: "mordor"}})
+	policyManager := &countingPolicyManager{PolicyManager: &mockup.MockPolicyManager{}}
+	moduleManager := &ModuleManager{
+		Log:           ctrl.Log.WithName("test-module-manager"),
+		Clusters:      clusters,
+		PolicyManager: policyManager,
+		Concurrency:   2,
+	}
+
+	// reading the data is allowed in all the geographies except theshire
+	geos := moduleManager.getFallbackGeos("s3/deny-theshire", application, pb.AccessOperation_READ, "neverland", nil)
+	g.Expect(geos).To(gomega.ConsistOf("mordor"))
+	g.Expect(policyManager.calls).To(gomega.Equal(int32(2)))
+	g.Expect(moduleManager.PolicyDecisionRecords()).To(gomega.HaveLen(2))
+
+	// the decisions are not looked up again
+	geos = moduleManager.getFallbackGeos("s3/deny-theshire", application, pb.AccessOperation_READ, "neverland", nil)
+	g.Expect(geos).To(gomega.ConsistOf("mordor"))
+	g.Expect(policyManager.calls).To(gomega.Equal(int32(2)))
+}
+
+// This test checks that the sink of a write module is chosen among the sinks of storage that can be allocated
+func TestSelectWriteSink(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	writeModule := &app.FybrikModule{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/module-write-parquet.yaml", writeModule)).NotTo(gomega.HaveOccurred())
+	interfaces := &writeModule.Spec.Capabilities[0].SupportedInterfaces
+	*interfaces = append([]app.ModuleInOut{
+		{Sink: &app.InterfaceDetails{Protocol: app.JdbcDb2, DataFormat: app.Table}},
+		{Sink: &app.InterfaceDetails{Protocol: app.S3, DataFormat: "csv"}},
+	}, *interfaces...)
+	item := modules.DataInfo{DataDetails: &modules.DataDetails{Interface: app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}}}
+
+	// the format of the existing data is preferred
+	sink, err := selectWriteSink(writeModule, item)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(*sink).To(gomega.Equal(app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}))
+
+	// otherwise the first sink of an allocated storage is used
+	item.DataDetails.Interface.DataFormat = "json"
+	sink, err = selectWriteSink(writeModule, item)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(*sink).To(gomega.Equal(app.InterfaceDetails{Protocol: app.S3, DataFormat: "csv"}))
+
+	// no storage can be allocated for a module that writes to databases only
+	*interfaces = (*interfaces)[:1]
+	_, err = selectWriteSink(writeModule, item)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
@@ -4,16 +4,19 @@
 package app
 
 import (
+	"sort"
 	"strings"
 
 	"emperror.dev/errors"
 	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	motion "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
 	modules "fybrik.io/fybrik/manager/controllers/app/modules"
 	"fybrik.io/fybrik/manager/controllers/utils"
 	connectors "fybrik.io/fybrik/pkg/connectors/clients"
 	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
 	"fybrik.io/fybrik/pkg/multicluster"
 	local "fybrik.io/fybrik/pkg/multicluster/local"
+	"fybrik.io/fybrik/pkg/secrets"
 	"fybrik.io/fybrik/pkg/serde"
 	"fybrik.io/fybrik/pkg/storage"
 	vault "fybrik.io/fybrik/pkg/vault"
@@ -40,33 +43,150 @@ type ModuleManager struct {
 	Provision          storage.ProvisionInterface
 	VaultConnection    vault.Interface
 	ProvisionedStorage map[string]NewAssetInfo
+	// SelectionDetails maps a dataset to the modules evaluated for it
+	SelectionDetails map[string][]app.ModuleSelectionDetails
+	// WorkloadCluster is the cluster where the workload runs, it is nil if there is no workload
+	WorkloadCluster *multicluster.Cluster
+	// PolicyDecisions holds the policy decisions that have been looked up per dataset and operation
+	PolicyDecisions map[policyDecisionKey]PolicyDecision
+	// SecretProvider creates the references with which modules obtain the credentials of data stores
+	SecretProvider secrets.Provider
+	// Concurrency is the maximal number of concurrent calls to the policy manager
+	Concurrency int
+}
+
+// policyDecisionKey identifies the decision for a dataset and an operation
+type policyDecisionKey struct {
+	datasetID   string
+	operation   pb.AccessOperation_AccessType
+	destination string
+}
+
+func newPolicyDecisionKey(datasetID string, op *pb.AccessOperation) policyDecisionKey {
+	return policyDecisionKey{datasetID: datasetID, operation: op.Type, destination: op.Destination}
+}
+
+// PrefetchPolicyDecisions evaluates the policies for reading or writing the datasets in the workload geography.
+// The decisions for all the datasets are looked up together.
+func (m *ModuleManager) PrefetchPolicyDecisions(requirements []modules.DataInfo, appContext *app.FybrikApplication) error {
+	geo, err := m.GetProcessingGeography(appContext)
+	if err != nil {
+		return err
+	}
+	if IsIngest(appContext) {
+		return nil
+	}
+	datasetsPerOperation := map[pb.AccessOperation_AccessType][]string{}
+	for _, item := range requirements {
+		operation := pb.AccessOperation_READ
+		if item.Context.Requirements.Flow == app.WriteFlow {
+			operation = pb.AccessOperation_WRITE
+		}
+		datasetsPerOperation[operation] = append(datasetsPerOperation[operation], item.Context.DataSetID)
+	}
+	for operation, datasetIDs := range datasetsPerOperation {
+		op := &pb.AccessOperation{Type: operation, Destination: geo}
+		for datasetID, decision := range LookupPoliciesDecisions(datasetIDs, m.PolicyManager, appContext, op, m.Concurrency) {
+			m.keepPolicyDecision(datasetID, op, decision)
+		}
+	}
+	return nil
+}
+
+func (m *ModuleManager) keepPolicyDecision(datasetID string, op *pb.AccessOperation, decision PolicyDecision) {
+	if m.PolicyDecisions == nil {
+		m.PolicyDecisions = make(map[policyDecisionKey]PolicyDecision)
+	}
+	m.PolicyDecisions[newPolicyDecisionKey(datasetID, op)] = decision
+}
+
+// lookupPolicyDecisions returns the decision that has been already looked up for the given dataset and operation,
+// or calls the policy manager otherwise
+func (m *ModuleManager) lookupPolicyDecisions(datasetID string, appContext *app.FybrikApplication, op *pb.AccessOperation) ([]*pb.EnforcementAction, error) {
+	decision, found := m.PolicyDecisions[newPolicyDecisionKey(datasetID, op)]
+	if !found {
+		actions, err := LookupPolicyDecisions(datasetID, m.PolicyManager, appContext, op)
+		decision = PolicyDecision{Actions: actions, Err: err}
+		m.keepPolicyDecision(datasetID, op, decision)
+	}
+	return append([]*pb.EnforcementAction{}, decision.Actions...), decision.Err
+}
+
+// PolicyDecisionRecords returns the records of the policy decisions that have been looked up, sorted by dataset and operation
+func (m *ModuleManager) PolicyDecisionRecords() []app.PolicyDecisionRecord {
+	records := []app.PolicyDecisionRecord{}
+	for key, decision := range m.PolicyDecisions {
+		records = append(records, app.PolicyDecisionRecord{
+			DataSetID:   key.datasetID,
+			Operation:   key.operation.String(),
+			Destination: key.destination,
+			Digest:      policyDecisionDigest(decision),
+		})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].DataSetID != records[j].DataSetID {
+			return records[i].DataSetID < records[j].DataSetID
+		}
+		if records[i].Operation != records[j].Operation {
+			return records[i].Operation < records[j].Operation
+		}
+		return records[i].Destination < records[j].Destination
+	})
+	return records
+}
+
+// recordSelection keeps the reasons for choosing or rejecting modules for the given dataset.
+// A module that is evaluated several times for the same capability, e.g. for each copy destination, is recorded once:
+// the latest evaluation is kept, unless the module has been already chosen.
+func (m *ModuleManager) recordSelection(datasetID string, selector *modules.Selector) {
+	if m.SelectionDetails == nil {
+		m.SelectionDetails = make(map[string][]app.ModuleSelectionDetails)
+	}
+	details := m.SelectionDetails[datasetID]
+	for _, candidate := range selector.Candidates {
+		recorded := false
+		for i := range details {
+			if details[i].Module == candidate.Module && details[i].Capability == candidate.Capability {
+				if !details[i].Selected {
+					details[i] = candidate
+				}
+				recorded = true
+				break
+			}
+		}
+		if !recorded {
+			details = append(details, candidate)
+		}
+	}
+	m.SelectionDetails[datasetID] = details
 }
 
 // SelectModuleInstances builds a list of required modules with the relevant arguments
 /*
 
-Future (ingest & write) order of the lookup to support ingest is:
-- If no label selector assume ingest of external data (what about archive in future?)
-	- run Copy module close to destination (determined based on governance decisions)
-	- and register new data set in data catalog
+Future (write) order of the lookup is:
 - If Data Context Capability=Write
    - Write is always required, and always close to compute
    - Implicit Copy is used on demand, e.g. if a write module does not support the existing source of data or governance actions
    - Transformations are always done at workload location
    - If not external data, then register in data catalog
 
-Updates to add ingest:
+Current order of the lookup:
 - If no label selector assume ingest of external data
+	- Copy is always required
 	- run Copy module close to destination (determined based on governance decisions)
-	- and register new data set in data catalog
+	- the allocated storage becomes persistent, and the new data set is registered in data catalog once the copy is ready
 - Otherwise assume workload wants to read from cataloged data
    - Read is always required.
    - Copy is used on demand, e.g. if a read module does not support the existing source of data or actions
    - Transformations are always done at data source location
    - Read module runs close to compute (in processing geography)
-   - Write module has not yet been implemented - will be implemented in future release
    - All data sets are processed, even if an error is encountered in one or more, to provide a complete status at the end of the reconcile
    - Dependencies are checked but not added yet to the blueprint
+   - Actions that the read module does not support are performed by a chain of transform modules, if the data resides in the processing geography
+- If Data Context Flow=Write
+   - Write module runs close to compute (in processing geography) and performs the write actions
+   - A new storage is allocated in a geography where writing is allowed
 */
 
 // GetCopyDestination creates a Dataset for bucket allocation by implicit copies or ingest.
@@ -104,7 +224,7 @@ func (m *ModuleManager) GetCopyDestination(item modules.DataInfo, destinationInt
 		Storage: bucket,
 		Details: &pb.DatasetDetails{
 			Name:       originalAssetName,
-			Geo:        item.DataDetails.Geography,
+			Geo:        geo,
 			DataFormat: destinationInterface.DataFormat,
 			DataStore:  datastore,
 			Metadata:   item.DataDetails.Metadata,
@@ -112,23 +232,45 @@ func (m *ModuleManager) GetCopyDestination(item modules.DataInfo, destinationInt
 	m.ProvisionedStorage[item.Context.DataSetID] = assetInfo
 	utils.PrintStructure(&assetInfo, m.Log, "ProvisionedStorage element")
 
-	vaultSecretPath := vault.PathForReadingKubeSecret(bucket.SecretRef.Namespace, bucket.SecretRef.Name)
-	vaultMap := make(map[string]app.Vault)
-	vaultMap[string(app.WriteFlow)] = app.Vault{
-		SecretPath: vaultSecretPath,
-		Role:       utils.GetModulesRole(),
-		Address:    utils.GetVaultAddress(),
-	}
-	return &app.DataStore{
-		Vault:      vaultMap,
+	dataStore := &app.DataStore{
 		Connection: *connection,
 		Format:     destinationInterface.DataFormat,
-	}, nil
+	}
+	setCredentials(dataStore, app.WriteFlow, m.SecretProvider.ForSecret(bucket.SecretRef))
+	return dataStore, nil
+}
+
+// setCredentials sets the credentials of a data store for the given flow.
+// Credentials that are read from Vault are also set in the Vault property, which modules used before the
+// provider-neutral credential references were introduced.
+func setCredentials(dataStore *app.DataStore, flow app.DataFlow, reference app.CredentialReference) {
+	if dataStore.Credentials == nil {
+		dataStore.Credentials = make(map[string]app.CredentialReference)
+	}
+	dataStore.Credentials[string(flow)] = reference
+	if reference.Vault != nil {
+		if dataStore.Vault == nil {
+			dataStore.Vault = make(map[string]app.Vault)
+		}
+		dataStore.Vault[string(flow)] = *reference.Vault
+	}
+}
+
+// setVaultAuthPath sets the path of the auth method with which the modules in the given cluster log into Vault
+func setVaultAuthPath(dataStore *app.DataStore, flow app.DataFlow, cluster *multicluster.Cluster) {
+	authPath := utils.GetAuthPath(cluster.Metadata.VaultAuthPath)
+	if vaultCredentials, ok := dataStore.Vault[string(flow)]; ok {
+		vaultCredentials.AuthPath = authPath
+		dataStore.Vault[string(flow)] = vaultCredentials
+	}
+	if reference, ok := dataStore.Credentials[string(flow)]; ok && reference.Vault != nil {
+		reference.Vault.AuthPath = authPath
+	}
 }
 
 func (m *ModuleManager) selectReadModule(item modules.DataInfo, appContext *app.FybrikApplication) (*modules.Selector, error) {
 	// read module is required if the workload exists
-	if appContext.Spec.Selector.WorkloadSelector.Size() == 0 {
+	if IsIngest(appContext) {
 		return nil, nil
 	}
 	m.Log.Info("Select read path for " + item.Context.DataSetID)
@@ -136,28 +278,69 @@ func (m *ModuleManager) selectReadModule(item modules.DataInfo, appContext *app.
 	// Read policies for data that is processed in the workload geography
 	var readActions []*pb.EnforcementAction
 	var err error
-	readActions, err = LookupPolicyDecisions(item.Context.DataSetID, m.PolicyManager, appContext,
+	readActions, err = m.lookupPolicyDecisions(item.Context.DataSetID, appContext,
 		&pb.AccessOperation{Type: pb.AccessOperation_READ, Destination: m.WorkloadGeography})
 	if err != nil {
 		return nil, err
 	}
 	// select a read module that supports user interface requirements
-	// actions are not checked since they are not necessarily done by the read module
+	// actions are not checked since they are not necessarily done by the read module,
+	// but modules that support the data source and perform the actions are preferred
 	readSelector := &modules.Selector{Capability: app.Read,
+		Destination:     &item.Context.Requirements.Interface,
+		Actions:         []*pb.EnforcementAction{},
+		OptionalActions: readActions,
+		Source:          &item.DataDetails.Interface,
+		Dependencies:    []*app.FybrikModule{},
+		Module:          nil,
+		Message:         "",
+		Geo:             m.WorkloadGeography,
+		Clusters:        m.Clusters,
+	}
+	found := readSelector.SelectModule(m.Modules)
+	m.recordSelection(item.Context.DataSetID, readSelector)
+	if !found {
+		m.Log.Info(readSelector.GetError())
+		return nil, errors.New(readSelector.GetError())
+	}
+	readSelector.Actions = readActions
+	return readSelector, nil
+}
+
+func (m *ModuleManager) selectWriteModule(item modules.DataInfo, appContext *app.FybrikApplication) (*modules.Selector, error) {
+	// write module is required if the workload writes the data
+	if item.Context.Requirements.Flow != app.WriteFlow {
+		return nil, nil
+	}
+	if IsIngest(appContext) {
+		return nil, errors.New("a workload is required for writing " + item.Context.DataSetID)
+	}
+	m.Log.Info("Select write path for " + item.Context.DataSetID)
+
+	// Write policies for data that is written from the workload geography
+	writeActions, err := m.lookupPolicyDecisions(item.Context.DataSetID, appContext,
+		&pb.AccessOperation{Type: pb.AccessOperation_WRITE, Destination: m.WorkloadGeography})
+	if err != nil {
+		return nil, err
+	}
+	// select a write module that supports user interface requirements and performs the write actions
+	writeSelector := &modules.Selector{Capability: app.Write,
 		Destination:  &item.Context.Requirements.Interface,
-		Actions:      []*pb.EnforcementAction{},
+		Actions:      writeActions,
 		Source:       nil,
 		Dependencies: []*app.FybrikModule{},
 		Module:       nil,
 		Message:      "",
 		Geo:          m.WorkloadGeography,
+		Clusters:     m.Clusters,
 	}
-	if !readSelector.SelectModule(m.Modules) {
-		m.Log.Info(readSelector.GetError())
-		return nil, errors.New(readSelector.GetError())
+	found := writeSelector.SelectModule(m.Modules)
+	m.recordSelection(item.Context.DataSetID, writeSelector)
+	if !found {
+		m.Log.Info(writeSelector.GetError())
+		return nil, errors.New(writeSelector.GetError())
 	}
-	readSelector.Actions = readActions
-	return readSelector, nil
+	return writeSelector, nil
 }
 
 func (m *ModuleManager) selectCopyModule(item modules.DataInfo, appContext *app.FybrikApplication, readSelector *modules.Selector) (*modules.Selector, error) {
@@ -167,7 +350,8 @@ func (m *ModuleManager) selectCopyModule(item modules.DataInfo, appContext *app.
 	additionalActions := []*pb.EnforcementAction{}
 	if readSelector != nil {
 		copyRequired, interfaces, additionalActions = m.getCopyRequirements(item, readSelector)
-	} else if item.Context.Requirements.Copy.Required {
+	} else if item.Context.Requirements.Copy.Required || IsIngest(appContext) {
+		// ingest of external data always requires a copy
 		copyRequired = true
 		interfaces = []*app.InterfaceDetails{&item.Context.Requirements.Interface}
 	}
@@ -176,6 +360,10 @@ func (m *ModuleManager) selectCopyModule(item modules.DataInfo, appContext *app.
 	}
 	actionsOnCopy := []*pb.EnforcementAction{}
 	geo := m.WorkloadGeography
+	if readSelector != nil {
+		// the data is copied to the geography in which read runs
+		geo = readSelector.Geo
+	}
 	// WRITE actions
 	if readSelector == nil {
 		var err error
@@ -197,9 +385,13 @@ func (m *ModuleManager) selectCopyModule(item modules.DataInfo, appContext *app.
 			Dependencies: make([]*app.FybrikModule, 0),
 			Module:       nil,
 			Geo:          geo,
+			Ingest:       IsIngest(appContext),
+			Clusters:     m.Clusters,
 			Message:      ""}
 
-		if copySelector.SelectModule(m.Modules) {
+		found := copySelector.SelectModule(m.Modules)
+		m.recordSelection(item.Context.DataSetID, copySelector)
+		if found {
 			break
 		}
 	}
@@ -214,33 +406,40 @@ func (m *ModuleManager) selectCopyModule(item modules.DataInfo, appContext *app.
 }
 
 // SelectModuleInstances selects the necessary read/copy/write modules for the blueprint for a given data set
-// Write path is not yet implemented
 func (m *ModuleManager) SelectModuleInstances(item modules.DataInfo, appContext *app.FybrikApplication) ([]modules.ModuleInstanceSpec, error) {
 	datasetID := item.Context.DataSetID
 	m.Log.Info("Select modules for " + datasetID)
 	instances := make([]modules.ModuleInstanceSpec, 0)
 	var err error
-	if m.WorkloadGeography, err = m.GetProcessingGeography(appContext); err != nil {
-		m.Log.Info("Could not determine the workload geography")
+	if m.WorkloadCluster, err = m.GetWorkloadCluster(appContext); err != nil {
+		m.Log.Info("Could not determine the workload cluster")
 		return nil, err
 	}
+	m.WorkloadGeography = ""
+	if m.WorkloadCluster != nil {
+		m.WorkloadGeography = m.WorkloadCluster.Metadata.Region
+	}
 
-	// Set the value received from the catalog connector.
-	vaultSecretPath := item.VaultSecretPath
+	if item.Context.Requirements.Flow == app.WriteFlow {
+		return m.selectWriteInstances(item, appContext)
+	}
 
 	// Each selector receives source/sink interface and relevant actions
 	// Starting with the data location interface for source and the required interface for sink
-	vaultMap := make(map[string]app.Vault)
-	vaultMap[string(app.ReadFlow)] = app.Vault{
-		SecretPath: vaultSecretPath,
-		Role:       utils.GetModulesRole(),
-		Address:    utils.GetVaultAddress(),
-	}
 	sourceDataStore := &app.DataStore{
 		Connection: item.DataDetails.Connection,
-		Vault:      vaultMap,
 		Format:     item.DataDetails.Interface.DataFormat,
 	}
+	// Set the value received from the catalog connector.
+	// Datasets without credentials are only referenced in Vault for the compatibility of existing modules.
+	if item.VaultSecretPath != "" || m.SecretProvider.Name() == app.VaultSecretProvider {
+		reference, err := m.SecretProvider.ForPath(item.VaultSecretPath)
+		if err != nil {
+			m.Log.Info("Could not reference the credentials of " + datasetID + ": " + err.Error())
+			return nil, err
+		}
+		setCredentials(sourceDataStore, app.ReadFlow, reference)
+	}
 
 	// DataStore for destination will be determined if an implicit copy is required
 	var sinkDataStore *app.DataStore
@@ -250,6 +449,31 @@ func (m *ModuleManager) SelectModuleInstances(item modules.DataInfo, appContext
 		m.Log.Info("Could not select a read module for " + datasetID + " : " + err.Error())
 		return instances, err
 	}
+
+	// the read module runs preferably in the workload cluster
+	// copy and transform modules are co-located with the read module when possible
+	var readCluster string
+	copyAffinity := modules.Affinity{}
+	if readSelector != nil {
+		if readCluster, err = m.selectCluster(item, appContext, readSelector, m.workloadAffinity(), pb.AccessOperation_READ); err != nil {
+			m.Log.Info("Could not determine the cluster for read: " + err.Error())
+			return instances, err
+		}
+		// the transform chain and the copy requirements depend on the geography in which read runs,
+		// which differs from the workload geography if read runs in a fallback geography
+		for i := range m.Clusters {
+			if readCluster == m.Clusters[i].Name {
+				readSelector.Geo = m.Clusters[i].Metadata.Region
+				break
+			}
+		}
+		copyAffinity = modules.Affinity{Cluster: readCluster, Zone: m.workloadAffinity().Zone}
+	}
+
+	var transformSelectors []*modules.Selector
+	if readSelector != nil {
+		transformSelectors = m.selectTransformModules(item, readSelector)
+	}
 	if copySelector, err = m.selectCopyModule(item, appContext, readSelector); err != nil {
 		m.Log.Info("Could not select a copy module for " + datasetID + " : " + err.Error())
 		return instances, err
@@ -257,13 +481,23 @@ func (m *ModuleManager) SelectModuleInstances(item modules.DataInfo, appContext
 
 	if copySelector != nil {
 		m.Log.Info("Found copy module " + copySelector.GetModule().Name + " for " + datasetID)
-		// copy should be applied - allocate storage
+		copyCluster, err := m.selectCluster(item, appContext, copySelector, copyAffinity, pb.AccessOperation_READ)
+		if err != nil {
+			m.Log.Info("Could not determine the cluster for copy: " + err.Error())
+			return instances, err
+		}
+		// the copy must enforce all the actions, otherwise the data is moved without them
+		actions, err := copyActionsToArbitrary(copySelector.Actions)
+		if err != nil {
+			m.Log.Info("Could not enforce the actions on the copy of " + datasetID + " : " + err.Error())
+			return instances, err
+		}
+		// copy should be applied - allocate storage once the copy module can run
 		if sinkDataStore, err = m.GetCopyDestination(item, copySelector.Destination, copySelector.Geo); err != nil {
 			m.Log.Info("Allocation failed: " + err.Error())
 			return instances, err
 		}
 		// append moduleinstances to the list
-		actions := actionsToArbitrary(copySelector.Actions)
 		copyArgs := &app.ModuleArguments{
 			Copy: &app.CopyModuleArgs{
 				Source:          *sourceDataStore,
@@ -271,22 +505,21 @@ func (m *ModuleManager) SelectModuleInstances(item modules.DataInfo, appContext
 				Transformations: actions,
 			},
 		}
-		copyCluster, err := copySelector.SelectCluster(item, m.Clusters)
-		if err != nil {
-			m.Log.Info("Could not determine the cluster for copy: " + err.Error())
-			return instances, err
-		}
-		for _, cluster := range m.Clusters {
-			if copyCluster == cluster.Name {
-				if writeVault, ok := copyArgs.Copy.Destination.Vault[string(app.WriteFlow)]; ok {
-					writeVault.AuthPath = utils.GetAuthPath(cluster.Metadata.VaultAuthPath)
-				}
-				if readVault, ok := copyArgs.Copy.Source.Vault[string(app.ReadFlow)]; ok {
-					readVault.AuthPath = utils.GetAuthPath(cluster.Metadata.VaultAuthPath)
-				}
+		for i := range m.Clusters {
+			if copyCluster == m.Clusters[i].Name {
+				setVaultAuthPath(&copyArgs.Copy.Destination, app.WriteFlow, &m.Clusters[i])
+				setVaultAuthPath(&copyArgs.Copy.Source, app.ReadFlow, &m.Clusters[i])
 				break
 			}
 		}
+		if err = setTransferDataStore(&copyArgs.Copy.Source, app.ReadFlow); err != nil {
+			m.Log.Info("Could not map the source of the copy: " + err.Error())
+			return instances, err
+		}
+		if err = setTransferDataStore(&copyArgs.Copy.Destination, app.WriteFlow); err != nil {
+			m.Log.Info("Could not map the destination of the copy: " + err.Error())
+			return instances, err
+		}
 
 		m.Log.Info("Adding copy module")
 		instances = copySelector.AddModuleInstances(copyArgs, item, copyCluster)
@@ -302,16 +535,9 @@ func (m *ModuleManager) SelectModuleInstances(item modules.DataInfo, appContext
 		}
 
 		actions := actionsToArbitrary(readSelector.Actions)
-		readCluster, err := readSelector.SelectCluster(item, m.Clusters)
-		if err != nil {
-			m.Log.Info("Could not determine the cluster for read: " + err.Error())
-			return instances, err
-		}
-		for _, cluster := range m.Clusters {
-			if readCluster == cluster.Name {
-				if readVault, ok := readSource.Vault[string(app.ReadFlow)]; ok {
-					readVault.AuthPath = utils.GetAuthPath(cluster.Metadata.VaultAuthPath)
-				}
+		for i := range m.Clusters {
+			if readCluster == m.Clusters[i].Name {
+				setVaultAuthPath(&readSource, app.ReadFlow, &m.Clusters[i])
 				break
 			}
 		}
@@ -328,10 +554,158 @@ func (m *ModuleManager) SelectModuleInstances(item modules.DataInfo, appContext
 		}
 
 		instances = append(instances, readSelector.AddModuleInstances(readArgs, item, readCluster)...)
+
+		// transform modules are chained after the read module
+		// Input holds the name of the previous module, it is replaced by the instance name when the blueprint is generated
+		input := readSelector.GetModule().Name
+		for _, transformSelector := range transformSelectors {
+			m.Log.Info("Adding transform module " + transformSelector.GetModule().Name)
+			transformAffinity := modules.Affinity{Cluster: readCluster, Zone: m.workloadAffinity().Zone}
+			transformCluster, err := m.selectCluster(item, appContext, transformSelector, transformAffinity, pb.AccessOperation_READ)
+			if err != nil {
+				m.Log.Info("Could not determine the cluster for transform: " + err.Error())
+				return instances, err
+			}
+			transformArgs := &app.ModuleArguments{
+				Transform: []app.TransformModuleArgs{
+					{
+						AssetID:         utils.CreateDataSetIdentifier(item.Context.DataSetID),
+						Input:           input,
+						Transformations: actionsToArbitrary(transformSelector.Actions),
+					},
+				},
+			}
+			instances = append(instances, transformSelector.AddModuleInstances(transformArgs, item, transformCluster)...)
+			input = transformSelector.GetModule().Name
+		}
 	}
 	return instances, nil
 }
 
+// selectTransformModules chains transform modules after the read module to perform the actions that the read module does not support.
+// Transformations are done together with read, thus a chain is built only if the data resides in the processing geography.
+// Otherwise, or if the transform modules can not perform all the actions, the actions are left to an implicit copy.
+func (m *ModuleManager) selectTransformModules(item modules.DataInfo, readSelector *modules.Selector) []*modules.Selector {
+	if item.DataDetails.Geography != readSelector.Geo {
+		return nil
+	}
+	supported := []*pb.EnforcementAction{}
+	unsupported := []*pb.EnforcementAction{}
+	for _, action := range readSelector.Actions {
+		if readSelector.SupportsGovernanceAction(readSelector.GetModule(), action) {
+			supported = append(supported, action)
+		} else {
+			unsupported = append(unsupported, action)
+		}
+	}
+	if len(unsupported) == 0 {
+		return nil
+	}
+	m.Log.Info("Select transform modules for " + item.Context.DataSetID)
+	chain, err := modules.SelectTransformModules(m.Modules, readSelector.Destination, unsupported, readSelector.Geo, m.Clusters)
+	for _, selector := range chain {
+		m.recordSelection(item.Context.DataSetID, selector)
+	}
+	if err != nil {
+		m.Log.Info("Could not find transform modules for " + item.Context.DataSetID + " : " + err.Error())
+		return nil
+	}
+	readSelector.Actions = supported
+	return chain
+}
+
+// selectWriteInstances selects the write module for a given data set and allocates the storage it writes to
+func (m *ModuleManager) selectWriteInstances(item modules.DataInfo, appContext *app.FybrikApplication) ([]modules.ModuleInstanceSpec, error) {
+	datasetID := item.Context.DataSetID
+	instances := make([]modules.ModuleInstanceSpec, 0)
+	writeSelector, err := m.selectWriteModule(item, appContext)
+	if err != nil {
+		m.Log.Info("Could not select a write module for " + datasetID + " : " + err.Error())
+		return instances, err
+	}
+	m.Log.Info("Found write module " + writeSelector.GetModule().Name + " for " + datasetID)
+	// the storage is allocated in a geography where writing is allowed
+	_, storageGeo, err := m.enforceWritePolicies(appContext, datasetID)
+	if err != nil {
+		m.Log.Info("Could not find a geography for writing " + datasetID + " : " + err.Error())
+		return instances, err
+	}
+	sinkInterface, err := selectWriteSink(writeSelector.GetModule(), item)
+	if err != nil {
+		m.Log.Info("Could not find a sink for writing " + datasetID + " : " + err.Error())
+		return instances, err
+	}
+	writeCluster, err := m.selectCluster(item, appContext, writeSelector, m.workloadAffinity(), pb.AccessOperation_WRITE)
+	if err != nil {
+		m.Log.Info("Could not determine the cluster for write: " + err.Error())
+		return instances, err
+	}
+	// the storage is allocated once the write module can run
+	sinkDataStore, err := m.GetCopyDestination(item, sinkInterface, storageGeo)
+	if err != nil {
+		m.Log.Info("Allocation failed: " + err.Error())
+		return instances, err
+	}
+	for i := range m.Clusters {
+		if writeCluster == m.Clusters[i].Name {
+			setVaultAuthPath(sinkDataStore, app.WriteFlow, &m.Clusters[i])
+			break
+		}
+	}
+	writeArgs := &app.ModuleArguments{
+		Write: []app.WriteModuleArgs{
+			{
+				Destination:     *sinkDataStore,
+				AssetID:         utils.CreateDataSetIdentifier(datasetID),
+				Transformations: actionsToArbitrary(writeSelector.Actions),
+			},
+		},
+	}
+	m.Log.Info("Adding write path")
+	return writeSelector.AddModuleInstances(writeArgs, item, writeCluster), nil
+}
+
+// GetSupportedWriteSinks returns a list of supported WRITE interfaces of a module
+func GetSupportedWriteSinks(module *app.FybrikModule) []*app.InterfaceDetails {
+	var list []*app.InterfaceDetails
+
+	// Check if the module supports WRITE
+	if hasCapability, caps := utils.GetModuleCapabilities(module, app.Write); hasCapability {
+		for _, cap := range caps {
+			// Collect the interface sinks
+			for _, inter := range cap.SupportedInterfaces {
+				if inter.Sink != nil {
+					list = append(list, inter.Sink)
+				}
+			}
+		}
+	}
+	return list
+}
+
+// selectWriteSink chooses the interface with which the write module stores the data among the sinks it supports.
+// Only sinks of storage that can be allocated, i.e. S3 buckets, are considered, and a sink in the format of the
+// existing data is preferred.
+func selectWriteSink(module *app.FybrikModule, item modules.DataInfo) (*app.InterfaceDetails, error) {
+	var candidates []*app.InterfaceDetails
+	for _, sink := range GetSupportedWriteSinks(module) {
+		if sink.Protocol == app.S3 {
+			candidates = append(candidates, sink)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New(string(app.Write) + " : " + module.Name + " does not support a sink of allocated " + app.S3 + " storage")
+	}
+	if item.DataDetails != nil {
+		for _, sink := range candidates {
+			if sink.DataFormat == item.DataDetails.Interface.DataFormat {
+				return sink, nil
+			}
+		}
+	}
+	return candidates[0], nil
+}
+
 // GetSupportedReadSources returns a list of supported READ interfaces of a module
 func GetSupportedReadSources(module *app.FybrikModule) []*app.InterfaceDetails {
 	var list []*app.InterfaceDetails
@@ -409,7 +783,7 @@ func (m *ModuleManager) enforceWritePolicies(appContext *app.FybrikApplication,
 	actions := []*pb.EnforcementAction{}
 	//	if the cluster selector is non-empty, the write will be done to the specified geography if possible
 	if m.WorkloadGeography != "" {
-		if actions, err = LookupPolicyDecisions(datasetID, m.PolicyManager, appContext,
+		if actions, err = m.lookupPolicyDecisions(datasetID, appContext,
 			&pb.AccessOperation{Type: pb.AccessOperation_WRITE, Destination: m.WorkloadGeography}); err == nil {
 			return actions, m.WorkloadGeography, nil
 		}
@@ -417,7 +791,7 @@ func (m *ModuleManager) enforceWritePolicies(appContext *app.FybrikApplication,
 	var excludedGeos string
 	for _, cluster := range m.Clusters {
 		operation := &pb.AccessOperation{Type: pb.AccessOperation_WRITE, Destination: cluster.Metadata.Region}
-		if actions, err = LookupPolicyDecisions(datasetID, m.PolicyManager, appContext, operation); err == nil {
+		if actions, err = m.lookupPolicyDecisions(datasetID, appContext, operation); err == nil {
 			return actions, cluster.Metadata.Region, nil
 		}
 		if err.Error() != app.WriteNotAllowed {
@@ -431,32 +805,141 @@ func (m *ModuleManager) enforceWritePolicies(appContext *app.FybrikApplication,
 	return actions, "", errors.New("writing to all geographies is denied: " + excludedGeos)
 }
 
-// GetProcessingGeography determines the geography of the workload cluster.
+// IsIngest returns true if no workload has been specified, i.e. external data is ingested into a governed store
+func IsIngest(appContext *app.FybrikApplication) bool {
+	return appContext.Spec.Selector.WorkloadSelector.Size() == 0
+}
+
+// GetWorkloadCluster determines the cluster where the workload runs.
 // If no cluster has been specified for a workload, a local cluster is assumed.
-func (m *ModuleManager) GetProcessingGeography(applicationContext *app.FybrikApplication) (string, error) {
+// It returns nil if there is no workload.
+func (m *ModuleManager) GetWorkloadCluster(applicationContext *app.FybrikApplication) (*multicluster.Cluster, error) {
 	clusterName := applicationContext.Spec.Selector.ClusterName
 	if clusterName == "" {
-		if applicationContext.Spec.Selector.WorkloadSelector.Size() == 0 {
+		if IsIngest(applicationContext) {
 			// no workload
-			return "", nil
+			return nil, nil
 		}
 		// the workload runs in a local cluster
 		localClusterManager, err := local.NewManager(m.Client, utils.GetSystemNamespace())
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 		clusters, err := localClusterManager.GetClusters()
 		if err != nil || len(clusters) != 1 {
-			return "", err
+			return nil, err
 		}
-		return clusters[0].Metadata.Region, nil
+		return &clusters[0], nil
 	}
+	for i := range m.Clusters {
+		if m.Clusters[i].Name == clusterName {
+			return &m.Clusters[i], nil
+		}
+	}
+	return nil, errors.New("Unknown cluster: " + clusterName)
+}
+
+// GetProcessingGeography determines the geography of the workload cluster.
+// If no cluster has been specified for a workload, a local cluster is assumed.
+func (m *ModuleManager) GetProcessingGeography(applicationContext *app.FybrikApplication) (string, error) {
+	cluster, err := m.GetWorkloadCluster(applicationContext)
+	if err != nil || cluster == nil {
+		return "", err
+	}
+	return cluster.Metadata.Region, nil
+}
+
+// workloadAffinity prefers running modules in the workload cluster, or at least in its zone
+func (m *ModuleManager) workloadAffinity() modules.Affinity {
+	if m.WorkloadCluster == nil {
+		return modules.Affinity{}
+	}
+	return modules.Affinity{Cluster: m.WorkloadCluster.Name, Zone: m.WorkloadCluster.Metadata.Zone}
+}
+
+// selectCluster chooses the cluster for the module according to the given affinity.
+// If no cluster in the module geography can run the module, the geographies allowed by the governance policies are used as a fallback.
+func (m *ModuleManager) selectCluster(item modules.DataInfo, appContext *app.FybrikApplication, selector *modules.Selector,
+	affinity modules.Affinity, operation pb.AccessOperation_AccessType) (string, error) {
+	selector.Affinity = affinity
+	cluster, err := selector.SelectCluster(item, m.Clusters)
+	if err == nil {
+		return cluster, nil
+	}
+	selector.Affinity.FallbackGeos = m.getFallbackGeos(item.Context.DataSetID, appContext, operation, selector.GetGeo(item), selector.Actions)
+	if len(selector.Affinity.FallbackGeos) == 0 {
+		return "", err
+	}
+	m.Log.Info("Trying fallback geographies for " + selector.GetModule().Name + ": " + strings.Join(selector.Affinity.FallbackGeos, ", "))
+	return selector.SelectCluster(item, m.Clusters)
+}
+
+// getFallbackGeos returns the cluster geographies, other than the given one, where the operation is allowed by the governance policies.
+// A geography is allowed if the policy decision does not deny the operation, and requires only actions that are already performed.
+func (m *ModuleManager) getFallbackGeos(datasetID string, appContext *app.FybrikApplication, operation pb.AccessOperation_AccessType,
+	geo string, performed []*pb.EnforcementAction) []string {
+	var regions []string
+	checked := map[string]bool{geo: true}
 	for _, cluster := range m.Clusters {
-		if cluster.Name == clusterName {
-			return cluster.Metadata.Region, nil
+		region := cluster.Metadata.Region
+		if checked[region] {
+			continue
+		}
+		checked[region] = true
+		regions = append(regions, region)
+	}
+	m.prefetchFallbackDecisions(datasetID, appContext, operation, regions)
+	var geos []string
+	for _, region := range regions {
+		actions, err := m.lookupPolicyDecisions(datasetID, appContext, &pb.AccessOperation{Type: operation, Destination: region})
+		if err != nil {
+			m.Log.Info("Geography " + region + " is not allowed for " + datasetID + " : " + err.Error())
+			continue
+		}
+		if !includesActions(performed, actions) {
+			m.Log.Info("Geography " + region + " requires additional actions for " + datasetID)
+			continue
+		}
+		geos = append(geos, region)
+	}
+	return geos
+}
+
+// prefetchFallbackDecisions looks up the decisions for the operation in the given geographies that have not been looked up yet.
+// The geographies are evaluated concurrently, and the decisions are kept for the following lookups.
+func (m *ModuleManager) prefetchFallbackDecisions(datasetID string, appContext *app.FybrikApplication, operation pb.AccessOperation_AccessType,
+	regions []string) {
+	var ops []*pb.AccessOperation
+	for _, region := range regions {
+		op := &pb.AccessOperation{Type: operation, Destination: region}
+		if _, found := m.PolicyDecisions[newPolicyDecisionKey(datasetID, op)]; !found {
+			ops = append(ops, op)
+		}
+	}
+	decisions := make([]PolicyDecision, len(ops))
+	utils.RunConcurrently(len(ops), m.Concurrency, func(i int) {
+		decisions[i] = LookupPoliciesDecisions([]string{datasetID}, m.PolicyManager, appContext, ops[i], 1)[datasetID]
+	})
+	for i, op := range ops {
+		m.keepPolicyDecision(datasetID, op, decisions[i])
+	}
+}
+
+// includesActions checks whether all the required actions are found in the given list
+func includesActions(actions []*pb.EnforcementAction, required []*pb.EnforcementAction) bool {
+	for _, action := range required {
+		found := false
+		for _, a := range actions {
+			if a.Id == action.Id && a.Level == action.Level {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
 		}
 	}
-	return "", errors.New("Unknown cluster: " + clusterName)
+	return true
 }
 
 func actionsToArbitrary(actions []*pb.EnforcementAction) []serde.Arbitrary {
@@ -467,3 +950,52 @@ func actionsToArbitrary(actions []*pb.EnforcementAction) []serde.Arbitrary {
 	}
 	return result
 }
+
+// copyAction is an enforcement action of a copy module together with the transformation of a transfer that
+// implements the action
+type copyAction struct {
+	*pb.EnforcementAction
+	Transformation *motion.Transformation `json:"transformation,omitempty"`
+}
+
+// copyActionsToArbitrary serializes the actions of a copy module like actionsToArbitrary. Every action also holds
+// the transformation of a transfer that implements it. An error naming the action is returned if an action is not
+// supported by a registered transformation plugin, since the copy would otherwise move the data without enforcing it.
+func copyActionsToArbitrary(actions []*pb.EnforcementAction) ([]serde.Arbitrary, error) {
+	result := []serde.Arbitrary{}
+	for _, action := range actions {
+		transformation, err := transformationFromAction(action)
+		if err != nil {
+			return nil, errors.New("the copy does not support the action " + action.Id + ": " + err.Error())
+		}
+		raw := serde.NewArbitrary(copyAction{EnforcementAction: action, Transformation: transformation})
+		result = append(result, *raw)
+	}
+	return result, nil
+}
+
+// copyConnection is the connection of a data store of a copy module. It holds the data store as returned by the
+// catalog together with the data store of a transfer that accesses it.
+type copyConnection struct {
+	*pb.DataStore
+	Transfer *motion.DataStore `json:"transfer,omitempty"`
+}
+
+// setTransferDataStore adds the data store of a transfer to the connection of a data store of a copy module.
+// The transfer fetches the credentials of the given flow from Vault.
+func setTransferDataStore(dataStore *app.DataStore, flow app.DataFlow) error {
+	store := &pb.DataStore{}
+	if err := dataStore.Connection.Into(store); err != nil {
+		return err
+	}
+	var vaultCredentials *app.Vault
+	if credentials, ok := dataStore.Vault[string(flow)]; ok {
+		vaultCredentials = &credentials
+	}
+	transfer, err := motion.DataStoreFromCatalog(store, dataStore.Format, vaultCredentials)
+	if err != nil {
+		return err
+	}
+	dataStore.Connection = *serde.NewArbitrary(copyConnection{DataStore: store, Transfer: transfer})
+	return nil
+}
@@ -5,6 +5,9 @@ package modules
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"fybrik.io/fybrik/pkg/serde"
 
@@ -44,6 +47,8 @@ type ModuleInstanceSpec struct {
 	Args        *app.ModuleArguments
 	AssetID     string
 	ClusterName string
+	// Scope determines which instances are unified into a single one
+	Scope app.CapabilityScope
 }
 
 // Selector is responsible for finding an appropriate module
@@ -58,6 +63,65 @@ type Selector struct {
 	Actions []*pb.EnforcementAction
 	// Geography where the module will be orchestrated
 	Geo string
+	// Ingest indicates that external data is copied into a governed store, thus the module runs close to the destination
+	Ingest bool
+	// OptionalActions are actions that are preferably performed by the module, but may be done by another module
+	// They are used for choosing between several modules fulfilling the requirements
+	OptionalActions []*pb.EnforcementAction
+	// Candidates record why each evaluated module has been chosen or rejected
+	Candidates []app.ModuleSelectionDetails
+	// Affinity describes the preferred placement of the module
+	Affinity Affinity
+	// Clusters that may run the module. Modules whose connector and feature dependencies are not provided by any of
+	// them are rejected, so that the next eligible module is chosen. The dependencies are not checked if empty.
+	Clusters []multicluster.Cluster
+}
+
+// Affinity describes where a module should preferably run
+type Affinity struct {
+	// Cluster is the preferred cluster, e.g. the workload cluster or the cluster of a co-located module
+	Cluster string
+	// Zone is the preferred zone within the geography
+	Zone string
+	// FallbackGeos are geographies, in order of preference, that may be used if no cluster in the module geography can run the module
+	FallbackGeos []string
+}
+
+// moduleScore is used to compare modules that fulfill the requirements
+type moduleScore struct {
+	// priority declared by the module
+	priority int32
+	// number of module instances, including the dependencies and an implicit copy if required
+	instances int
+	// sum of the weights of the module instances
+	cost int32
+	// number of optional actions that the module does not enforce natively
+	unsupportedActions int
+}
+
+// betterThan returns true if the score is preferred over the given one
+// A higher priority is preferred, then the lowest cost of the module instances, then the most actions enforced natively
+func (s moduleScore) betterThan(other moduleScore) bool {
+	if s.priority != other.priority {
+		return s.priority > other.priority
+	}
+	if s.cost != other.cost {
+		return s.cost < other.cost
+	}
+	return s.unsupportedActions < other.unsupportedActions
+}
+
+func (s moduleScore) String() string {
+	return fmt.Sprintf("priority %d, %d module instance(s) with cost %d, %d action(s) not enforced natively",
+		s.priority, s.instances, s.cost, s.unsupportedActions)
+}
+
+// moduleWeight returns the cost of an instance of the given module
+func moduleWeight(module *app.FybrikModule) int32 {
+	if module.Spec.Weight <= 0 {
+		return 1
+	}
+	return module.Spec.Weight
 }
 
 // TODO: Add function to check if module supports recurrence type
@@ -88,6 +152,7 @@ func (m *Selector) AddModuleInstances(args *app.ModuleArguments, item DataInfo,
 		Module:      m.GetModule(),
 		Args:        args,
 		ClusterName: cluster,
+		Scope:       utils.GetCapabilityScope(m.GetModule(), m.Capability),
 	})
 	for _, dep := range m.GetDependencies() {
 		instances = append(instances, ModuleInstanceSpec{
@@ -95,6 +160,7 @@ func (m *Selector) AddModuleInstances(args *app.ModuleArguments, item DataInfo,
 			Module:      dep,
 			Args:        args,
 			ClusterName: cluster,
+			Scope:       utils.GetCapabilityScope(dep, m.Capability),
 		})
 	}
 	return instances
@@ -129,23 +195,14 @@ func (m *Selector) SupportsGovernanceAction(module *app.FybrikModule, action *pb
 	return false // Action not supported by module
 }
 
-// SupportsDependencies checks whether the module supports the dependency requirements
-func (m *Selector) SupportsDependencies(module *app.FybrikModule, moduleMap map[string]*app.FybrikModule) bool {
-	// check dependencies
-	subModuleNames, errNames := CheckDependencies(module, moduleMap)
-	if len(errNames) > 0 {
-		m.Message += module.Name + " has missing dependencies: "
-		for _, name := range errNames {
-			m.Message += "\n" + name
+// supportsAnyGovernanceAction checks whether the module supports at least one of the given governance actions
+func (m *Selector) supportsAnyGovernanceAction(module *app.FybrikModule, actions []*pb.EnforcementAction) bool {
+	for _, action := range actions {
+		if m.SupportsGovernanceAction(module, action) {
+			return true
 		}
-		m.Message += "\n"
-		return false
-	}
-	m.Module = module.DeepCopy()
-	for _, name := range subModuleNames {
-		m.Dependencies = append(m.Dependencies, moduleMap[name])
 	}
-	return true
+	return false
 }
 
 // SupportsInterface indicates whether the module supports interface requirements and dependencies
@@ -158,11 +215,24 @@ func (m *Selector) SupportsInterface(module *app.FybrikModule) bool {
 		for _, cap := range caps {
 			// Check if the source and sink protocols requested are supported
 
-			if m.Capability == app.Read {
+			if m.Capability == app.Read || m.Capability == app.Write {
+				if cap.API == nil {
+					continue
+				}
 				supportsInterface = cap.API.DataFormat == m.Destination.DataFormat && cap.API.Protocol == m.Destination.Protocol
 				if supportsInterface {
 					return true
 				}
+			} else if m.Capability == app.Transform {
+				// a transform module consumes and serves the same interface
+				if cap.API == nil || cap.API.DataFormat != m.Destination.DataFormat || cap.API.Protocol != m.Destination.Protocol {
+					continue
+				}
+				for _, inter := range cap.SupportedInterfaces {
+					if inter.Source != nil && inter.Source.DataFormat == m.Source.DataFormat && inter.Source.Protocol == m.Source.Protocol {
+						return true
+					}
+				}
 			} else if m.Capability == app.Copy {
 				for _, inter := range cap.SupportedInterfaces {
 					if inter.Source.DataFormat != m.Source.DataFormat || inter.Source.Protocol != m.Source.Protocol {
@@ -181,25 +251,188 @@ func (m *Selector) SupportsInterface(module *app.FybrikModule) bool {
 }
 
 // SelectModule finds the module that fits the requirements
+// Modules are evaluated in a deterministic order, and the module with the best score is chosen.
+// The reason for choosing or rejecting each module is recorded in the selector candidates.
 func (m *Selector) SelectModule(moduleMap map[string]*app.FybrikModule) bool {
 	m.Message = ""
-	for _, module := range moduleMap {
+	m.Module = nil
+	m.Dependencies = []*app.FybrikModule{}
+	m.Candidates = []app.ModuleSelectionDetails{}
+	names := make([]string, 0, len(moduleMap))
+	for name := range moduleMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var bestScore moduleScore
+	var bestDependencies []string
+	for _, name := range names {
+		module := moduleMap[name]
 		if !m.SupportsInterface(module) {
+			m.reject(module, "the requested interface is not supported")
 			continue
 		}
 		if !m.SupportsGovernanceActions(module, m.Actions) {
+			m.reject(module, "the required governance actions are not supported")
+			continue
+		}
+		if m.Capability == app.Transform && !m.supportsAnyGovernanceAction(module, m.OptionalActions) {
+			m.reject(module, "none of the governance actions are supported")
+			continue
+		}
+		subModuleNames, errNames := CheckDependencies(module, moduleMap)
+		if len(errNames) > 0 {
+			m.Message += module.Name + " has missing dependencies: "
+			for _, depName := range errNames {
+				m.Message += "\n" + depName
+			}
+			m.Message += "\n"
+			m.reject(module, "missing dependencies: "+strings.Join(errNames, ", "))
 			continue
 		}
-		if !m.SupportsDependencies(module, moduleMap) {
+		if !m.runsInAnyCluster(module, subModuleNames, moduleMap) {
+			m.Message += module.Name + " : " + app.UnsupportedDependencies + "\n"
+			m.reject(module, "no cluster provides the connectors and features it depends on")
 			continue
 		}
+		score := m.score(module, subModuleNames, moduleMap)
+		m.Candidates = append(m.Candidates, app.ModuleSelectionDetails{
+			Capability: m.Capability,
+			Module:     module.Name,
+			Reason:     score.String(),
+		})
+		if m.Module == nil || score.betterThan(bestScore) {
+			m.Module = module
+			bestScore = score
+			bestDependencies = subModuleNames
+		}
+	}
+	if m.Module == nil {
+		m.Message += string(m.Capability) + " : " + app.ModuleNotFound
+		return false
+	}
+	for i := range m.Candidates {
+		if m.Candidates[i].Module == m.Module.Name {
+			m.Candidates[i].Selected = true
+			m.Candidates[i].Reason = "chosen: " + m.Candidates[i].Reason
+		} else if !strings.HasPrefix(m.Candidates[i].Reason, rejectedPrefix) {
+			m.Candidates[i].Reason = rejectedPrefix + "a better module was found: " + m.Candidates[i].Reason
+		}
+	}
+	m.Module = m.Module.DeepCopy()
+	for _, name := range bestDependencies {
+		m.Dependencies = append(m.Dependencies, moduleMap[name])
+	}
+	return true
+}
+
+const rejectedPrefix = "rejected: "
+
+// runsInAnyCluster checks whether one of the selector clusters provides the connector and feature dependencies of the
+// module and of the modules it depends on
+func (m *Selector) runsInAnyCluster(module *app.FybrikModule, dependencies []string, moduleMap map[string]*app.FybrikModule) bool {
+	if len(m.Clusters) == 0 {
 		return true
 	}
-	m.Message += string(m.Capability) + " : " + app.ModuleNotFound
+	required := []*app.FybrikModule{module}
+	for _, name := range dependencies {
+		required = append(required, moduleMap[name])
+	}
+	for _, cluster := range m.Clusters {
+		if len(unsupportedClusterDependencies(cluster, required)) == 0 {
+			return true
+		}
+	}
 	return false
 }
 
+// reject records the reason for rejecting a module
+func (m *Selector) reject(module *app.FybrikModule, reason string) {
+	m.Candidates = append(m.Candidates, app.ModuleSelectionDetails{
+		Capability: m.Capability,
+		Module:     module.Name,
+		Reason:     rejectedPrefix + reason,
+	})
+}
+
+// score computes the module score used for choosing between modules that fulfill the requirements
+func (m *Selector) score(module *app.FybrikModule, dependencies []string, moduleMap map[string]*app.FybrikModule) moduleScore {
+	score := moduleScore{
+		priority:  module.Spec.Priority,
+		instances: 1 + len(dependencies),
+		cost:      moduleWeight(module),
+	}
+	for _, name := range dependencies {
+		score.cost += moduleWeight(moduleMap[name])
+	}
+	// the copy module is not known yet, its instance has the default weight
+	if m.requiresCopy(module) {
+		score.instances++
+		score.cost++
+	}
+	for _, action := range m.OptionalActions {
+		if !m.SupportsGovernanceAction(module, action) {
+			score.unsupportedActions++
+		}
+	}
+	return score
+}
+
+// requiresCopy checks whether an implicit copy is required for the read module to access the data source
+func (m *Selector) requiresCopy(module *app.FybrikModule) bool {
+	if m.Capability != app.Read || m.Source == nil {
+		return false
+	}
+	_, caps := utils.GetModuleCapabilities(module, app.Read)
+	for _, cap := range caps {
+		for _, inter := range cap.SupportedInterfaces {
+			if inter.Source != nil && inter.Source.Protocol == m.Source.Protocol && inter.Source.DataFormat == m.Source.DataFormat {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SelectTransformModules builds a chain of transform modules that together perform the given actions.
+// Each module in the chain consumes and serves the given interface.
+// At each step the best scoring module among those performing some of the remaining actions is chosen.
+// The selectors are returned in the order of the chain, each with the actions it performs.
+// If the actions can not be performed, the last returned selector records why the modules have been rejected.
+func SelectTransformModules(moduleMap map[string]*app.FybrikModule, iface *app.InterfaceDetails, actions []*pb.EnforcementAction, geo string,
+	clusters []multicluster.Cluster) ([]*Selector, error) {
+	chain := []*Selector{}
+	remaining := actions
+	for len(remaining) > 0 {
+		selector := &Selector{
+			Capability:      app.Transform,
+			Source:          iface,
+			Destination:     iface,
+			Actions:         []*pb.EnforcementAction{},
+			OptionalActions: remaining,
+			Dependencies:    []*app.FybrikModule{},
+			Geo:             geo,
+			Clusters:        clusters,
+		}
+		if !selector.SelectModule(moduleMap) {
+			return append(chain, selector), errors.New(selector.GetError())
+		}
+		var rest []*pb.EnforcementAction
+		for _, action := range remaining {
+			if selector.SupportsGovernanceAction(selector.GetModule(), action) {
+				selector.Actions = append(selector.Actions, action)
+			} else {
+				rest = append(rest, action)
+			}
+		}
+		chain = append(chain, selector)
+		remaining = rest
+	}
+	return chain, nil
+}
+
 // CheckDependencies returns dependent module names
+// Connector and feature dependencies are provided by the clusters and are checked against the selector clusters
 func CheckDependencies(module *app.FybrikModule, moduleMap map[string]*app.FybrikModule) ([]string, []string) {
 	var found []string
 	var missing []string
@@ -220,24 +453,86 @@ func CheckDependencies(module *app.FybrikModule, moduleMap map[string]*app.Fybri
 	return found, missing
 }
 
-// SelectCluster chooses where the module runs
+// GetGeo returns the geography in which the module should run
 // Current logic:
 // Read is done at target (processing geography)
 // Copy is done at source when transformations are required, and at target - otherwise
-// Write is done at target
+// Copy of external data (ingest) is done at target
+// Write is done at target (processing geography)
+// Transform is done together with read (processing geography)
+func (m *Selector) GetGeo(item DataInfo) string {
+	if m.Capability == app.Read || m.Capability == app.Write || m.Capability == app.Transform {
+		return m.Geo
+	}
+	if m.Capability == app.Copy && (len(m.Actions) == 0 || m.Ingest) {
+		return m.Geo
+	}
+	return item.DataDetails.Geography
+}
+
+// SelectCluster chooses where the module runs
+// Only clusters in the module geography that provide the connectors and features the module depends on are considered.
+// Among those, the preferred cluster is chosen first, then a cluster in the preferred zone.
+// If no cluster in the module geography can run the module, the fallback geographies are tried in order.
 func (m *Selector) SelectCluster(item DataInfo, clusters []multicluster.Cluster) (string, error) {
-	geo := item.DataDetails.Geography
-	if m.Capability == app.Read {
-		geo = m.Geo
-	} else if m.Capability == app.Copy && len(m.Actions) == 0 {
-		geo = m.Geo
-	}
-	for _, cluster := range clusters {
-		if cluster.Metadata.Region == geo {
-			return cluster.Name, nil
+	geos := append([]string{m.GetGeo(item)}, m.Affinity.FallbackGeos...)
+	var unmet []string
+	for _, geo := range geos {
+		var candidates []multicluster.Cluster
+		for _, cluster := range clusters {
+			if cluster.Metadata.Region != geo {
+				continue
+			}
+			if missing := unsupportedClusterDependencies(cluster, append([]*app.FybrikModule{m.Module}, m.Dependencies...)); len(missing) > 0 {
+				unmet = append(unmet, cluster.Name+" is missing "+strings.Join(missing, ", "))
+				continue
+			}
+			candidates = append(candidates, cluster)
+		}
+		if len(candidates) > 0 {
+			return m.Affinity.choose(candidates), nil
+		}
+	}
+	geoList := strings.Join(geos, ", ")
+	if len(unmet) > 0 {
+		return "", errors.New(app.UnsupportedDependencies + "\n" + m.Module.Name + " can not run in " + geoList + ": " + strings.Join(unmet, "; "))
+	}
+	return "", errors.New(app.InvalidClusterConfiguration + "\nNo clusters have been found for running " + m.Module.Name + " in " + geoList)
+}
+
+// choose returns the preferred cluster if it is a candidate, otherwise the first candidate in the preferred zone,
+// and the first candidate if none of them is in the preferred zone
+func (a *Affinity) choose(candidates []multicluster.Cluster) string {
+	if a.Cluster != "" {
+		for _, cluster := range candidates {
+			if cluster.Name == a.Cluster {
+				return cluster.Name
+			}
+		}
+	}
+	if a.Zone != "" {
+		for _, cluster := range candidates {
+			if cluster.Metadata.Zone == a.Zone {
+				return cluster.Name
+			}
+		}
+	}
+	return candidates[0].Name
+}
+
+// unsupportedClusterDependencies returns the connector and feature dependencies of the given modules that are not
+// available in the given cluster
+func unsupportedClusterDependencies(cluster multicluster.Cluster, required []*app.FybrikModule) []string {
+	var missing []string
+	for _, module := range required {
+		for _, dependency := range module.Spec.Dependencies {
+			if dependency.Type == app.Module || cluster.SupportsDependency(dependency) {
+				continue
+			}
+			missing = append(missing, string(dependency.Type)+" "+dependency.Name)
 		}
 	}
-	return "", errors.New(app.InvalidClusterConfiguration + "\nNo clusters have been found for running " + m.Module.Name + " in " + geo)
+	return missing
 }
 
 // Transforms a CatalogDatasetInfo into a DataDetails struct
@@ -0,0 +1,287 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package modules
+
+import (
+	"testing"
+
+	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	"fybrik.io/fybrik/pkg/multicluster"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func readModule(name string, source app.InterfaceDetails, actions []app.SupportedAction) *app.FybrikModule {
+	return &app.FybrikModule{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fybrik-system"},
+		Spec: app.FybrikModuleSpec{
+			Capabilities: []app.ModuleCapability{
+				{
+					Capability: app.Read,
+					API: &app.ModuleAPI{
+						InterfaceDetails: app.InterfaceDetails{Protocol: app.ArrowFlight, DataFormat: app.Arrow},
+						Endpoint:         app.EndpointSpec{Port: 80, Scheme: "grpc"},
+					},
+					SupportedInterfaces: []app.ModuleInOut{{Source: &source}},
+					Actions:             actions,
+				},
+			},
+		},
+	}
+}
+
+func readSelector() *Selector {
+	return &Selector{
+		Capability:  app.Read,
+		Destination: &app.InterfaceDetails{Protocol: app.ArrowFlight, DataFormat: app.Arrow},
+		Source:      &app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet},
+		Actions:     []*pb.EnforcementAction{},
+		OptionalActions: []*pb.EnforcementAction{
+			{Name: "redact", Id: "redact-ID", Level: pb.EnforcementAction_COLUMN},
+		},
+	}
+}
+
+func TestSelectModulePrefersNoCopy(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	csvSource := app.InterfaceDetails{Protocol: app.S3, DataFormat: "csv"}
+	parquetSource := app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}
+	moduleMap := map[string]*app.FybrikModule{
+		"a-csv-reader":     readModule("a-csv-reader", csvSource, nil),
+		"b-parquet-reader": readModule("b-parquet-reader", parquetSource, nil),
+	}
+	selector := readSelector()
+	g.Expect(selector.SelectModule(moduleMap)).To(gomega.BeTrue())
+	g.Expect(selector.GetModule().Name).To(gomega.Equal("b-parquet-reader"))
+	g.Expect(selector.Candidates).To(gomega.HaveLen(2))
+	for _, candidate := range selector.Candidates {
+		g.Expect(candidate.Selected).To(gomega.Equal(candidate.Module == "b-parquet-reader"))
+		g.Expect(candidate.Reason).NotTo(gomega.BeEmpty())
+	}
+}
+
+func TestSelectModulePrefersNativeActions(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	source := app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}
+	redact := []app.SupportedAction{{ID: "redact-ID", Level: pb.EnforcementAction_COLUMN}}
+	moduleMap := map[string]*app.FybrikModule{
+		"a-reader":           readModule("a-reader", source, nil),
+		"b-redacting-reader": readModule("b-redacting-reader", source, redact),
+	}
+	// the selection is deterministic
+	for i := 0; i < 10; i++ {
+		selector := readSelector()
+		g.Expect(selector.SelectModule(moduleMap)).To(gomega.BeTrue())
+		g.Expect(selector.GetModule().Name).To(gomega.Equal("b-redacting-reader"))
+	}
+}
+
+func TestSelectModulePriority(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	csvSource := app.InterfaceDetails{Protocol: app.S3, DataFormat: "csv"}
+	parquetSource := app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}
+	preferred := readModule("a-csv-reader", csvSource, nil)
+	preferred.Spec.Priority = 1
+	moduleMap := map[string]*app.FybrikModule{
+		"a-csv-reader":     preferred,
+		"b-parquet-reader": readModule("b-parquet-reader", parquetSource, nil),
+	}
+	selector := readSelector()
+	g.Expect(selector.SelectModule(moduleMap)).To(gomega.BeTrue())
+	g.Expect(selector.GetModule().Name).To(gomega.Equal("a-csv-reader"))
+}
+
+func TestSelectModuleWeight(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	csvSource := app.InterfaceDetails{Protocol: app.S3, DataFormat: "csv"}
+	parquetSource := app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}
+	costly := readModule("a-parquet-reader", parquetSource, nil)
+	costly.Spec.Weight = 3
+	moduleMap := map[string]*app.FybrikModule{
+		"a-parquet-reader": costly,
+		"b-parquet-reader": readModule("b-parquet-reader", parquetSource, nil),
+	}
+	selector := readSelector()
+	g.Expect(selector.SelectModule(moduleMap)).To(gomega.BeTrue())
+	g.Expect(selector.GetModule().Name).To(gomega.Equal("b-parquet-reader"))
+
+	// a module with an implicit copy costs less than a single module with a higher weight
+	moduleMap = map[string]*app.FybrikModule{
+		"a-parquet-reader": costly,
+		"b-csv-reader":     readModule("b-csv-reader", csvSource, nil),
+	}
+	selector = readSelector()
+	g.Expect(selector.SelectModule(moduleMap)).To(gomega.BeTrue())
+	g.Expect(selector.GetModule().Name).To(gomega.Equal("b-csv-reader"))
+}
+
+func TestSelectModuleRejectionReasons(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	source := app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}
+	module := readModule("reader", source, nil)
+	module.Spec.Dependencies = []app.Dependency{{Type: app.Module, Name: "missing"}}
+	moduleMap := map[string]*app.FybrikModule{"reader": module}
+	selector := readSelector()
+	g.Expect(selector.SelectModule(moduleMap)).To(gomega.BeFalse())
+	g.Expect(selector.Candidates).To(gomega.HaveLen(1))
+	g.Expect(selector.Candidates[0].Selected).To(gomega.BeFalse())
+	g.Expect(selector.Candidates[0].Reason).To(gomega.ContainSubstring("missing"))
+}
+
+func transformModule(name string, actions []app.SupportedAction) *app.FybrikModule {
+	arrow := app.InterfaceDetails{Protocol: app.ArrowFlight, DataFormat: app.Arrow}
+	return &app.FybrikModule{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fybrik-system"},
+		Spec: app.FybrikModuleSpec{
+			Capabilities: []app.ModuleCapability{
+				{
+					Capability: app.Transform,
+					API: &app.ModuleAPI{
+						InterfaceDetails: arrow,
+						Endpoint:         app.EndpointSpec{Port: 80, Scheme: "grpc"},
+					},
+					SupportedInterfaces: []app.ModuleInOut{{Source: &arrow}},
+					Actions:             actions,
+				},
+			},
+		},
+	}
+}
+
+func TestSelectTransformModules(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	arrow := &app.InterfaceDetails{Protocol: app.ArrowFlight, DataFormat: app.Arrow}
+	redact := &pb.EnforcementAction{Name: "redact", Id: "redact-ID", Level: pb.EnforcementAction_COLUMN}
+	encrypt := &pb.EnforcementAction{Name: "encrypt", Id: "encrypt-ID", Level: pb.EnforcementAction_COLUMN}
+	moduleMap := map[string]*app.FybrikModule{
+		"a-redact":  transformModule("a-redact", []app.SupportedAction{{ID: "redact-ID", Level: pb.EnforcementAction_COLUMN}}),
+		"b-encrypt": transformModule("b-encrypt", []app.SupportedAction{{ID: "encrypt-ID", Level: pb.EnforcementAction_COLUMN}}),
+		"c-noop":    transformModule("c-noop", nil),
+	}
+	chain, err := SelectTransformModules(moduleMap, arrow, []*pb.EnforcementAction{redact, encrypt}, "theshire", nil)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(chain).To(gomega.HaveLen(2))
+	g.Expect(chain[0].GetModule().Name).To(gomega.Equal("a-redact"))
+	g.Expect(chain[0].Actions).To(gomega.ConsistOf(redact))
+	g.Expect(chain[1].GetModule().Name).To(gomega.Equal("b-encrypt"))
+	g.Expect(chain[1].Actions).To(gomega.ConsistOf(encrypt))
+
+	// an action that no transform module supports
+	mask := &pb.EnforcementAction{Name: "mask", Id: "mask-ID", Level: pb.EnforcementAction_COLUMN}
+	chain, err = SelectTransformModules(moduleMap, arrow, []*pb.EnforcementAction{redact, mask}, "theshire", nil)
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(chain).To(gomega.HaveLen(2))
+	g.Expect(chain[1].Candidates).To(gomega.HaveLen(3))
+}
+
+func TestSelectClusterDependencies(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	source := app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}
+	module := readModule("kafka-reader", source, nil)
+	module.Spec.Dependencies = []app.Dependency{
+		{Type: app.Connector, Name: "kafka"},
+		{Type: app.Feature, Name: "streaming"},
+	}
+	selector := readSelector()
+	selector.Geo = "theshire"
+	g.Expect(selector.SelectModule(map[string]*app.FybrikModule{"kafka-reader": module})).To(gomega.BeTrue())
+	item := DataInfo{DataDetails: &DataDetails{Geography: "theshire"}}
+
+	clusters := []multicluster.Cluster{
+		{Name: "no-kafka", Metadata: multicluster.ClusterMetadata{Region: "theshire", Features: []string{"streaming"}}},
+		{Name: "kafka", Metadata: multicluster.ClusterMetadata{Region: "theshire", Connectors: []string{"kafka"}, Features: []string{"streaming"}}},
+	}
+	cluster, err := selector.SelectCluster(item, clusters)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cluster).To(gomega.Equal("kafka"))
+
+	_, err = selector.SelectCluster(item, clusters[:1])
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.HavePrefix(app.UnsupportedDependencies))
+	g.Expect(err.Error()).To(gomega.ContainSubstring("connector kafka"))
+	g.Expect(err.Error()).NotTo(gomega.ContainSubstring("feature streaming"))
+}
+
+func TestSelectModuleClusterDependencies(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	source := app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}
+	preferred := readModule("a-kafka-reader", source, nil)
+	preferred.Spec.Priority = 1
+	preferred.Spec.Dependencies = []app.Dependency{{Type: app.Connector, Name: "kafka"}}
+	moduleMap := map[string]*app.FybrikModule{
+		"a-kafka-reader": preferred,
+		"b-reader":       readModule("b-reader", source, nil),
+	}
+	selector := readSelector()
+	selector.Clusters = []multicluster.Cluster{{Name: "no-kafka", Metadata: multicluster.ClusterMetadata{Region: "theshire"}}}
+	g.Expect(selector.SelectModule(moduleMap)).To(gomega.BeTrue())
+	g.Expect(selector.GetModule().Name).To(gomega.Equal("b-reader"))
+	g.Expect(selector.Candidates[0].Module).To(gomega.Equal("a-kafka-reader"))
+	g.Expect(selector.Candidates[0].Reason).To(gomega.HavePrefix(rejectedPrefix))
+
+	selector.Clusters = append(selector.Clusters,
+		multicluster.Cluster{Name: "kafka", Metadata: multicluster.ClusterMetadata{Region: "theshire", Connectors: []string{"kafka"}}})
+	g.Expect(selector.SelectModule(moduleMap)).To(gomega.BeTrue())
+	g.Expect(selector.GetModule().Name).To(gomega.Equal("a-kafka-reader"))
+}
+
+func TestSelectClusterAffinity(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	source := app.InterfaceDetails{Protocol: app.S3, DataFormat: app.Parquet}
+	selector := readSelector()
+	selector.Geo = "theshire"
+	g.Expect(selector.SelectModule(map[string]*app.FybrikModule{"reader": readModule("reader", source, nil)})).To(gomega.BeTrue())
+	item := DataInfo{DataDetails: &DataDetails{Geography: "theshire"}}
+
+	clusters := []multicluster.Cluster{
+		{Name: "bree", Metadata: multicluster.ClusterMetadata{Region: "neverland", Zone: "bree"}},
+		{Name: "bywater", Metadata: multicluster.ClusterMetadata{Region: "theshire", Zone: "bywater"}},
+		{Name: "hobbiton-1", Metadata: multicluster.ClusterMetadata{Region: "theshire", Zone: "hobbiton"}},
+		{Name: "hobbiton-2", Metadata: multicluster.ClusterMetadata{Region: "theshire", Zone: "hobbiton"}},
+	}
+	// the first cluster in the geography is chosen by default
+	cluster, err := selector.SelectCluster(item, clusters)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cluster).To(gomega.Equal("bywater"))
+
+	// a cluster in the preferred zone is chosen
+	selector.Affinity = Affinity{Zone: "hobbiton"}
+	cluster, err = selector.SelectCluster(item, clusters)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cluster).To(gomega.Equal("hobbiton-1"))
+
+	// the preferred cluster is chosen
+	selector.Affinity = Affinity{Cluster: "hobbiton-2", Zone: "hobbiton"}
+	cluster, err = selector.SelectCluster(item, clusters)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cluster).To(gomega.Equal("hobbiton-2"))
+
+	// the fallback geographies are used if no cluster exists in the geography
+	selector.Affinity = Affinity{}
+	_, err = selector.SelectCluster(item, clusters[:1])
+	g.Expect(err).To(gomega.HaveOccurred())
+	selector.Affinity = Affinity{FallbackGeos: []string{"neverland"}}
+	cluster, err = selector.SelectCluster(item, clusters[:1])
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(cluster).To(gomega.Equal("bree"))
+}
@@ -201,7 +201,6 @@ func (r *PlotterReconciler) reconcile(plotter *app.Plotter) (ctrl.Result, []erro
 					Namespace:   BlueprintNamespace,
 					ClusterName: cluster,
 					Labels: map[string]string{
-						"razee/watch-resource":        "debug",
 						app.ApplicationNameLabel:      plotter.Labels[app.ApplicationNameLabel],
 						app.ApplicationNamespaceLabel: plotter.Labels[app.ApplicationNamespaceLabel],
 					},
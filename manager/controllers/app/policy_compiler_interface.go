@@ -4,7 +4,12 @@
 package app
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"log"
+	"sort"
+	"strings"
 
 	"emperror.dev/errors"
 	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
@@ -46,11 +51,81 @@ func LookupPolicyDecisions(datasetID string, policyManager connectors.PolicyMana
 	pcresponse, _ := connectors.ConvertOpenAPIRespToGrpcResp(openapiResp, datasetID, op)
 	log.Println("transformed grpc response: ", pcresponse)
 
-	actions := []*pb.EnforcementAction{}
 	if err != nil {
-		return actions, err
+		return []*pb.EnforcementAction{}, err
 	}
+	return getEnforcementActions(pcresponse, datasetID)
+}
 
+// PolicyDecision holds the governance actions for a dataset, or the reason for which they could not be provided, e.g. access denial
+type PolicyDecision struct {
+	Actions []*pb.EnforcementAction
+	Err     error
+}
+
+// policyDecisionDigest summarizes the governance actions of a decision, or the reason for which they could not be provided.
+// Decisions with the same actions, in any order, have the same digest.
+func policyDecisionDigest(decision PolicyDecision) string {
+	content := "error:"
+	if decision.Err != nil {
+		content += decision.Err.Error()
+	} else {
+		actions := []string{}
+		for _, action := range decision.Actions {
+			raw, _ := json.Marshal(action)
+			actions = append(actions, string(raw))
+		}
+		sort.Strings(actions)
+		content = "actions:" + strings.Join(actions, ",")
+	}
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// LookupPoliciesDecisions provides the governance actions for several datasets and the given operation.
+// If the policy manager supports batching, a single call is made for all the datasets.
+// Otherwise, the datasets are evaluated concurrently, running at most the given number of calls at the same time.
+func LookupPoliciesDecisions(datasetIDs []string, policyManager connectors.PolicyManager, input *app.FybrikApplication,
+	op *pb.AccessOperation, concurrency int) map[string]PolicyDecision {
+	decisions := make(map[string]PolicyDecision)
+	if len(datasetIDs) == 0 {
+		return decisions
+	}
+	if batchManager, ok := policyManager.(connectors.BatchPolicyManager); ok {
+		appContext := ConstructApplicationContext(datasetIDs[0], input, op)
+		appContext.Datasets = []*pb.DatasetContext{}
+		for _, datasetID := range datasetIDs {
+			appContext.Datasets = append(appContext.Datasets, &pb.DatasetContext{
+				Dataset:   &pb.DatasetIdentifier{DatasetId: datasetID},
+				Operation: op,
+			})
+		}
+		pcresponse, err := batchManager.GetPoliciesDecisionsBatch(appContext)
+		for _, datasetID := range datasetIDs {
+			if err != nil {
+				decisions[datasetID] = PolicyDecision{Actions: []*pb.EnforcementAction{}, Err: err}
+				continue
+			}
+			actions, err := getEnforcementActions(pcresponse, datasetID)
+			decisions[datasetID] = PolicyDecision{Actions: actions, Err: err}
+		}
+		return decisions
+	}
+	results := make([]PolicyDecision, len(datasetIDs))
+	utils.RunConcurrently(len(datasetIDs), concurrency, func(i int) {
+		actions, err := LookupPolicyDecisions(datasetIDs[i], policyManager, input, op)
+		results[i] = PolicyDecision{Actions: actions, Err: err}
+	})
+	for i, datasetID := range datasetIDs {
+		decisions[datasetID] = results[i]
+	}
+	return decisions
+}
+
+// getEnforcementActions extracts the governance actions for the given dataset from the policy decisions
+// An error is returned if the operation is denied
+func getEnforcementActions(pcresponse *pb.PoliciesDecisions, datasetID string) ([]*pb.EnforcementAction, error) {
+	actions := []*pb.EnforcementAction{}
 	for _, datasetDecision := range pcresponse.GetDatasetDecisions() {
 		if datasetDecision.GetDataset().GetDatasetId() != datasetID {
 			continue // not our data set
@@ -0,0 +1,44 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/manager/controllers/mockup"
+	connectors "fybrik.io/fybrik/pkg/connectors/clients"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	"github.com/onsi/gomega"
+)
+
+// nonBatchPolicyManager hides the batching capability of the mock policy manager
+type nonBatchPolicyManager struct {
+	connectors.PolicyManager
+}
+
+func TestLookupPoliciesDecisions(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	application := &app.FybrikApplication{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/data-usage.yaml", application)).NotTo(gomega.HaveOccurred())
+	datasetIDs := []string{"s3/allow-dataset", "s3/deny-dataset", "s3/redact-dataset"}
+	op := &pb.AccessOperation{Type: pb.AccessOperation_READ, Destination: "theshire"}
+
+	policyManagers := []connectors.PolicyManager{
+		&mockup.MockPolicyManager{},
+		&nonBatchPolicyManager{PolicyManager: &mockup.MockPolicyManager{}},
+	}
+	for _, policyManager := range policyManagers {
+		decisions := LookupPoliciesDecisions(datasetIDs, policyManager, application, op, 2)
+		g.Expect(decisions).To(gomega.HaveLen(3))
+		g.Expect(decisions["s3/allow-dataset"].Err).NotTo(gomega.HaveOccurred())
+		g.Expect(decisions["s3/allow-dataset"].Actions).To(gomega.BeEmpty())
+		g.Expect(decisions["s3/deny-dataset"].Err).To(gomega.MatchError(app.ReadAccessDenied))
+		g.Expect(decisions["s3/redact-dataset"].Err).NotTo(gomega.HaveOccurred())
+		g.Expect(decisions["s3/redact-dataset"].Actions).To(gomega.HaveLen(1))
+		g.Expect(decisions["s3/redact-dataset"].Actions[0].Name).To(gomega.Equal("redact"))
+	}
+}
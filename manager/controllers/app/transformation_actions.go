@@ -0,0 +1,66 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	motion "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	"fybrik.io/fybrik/pkg/transformations"
+)
+
+// transformationFromAction maps an enforcement action as returned by a policy manager to a transformation of a
+// transfer using the plugin that is registered for the ID of the action.
+// The argument of the action that is named by the plugin holds a comma separated list of columns, all other
+// arguments become options of the transformation. An error is returned if no plugin supports the action or
+// if its arguments do not conform to the schema of the plugin.
+func transformationFromAction(action *pb.EnforcementAction) (*motion.Transformation, error) {
+	if action == nil {
+		return nil, fmt.Errorf("no enforcement action specified")
+	}
+	plugin := transformations.DefaultRegistry.Get(action.Id)
+	if plugin == nil {
+		return nil, fmt.Errorf("no transformation plugin supports the action %s (%s)", action.Name, action.Id)
+	}
+
+	transformation := &motion.Transformation{Name: action.Name}
+	if transformation.Name == "" {
+		transformation.Name = action.Id
+	}
+	if plugin.IsBuiltin() {
+		transformation.Action = motion.Action(plugin.Action)
+	} else {
+		transformation.Plugin = plugin.ID
+	}
+
+	options := map[string]string{}
+	for key, value := range plugin.Defaults {
+		options[key] = value
+	}
+	for key, value := range action.Args {
+		if plugin.ColumnsArgument != "" && key == plugin.ColumnsArgument {
+			for _, column := range strings.Split(value, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					transformation.Columns = append(transformation.Columns, column)
+				}
+			}
+			continue
+		}
+		options[key] = value
+	}
+	if len(options) > 0 {
+		transformation.Options = options
+	}
+
+	violations, err := transformations.DefaultRegistry.ValidateOptions(plugin.ID, transformation.Options)
+	if err != nil {
+		return nil, err
+	}
+	if len(violations) > 0 {
+		return nil, fmt.Errorf("invalid arguments of the action %s: %s", action.Name, strings.Join(violations, "; "))
+	}
+	return transformation, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"testing"
+
+	motion "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	"github.com/onsi/gomega"
+)
+
+func TestTransformationFromAction(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	redact, err := transformationFromAction(&pb.EnforcementAction{Name: "redact column", Id: "redact-ID",
+		Args: map[string]string{"column_name": "name, address"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(redact.Action).To(gomega.Equal(motion.RedactColumns))
+	g.Expect(redact.Columns).To(gomega.Equal([]string{"name", "address"}))
+	g.Expect(redact.Options).To(gomega.Equal(map[string]string{"redactValue": "XXXXXX"}))
+
+	remove, err := transformationFromAction(&pb.EnforcementAction{Name: "remove column", Id: "removed-ID",
+		Args: map[string]string{"column_name": "ssn"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(remove.Action).To(gomega.Equal(motion.RemoveColumns))
+	g.Expect(remove.Columns).To(gomega.Equal([]string{"ssn"}))
+	g.Expect(remove.Options).To(gomega.BeNil())
+
+	sample, err := transformationFromAction(&pb.EnforcementAction{Name: "sample", Id: "SampleRows",
+		Args: map[string]string{"fraction": "0.1"}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(sample.Action).To(gomega.Equal(motion.SampleRows))
+	g.Expect(sample.Options).To(gomega.HaveKeyWithValue("fraction", "0.1"))
+
+	_, err = transformationFromAction(&pb.EnforcementAction{Name: "unsupported", Id: "unknown-ID"})
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestCopyActionsToArbitrary(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	actions, err := copyActionsToArbitrary([]*pb.EnforcementAction{{Name: "redact column", Id: "redact-ID",
+		Args: map[string]string{"column_name": "name"}}})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(actions).To(gomega.HaveLen(1))
+	action := copyAction{}
+	g.Expect(actions[0].Into(&action)).To(gomega.Succeed())
+	g.Expect(action.Id).To(gomega.Equal("redact-ID"))
+	g.Expect(action.Transformation).ToNot(gomega.BeNil())
+	g.Expect(action.Transformation.Action).To(gomega.Equal(motion.RedactColumns))
+
+	// the copy fails rather than moving the data without enforcing an unsupported action
+	_, err = copyActionsToArbitrary([]*pb.EnforcementAction{
+		{Name: "redact column", Id: "redact-ID", Args: map[string]string{"column_name": "name"}},
+		{Name: "encrypt", Id: "encrypted-ID"},
+	})
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(err.Error()).To(gomega.ContainSubstring("encrypted-ID"))
+}
@@ -0,0 +1,225 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"emperror.dev/errors"
+	api "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/manager/controllers/utils"
+	"fybrik.io/fybrik/pkg/multicluster"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultVaultPolicyTTL is the TTL of the Vault tokens that modules obtain with the role of their application
+	DefaultVaultPolicyTTL = "24h"
+	// defaultVaultAuthPath is the Vault auth method of clusters whose metadata does not specify one
+	defaultVaultAuthPath = "kubernetes"
+)
+
+// VaultPolicyName returns the name of the Vault policy and of the Vault role that give the modules of an application
+// access to the credentials of its datasets
+func VaultPolicyName(owner types.NamespacedName) string {
+	return utils.K8sConformName("fybrik-" + owner.Namespace + "-" + owner.Name)
+}
+
+// vaultPolicyPath returns the path that a policy has to grant for a secret path that is handed to a module, together
+// with the namespace of the secret. Secret paths are API paths such as /v1/kubernetes-secrets/my-secret?namespace=default,
+// possibly prefixed with the Vault address, whereas policies refer to the path without the API version and restrict the
+// namespace with a parameter constraint.
+func vaultPolicyPath(secretPath string) (string, string) {
+	path := secretPath
+	namespace := ""
+	if u, err := url.Parse(secretPath); err == nil {
+		path = u.Path
+		namespace = u.Query().Get("namespace")
+	}
+	path = strings.TrimPrefix(path, "/")
+	return strings.TrimPrefix(path, "v1/"), namespace
+}
+
+// GenerateVaultPolicy returns a policy that grants read access to exactly the given secret paths.
+// Secrets of the same name in several namespaces share a path whose namespace parameter is restricted to these namespaces.
+func GenerateVaultPolicy(secretPaths []string) string {
+	// path -> namespaces of the secrets
+	paths := make(map[string]map[string]bool)
+	for _, secretPath := range secretPaths {
+		path, namespace := vaultPolicyPath(secretPath)
+		if path == "" {
+			continue
+		}
+		if paths[path] == nil {
+			paths[path] = make(map[string]bool)
+		}
+		if namespace != "" {
+			paths[path][namespace] = true
+		}
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	var policy strings.Builder
+	for _, path := range sorted {
+		fmt.Fprintf(&policy, "path %q {\n  capabilities = [\"read\"]\n", path)
+		if len(paths[path]) > 0 {
+			namespaces := make([]string, 0, len(paths[path]))
+			for namespace := range paths[path] {
+				namespaces = append(namespaces, strconv.Quote(namespace))
+			}
+			sort.Strings(namespaces)
+			fmt.Fprintf(&policy, "  allowed_parameters = {\n    \"namespace\" = [%s]\n  }\n", strings.Join(namespaces, ", "))
+		}
+		policy.WriteString("}\n")
+	}
+	return policy.String()
+}
+
+// scopeDataStoreCredentials sets the role of the credentials of a data store and returns their secret paths
+func scopeDataStoreCredentials(dataStore *api.DataStore, role string) []string {
+	var secretPaths []string
+	for flow, credentials := range dataStore.Vault {
+		if credentials.SecretPath == "" {
+			continue
+		}
+		credentials.Role = role
+		dataStore.Vault[flow] = credentials
+		secretPaths = append(secretPaths, credentials.SecretPath)
+	}
+	for _, reference := range dataStore.Credentials {
+		if reference.Vault != nil && reference.Vault.SecretPath != "" {
+			reference.Vault.Role = role
+		}
+	}
+	return secretPaths
+}
+
+// scopeModuleCredentials sets the role of all the credentials that are handed to a module and returns their secret paths
+func scopeModuleCredentials(module *api.BlueprintModule, role string) []string {
+	var secretPaths []string
+	for _, dataStore := range moduleDataStores(&module.Arguments) {
+		secretPaths = append(secretPaths, scopeDataStoreCredentials(dataStore, role)...)
+	}
+	return secretPaths
+}
+
+// vaultAuthPath returns the Vault auth method with which the modules of the given cluster authenticate
+func vaultAuthPath(clusterName string, clusters []multicluster.Cluster) string {
+	for _, cluster := range clusters {
+		if cluster.Name == clusterName && cluster.Metadata.VaultAuthPath != "" {
+			return cluster.Metadata.VaultAuthPath
+		}
+	}
+	return defaultVaultAuthPath
+}
+
+// vaultAuthPaths returns the Vault auth methods with which the modules of all the given clusters authenticate
+func vaultAuthPaths(clusters []multicluster.Cluster) map[string]bool {
+	authPaths := map[string]bool{defaultVaultAuthPath: true}
+	for _, cluster := range clusters {
+		authPaths[vaultAuthPath(cluster.Name, clusters)] = true
+	}
+	return authPaths
+}
+
+// reconcileVaultPolicy restricts the modules of an application to the credentials of its datasets.
+// A policy that grants read access to exactly the secret paths handed to the modules is written to Vault and
+// linked to a role of the application, which is bound to the service accounts of the module releases.
+// Modules that are shared by all the workloads of a cluster keep the global modules role.
+// The role is removed from the auth methods of clusters that no longer run modules with credentials, and the policy
+// is removed altogether once no module is handed credentials.
+func (r *FybrikApplicationReconciler) reconcileVaultPolicy(applicationContext *api.FybrikApplication,
+	blueprints map[string]api.BlueprintSpec, clusters []multicluster.Cluster) error {
+	if r.VaultConnection == nil {
+		return nil
+	}
+	name := VaultPolicyName(client.ObjectKeyFromObject(applicationContext))
+	var secretPaths []string
+	// auth path -> service accounts of the modules
+	serviceAccounts := make(map[string][]string)
+	for clusterName, spec := range blueprints {
+		authPath := vaultAuthPath(clusterName, clusters)
+		for i := range spec.Modules {
+			module := &spec.Modules[i]
+			if module.Scope == api.Cluster {
+				continue
+			}
+			paths := scopeModuleCredentials(module, name)
+			if len(paths) == 0 {
+				continue
+			}
+			secretPaths = append(secretPaths, paths...)
+			releaseName := utils.GetReleaseName(applicationContext.Name, applicationContext.Namespace, *module)
+			serviceAccounts[authPath] = append(serviceAccounts[authPath], releaseName)
+		}
+	}
+	if len(secretPaths) == 0 {
+		return r.removeVaultPolicy(name, vaultAuthPaths(clusters))
+	}
+
+	if err := r.VaultConnection.WritePolicy(name, GenerateVaultPolicy(secretPaths)); err != nil {
+		return err
+	}
+	ttl := r.VaultPolicyTTL
+	if ttl == "" {
+		ttl = DefaultVaultPolicyTTL
+	}
+	for authPath, accounts := range serviceAccounts {
+		sort.Strings(accounts)
+		if err := r.VaultConnection.LinkPolicyToIdentity("role/"+name, name, BlueprintNamespace,
+			strings.Join(accounts, ","), authPath, ttl); err != nil {
+			return err
+		}
+	}
+	unused := make(map[string]bool)
+	for authPath := range vaultAuthPaths(clusters) {
+		if _, used := serviceAccounts[authPath]; !used {
+			unused[authPath] = true
+		}
+	}
+	return r.unlinkVaultPolicy(name, unused)
+}
+
+// deleteVaultPolicy removes the role and the policy of an application from Vault
+func (r *FybrikApplicationReconciler) deleteVaultPolicy(applicationContext *api.FybrikApplication) error {
+	if r.VaultConnection == nil {
+		return nil
+	}
+	clusters, err := r.ClusterManager.GetClusters()
+	if err != nil {
+		return err
+	}
+	return r.removeVaultPolicy(VaultPolicyName(client.ObjectKeyFromObject(applicationContext)), vaultAuthPaths(clusters))
+}
+
+// removeVaultPolicy removes the role with the given name from the given auth methods and then the policy itself
+func (r *FybrikApplicationReconciler) removeVaultPolicy(name string, authPaths map[string]bool) error {
+	if err := r.unlinkVaultPolicy(name, authPaths); err != nil {
+		return err
+	}
+	return r.VaultConnection.DeletePolicy(name)
+}
+
+// unlinkVaultPolicy removes the role with the given name from the given auth methods
+func (r *FybrikApplicationReconciler) unlinkVaultPolicy(name string, authPaths map[string]bool) error {
+	var errMsgs []string
+	for authPath := range authPaths {
+		if err := r.VaultConnection.RemovePolicyFromIdentity("role/"+name, name, authPath); err != nil {
+			errMsgs = append(errMsgs, err.Error())
+		}
+	}
+	if len(errMsgs) != 0 {
+		return errors.New(strings.Join(errMsgs, ";"))
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package app
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/manager/controllers/utils"
+	"fybrik.io/fybrik/pkg/multicluster"
+	"fybrik.io/fybrik/pkg/vault"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestGenerateVaultPolicy(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	policy := GenerateVaultPolicy([]string{
+		"/v1/kubernetes-secrets/secret-b?namespace=default",
+		"http://vault.fybrik-system:8200/v1/kubernetes-secrets/secret-a?namespace=default",
+		"/v1/kubernetes-secrets/secret-b?namespace=default",
+		"/v1/kubernetes-secrets/secret-b?namespace=fybrik-system",
+	})
+	g.Expect(policy).To(gomega.Equal(
+		"path \"kubernetes-secrets/secret-a\" {\n  capabilities = [\"read\"]\n" +
+			"  allowed_parameters = {\n    \"namespace\" = [\"default\"]\n  }\n}\n" +
+			"path \"kubernetes-secrets/secret-b\" {\n  capabilities = [\"read\"]\n" +
+			"  allowed_parameters = {\n    \"namespace\" = [\"default\", \"fybrik-system\"]\n  }\n}\n"))
+	g.Expect(GenerateVaultPolicy(nil)).To(gomega.BeEmpty())
+}
+
+// This test checks that the modules of an application get a Vault role whose policy covers exactly the credentials
+// of the application, and that the role and the policy are removed together with the application
+func TestVaultPolicyPerApplication(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	application := &app.FybrikApplication{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/fybrikcopyapp-csv.yaml", application)).NotTo(gomega.HaveOccurred())
+	application.SetGeneration(1)
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, []runtime.Object{application}...)
+
+	readModule := &app.FybrikModule{}
+	copyModule := &app.FybrikModule{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/implicit-copy-batch-module-csv.yaml", copyModule)).NotTo(gomega.HaveOccurred())
+	g.Expect(readObjectFromFile("../../testdata/unittests/module-read-csv.yaml", readModule)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.Background(), copyModule)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.Background(), readModule)).NotTo(gomega.HaveOccurred())
+	dummySecret := &corev1.Secret{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/credentials-theshire.yaml", dummySecret)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.Background(), dummySecret)).NotTo(gomega.HaveOccurred())
+	account := &app.FybrikStorageAccount{}
+	g.Expect(readObjectFromFile("../../testdata/unittests/account-theshire.yaml", account)).NotTo(gomega.HaveOccurred())
+	g.Expect(cl.Create(context.Background(), account)).NotTo(gomega.HaveOccurred())
+
+	r := createTestFybrikApplicationController(cl, s)
+	vaultConnection := vault.NewDummyConnection()
+	r.VaultConnection = vaultConnection
+	r.VaultPolicyTTL = "1h"
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "notebook", Namespace: "default"}}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(gomega.BeNil())
+
+	name := VaultPolicyName(req.NamespacedName)
+	policy, exists := vaultConnection.GetPolicy(name)
+	g.Expect(exists).To(gomega.BeTrue())
+	g.Expect(policy).To(gomega.ContainSubstring("path \"kubernetes-secrets/creds-secret-name\""))
+	g.Expect(policy).To(gomega.ContainSubstring("path \"kubernetes-secrets/credentials-theshire\""))
+	g.Expect(strings.Count(policy, "path ")).To(gomega.Equal(2))
+
+	// the role is bound to the service accounts of the copy and the read module
+	identity, exists := vaultConnection.GetIdentity("role/"+name, "kubernetes")
+	g.Expect(exists).To(gomega.BeTrue())
+	g.Expect(identity.PolicyName).To(gomega.Equal(name))
+	g.Expect(identity.BoundedNamespace).To(gomega.Equal(BlueprintNamespace))
+	g.Expect(identity.TTL).To(gomega.Equal("1h"))
+	g.Expect(strings.Split(identity.ServiceAccount, ",")).To(gomega.HaveLen(2))
+
+	// the modules use the role of the application
+	plotter := &app.Plotter{}
+	g.Expect(cl.Get(context.Background(), types.NamespacedName{Namespace: "fybrik-system", Name: "notebook-default"}, plotter)).To(gomega.Succeed())
+	bpSpec := plotter.Spec.Blueprints["thegreendragon"]
+	var dataStores []app.DataStore
+	for _, module := range bpSpec.Modules {
+		if module.Arguments.Copy != nil {
+			dataStores = append(dataStores, module.Arguments.Copy.Source, module.Arguments.Copy.Destination)
+		}
+		for _, read := range module.Arguments.Read {
+			dataStores = append(dataStores, read.Source)
+		}
+	}
+	g.Expect(dataStores).To(gomega.HaveLen(3))
+	for _, dataStore := range dataStores {
+		g.Expect(dataStore.Vault).NotTo(gomega.BeEmpty())
+		for _, credentials := range dataStore.Vault {
+			g.Expect(credentials.Role).To(gomega.Equal(name))
+		}
+	}
+
+	// the role and the policy are removed with the application
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, application)).To(gomega.Succeed())
+	g.Expect(r.deleteExternalResources(application)).To(gomega.Succeed())
+	_, exists = vaultConnection.GetPolicy(name)
+	g.Expect(exists).To(gomega.BeFalse())
+	_, exists = vaultConnection.GetIdentity("role/"+name, "kubernetes")
+	g.Expect(exists).To(gomega.BeFalse())
+}
+
+// This test checks that the role of an application is removed from the auth methods of clusters that no longer run
+// its modules, and that the policy is removed once no module is handed credentials
+func TestVaultPolicyOfRemovedModules(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	application := &app.FybrikApplication{ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "default"}}
+	clusters := []multicluster.Cluster{
+		{Name: "cluster1"},
+		{Name: "cluster2", Metadata: multicluster.ClusterMetadata{VaultAuthPath: "cluster2-auth"}},
+	}
+	moduleOn := func(cluster string) map[string]app.BlueprintSpec {
+		source := app.DataStore{Vault: map[string]app.Vault{"read": {SecretPath: "/v1/kubernetes-secrets/creds?namespace=default"}}}
+		module := app.BlueprintModule{Name: "read", InstanceName: "read-" + cluster,
+			Arguments: app.ModuleArguments{Read: []app.ReadModuleArgs{{Source: source}}}}
+		return map[string]app.BlueprintSpec{cluster: {Modules: []app.BlueprintModule{module}}}
+	}
+	vaultConnection := vault.NewDummyConnection()
+	r := &FybrikApplicationReconciler{VaultConnection: vaultConnection}
+	name := VaultPolicyName(client.ObjectKeyFromObject(application))
+
+	g.Expect(r.reconcileVaultPolicy(application, moduleOn("cluster2"), clusters)).To(gomega.Succeed())
+	_, exists := vaultConnection.GetIdentity("role/"+name, "cluster2-auth")
+	g.Expect(exists).To(gomega.BeTrue())
+
+	// the module moves to a cluster with another auth method
+	g.Expect(r.reconcileVaultPolicy(application, moduleOn("cluster1"), clusters)).To(gomega.Succeed())
+	_, exists = vaultConnection.GetIdentity("role/"+name, "cluster2-auth")
+	g.Expect(exists).To(gomega.BeFalse())
+	_, exists = vaultConnection.GetIdentity("role/"+name, "kubernetes")
+	g.Expect(exists).To(gomega.BeTrue())
+
+	// the module is no longer handed credentials
+	g.Expect(r.reconcileVaultPolicy(application, map[string]app.BlueprintSpec{}, clusters)).To(gomega.Succeed())
+	_, exists = vaultConnection.GetIdentity("role/"+name, "kubernetes")
+	g.Expect(exists).To(gomega.BeFalse())
+	_, exists = vaultConnection.GetPolicy(name)
+	g.Expect(exists).To(gomega.BeFalse())
+}
@@ -3,6 +3,8 @@
 
 package controllers
 
+import "time"
+
 // This is a collection of constants related to the manager and it's configuration
 
 const ApplicationConcurrentReconcilesConfiguration = "APPLICATION_CONCURRENT_RECONCILES"
@@ -12,6 +14,17 @@ const BlueprintConcurrentReconcilesConfiguration = "BLUEPRINT_CONCURRENT_RECONCI
 const BatchTransferConcurrentReconcilesConfiguration = "BATCHTRANSFER_CONCURRENT_RECONCILES"
 const StreamTransferConcurrentReconcilesConfiguration = "STREAMTRANSFER_CONCURRENT_RECONCILES"
 
+const DatasetConcurrencyConfiguration = "DATASET_CONCURRENCY"
+
+const ConnectorsCacheTTLConfiguration = "CONNECTORS_CACHE_TTL"
+
+const ConnectorsTaxonomyValidationConfiguration = "CONNECTORS_TAXONOMY_VALIDATION"
+
+const PolicyReevaluationIntervalConfiguration = "POLICY_REEVALUATION_INTERVAL"
+
+const BatchTransferProgressIntervalConfiguration = "BATCHTRANSFER_PROGRESS_INTERVAL"
+const StreamTransferHealthIntervalConfiguration = "STREAMTRANSFER_HEALTH_INTERVAL"
+
 const KubernetesClientQPSConfiguration = "CLIENT_QPS"
 const KubernetesClientBurstConfiguration = "CLIENT_BURST"
 
@@ -22,5 +35,23 @@ const DefaultBlueprintConcurrentReconciles = 1
 const DefaultBatchTransferConcurrentReconciles = 1
 const DefaultStreamTransferConcurrentReconciles = 1
 
+// Default number of datasets of an application that are looked up concurrently in the catalog and the policy manager
+const DefaultDatasetConcurrency = 10
+
+// Default time to live of cached catalog and policy manager responses; caching is disabled by default
+const DefaultConnectorsCacheTTL = 0
+
+// Directory in which the taxonomy schemas are mounted
+const TaxonomyDirectory = "/tmp/taxonomy"
+
+// Default interval in which the policies of running applications are evaluated again; periodic evaluation is disabled by default
+const DefaultPolicyReevaluationInterval = 0
+
+// Default interval in which the progress reported by active BatchTransfer runs is read
+const DefaultBatchTransferProgressInterval = 30 * time.Second
+
+// Default interval in which the health reported by running streams is checked
+const DefaultStreamTransferHealthInterval = 30 * time.Second
+
 const DefaultKubernetesClientQPS = 5.0  // Default from Kubernetes client: 5
 const DefaultKubernetesClientBurst = 10 // Default from Kubernetes client: 10
@@ -40,6 +40,14 @@ func (d *DataCatalogDummy) GetDatasetInfo(ctx context.Context, in *pb.CatalogDat
 	return nil, errors.New("could not find data details")
 }
 
+func (d *DataCatalogDummy) RegisterDatasetInfo(ctx context.Context, in *pb.RegisterAssetRequest) (*pb.RegisterAssetResponse, error) {
+	log.Printf("MockDataCatalog.RegisterDatasetInfo called with catalog " + in.GetDestinationCatalogId())
+	if in.GetDatasetDetails() == nil {
+		return nil, errors.New("no dataset details have been provided")
+	}
+	return &pb.RegisterAssetResponse{AssetId: in.GetDestinationCatalogId() + "/" + in.GetDatasetDetails().GetName()}, nil
+}
+
 func (d *DataCatalogDummy) Close() error {
 	return nil
 }
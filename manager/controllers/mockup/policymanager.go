@@ -24,6 +24,27 @@ func (m *MockPolicyManager) GetPoliciesDecisions(input *openapiclientmodels.Poli
 	in, _ := connectors.ConvertOpenAPIReqToGrpcReq(input, creds)
 	log.Println("appContext: created from convertOpenApiReqToGrpcReq: ", in)
 
+	result := m.decisions(in)
+
+	policyManagerResp, _ := connectors.ConvertGrpcRespToOpenAPIResp(result)
+
+	res, err := json.MarshalIndent(policyManagerResp, "", "\t")
+	if err != nil {
+		log.Println("error in marshalling policy manager response :", err)
+		return nil, err
+	}
+	log.Println("Marshalled policy manager response:", string(res))
+
+	return policyManagerResp, nil
+}
+
+// GetPoliciesDecisionsBatch implements the BatchPolicyManager interface
+func (m *MockPolicyManager) GetPoliciesDecisionsBatch(in *pb.ApplicationContext) (*pb.PoliciesDecisions, error) {
+	return m.decisions(in), nil
+}
+
+// decisions returns the decisions for each dataset according to its identifier
+func (m *MockPolicyManager) decisions(in *pb.ApplicationContext) *pb.PoliciesDecisions {
 	log.Printf("Received: ")
 	log.Printf("ProcessingGeography: " + in.AppInfo.GetProcessingGeography())
 	log.Printf("Secret: " + in.GetCredentialPath())
@@ -35,7 +56,7 @@ func (m *MockPolicyManager) GetPoliciesDecisions(input *openapiclientmodels.Poli
 	externalComponents = append(externalComponents, &pb.ComponentVersion{Id: "PC1", Version: "1.0", Name: "PolicyCompiler"})
 	var dataSetWithActions []*pb.DatasetDecision
 
-	for ind, element := range in.GetDatasets() {
+	for _, element := range in.GetDatasets() {
 		dataset := element.GetDataset()
 		log.Printf("Sending DataSet: ")
 		log.Printf("   DataSetID: " + dataset.GetDatasetId())
@@ -91,24 +112,13 @@ func (m *MockPolicyManager) GetPoliciesDecisions(input *openapiclientmodels.Poli
 				Level: pb.EnforcementAction_COLUMN,
 				Args:  args})
 		}
-		operationDecisions = append(operationDecisions, &pb.OperationDecision{Operation: in.GetDatasets()[0].GetOperation(), EnforcementActions: enforcementActions})
+		operationDecisions = append(operationDecisions, &pb.OperationDecision{Operation: element.GetOperation(), EnforcementActions: enforcementActions})
 		dataSetWithActions = append(dataSetWithActions, &pb.DatasetDecision{
 			Dataset: &pb.DatasetIdentifier{
-				DatasetId: in.GetDatasets()[ind].GetDataset().GetDatasetId()},
+				DatasetId: dataset.GetDatasetId()},
 			Decisions: operationDecisions})
 	}
 
-	result := &pb.PoliciesDecisions{ComponentVersions: externalComponents,
+	return &pb.PoliciesDecisions{ComponentVersions: externalComponents,
 		DatasetDecisions: dataSetWithActions}
-
-	policyManagerResp, _ := connectors.ConvertGrpcRespToOpenAPIResp(result)
-
-	res, err := json.MarshalIndent(policyManagerResp, "", "\t")
-	if err != nil {
-		log.Println("error in marshalling policy manager response :", err)
-		return nil, err
-	}
-	log.Println("Marshalled policy manager response:", string(res))
-
-	return policyManagerResp, nil
 }
@@ -6,18 +6,24 @@ package motion
 import (
 	"context"
 	"fmt"
+	"time"
+
 	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
 	"fybrik.io/fybrik/manager/controllers"
 	"fybrik.io/fybrik/pkg/environment"
 	"github.com/go-logr/logr"
 	kbatch "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/reference"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 // A reconciler can be used to base reconcilers of Transfers on.
@@ -35,6 +41,8 @@ type Reconciler struct {
 // It is "derived" from the Reconciler object
 type BatchTransferReconciler struct {
 	Reconciler
+	// Interval in which the progress of active runs is read, polling is disabled if not positive
+	ProgressInterval time.Duration
 }
 
 // This is the main entry point of the controller. It reconciles BatchTransfer objects.
@@ -42,7 +50,8 @@ type BatchTransferReconciler struct {
 // Reconciliation happens with the following steps:
 // - Fetch the BatchTransfer object
 // - Check if the object is being deleted and handle a finalizer if needed
-// - Update the status by checking the existing Job/CronJob
+// - Update the status by checking the existing Job/CronJob and the results reported by their runs
+// - Propagate spec changes and watermarks to the configuration secret and CronJob and handle re-run requests
 // - If K8s objects are not yet created create the objects
 func (reconciler *BatchTransferReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := reconciler.Log.WithValues("batchtransfer", req.NamespacedName)
@@ -51,6 +60,8 @@ func (reconciler *BatchTransferReconciler) Reconcile(ctx context.Context, req ct
 	if err := reconciler.Get(ctx, req.NamespacedName, batchTransfer); err != nil {
 		if err.(*kerrors.StatusError).ErrStatus.Code != 404 {
 			log.Error(err, "unable to fetch BatchTransfer")
+		} else {
+			forgetRunMetrics(req.NamespacedName)
 		}
 		// ignore not-found errors since they can't be fixed by an immediate requeue.
 		return ctrl.Result{}, client.IgnoreNotFound(err)
@@ -73,39 +84,46 @@ func (reconciler *BatchTransferReconciler) Reconcile(ctx context.Context, req ct
 	}
 
 	// Reconcile status
+	cronJobExists := false
+	// runs of an incremental scheduled transfer that wait for the current watermark
+	var suspendedRuns []*kbatch.Job
 	if !batchTransfer.IsCronJob() {
-		job := &kbatch.Job{}
-		if err := reconciler.Get(ctx, batchTransfer.ObjectKey(), job); err != nil {
+		backend := reconciler.backend(batchTransfer)
+		workload := backend.newObject()
+		if err := reconciler.Get(ctx, batchTransfer.ObjectKey(), workload); err != nil {
 			if !kerrors.IsNotFound(err) {
-				log.Error(err, "could not fetch Job for batchTransfer %s", "batchTransfer", batchTransfer.ObjectKey())
+				log.Error(err, "could not fetch the workload for batchTransfer", "batchTransfer", batchTransfer.ObjectKey())
 				return ctrl.Result{}, err
 			}
-			// If job was not found just continue...
+			// If the workload was not found just continue...
+		} else if !workload.GetDeletionTimestamp().IsZero() {
+			// The workload of a previous run is being removed, wait until it is gone
+			return ctrl.Result{RequeueAfter: time.Second}, nil
 		} else {
-			_, finishedType := isJobFinished(job)
-			jobRef, err := reference.GetReference(reconciler.Scheme, job)
+			state := backend.observe(ctx, batchTransfer, workload)
+			workloadRef, err := reference.GetReference(reconciler.Scheme, workload)
 			if err != nil {
-				log.Error(err, "unable to make reference to active job", "job", job)
+				log.Error(err, "unable to make reference to active workload", "workload", workload.GetName())
 			}
 
-			// update the list of jobs by state.
-			switch finishedType {
-			case "": // not a finisher, i.e. still on-going.
-				if job.Status.Active == 0 {
-					batchTransfer.Status.Status = motionv1.Starting
-				} else {
-					batchTransfer.Status.Status = motionv1.Running
-				}
-				batchTransfer.Status.Active = jobRef
-			case kbatch.JobFailed:
-				batchTransfer.Status.Status = motionv1.Failed
-				batchTransfer.Status.LastFailed = jobRef
+			// update the list of workloads by state.
+			batchTransfer.Status.Status = state.status
+			var runMetrics *motionv1.TransferMetrics
+			switch state.status {
+			case motionv1.Failed:
+				batchTransfer.Status.LastFailed = workloadRef
 				batchTransfer.Status.Active = nil
-				reconciler.updateBatchErrorMessage(batchTransfer, job.Labels["controller-uid"])
-			case kbatch.JobComplete:
-				batchTransfer.Status.Status = motionv1.Succeeded
-				batchTransfer.Status.LastCompleted = jobRef
+				batchTransfer.Status.Progress = nil
+				if state.message != "" {
+					batchTransfer.Status.Error = failureMessage(state.message)
+				}
+			case motionv1.Succeeded:
+				batchTransfer.Status.LastCompleted = workloadRef
 				batchTransfer.Status.Active = nil
+				runMetrics = reconciler.recordResult(ctx, batchTransfer, workload, state)
+			default: // still on-going.
+				batchTransfer.Status.Active = workloadRef
+				reconciler.recordProgress(ctx, batchTransfer, workload)
 			}
 
 			// update the status of our CRD.
@@ -113,13 +131,30 @@ func (reconciler *BatchTransferReconciler) Reconcile(ctx context.Context, req ct
 				log.Error(err, "unable to update batchTransfer status")
 				return ctrl.Result{}, err
 			}
+			if runMetrics != nil {
+				observeRunMetrics(batchTransfer, runMetrics)
+			}
 		}
 	} else {
-		// TODO Handle CronJob status updates
-		return ctrl.Result{}, nil
+		exists, suspended, err := reconciler.reconcileCronJobStatus(ctx, batchTransfer)
+		if err != nil {
+			log.Error(err, "unable to update the status of the scheduled batchTransfer")
+			return ctrl.Result{}, err
+		}
+		cronJobExists = exists
+		suspendedRuns = suspended
+	}
+
+	// Discard the watermark of an incremental transfer if a full copy is requested
+	if incremental := batchTransfer.Spec.Incremental; incremental != nil && incremental.Reset != batchTransfer.Status.ObservedReset {
+		resetWatermark(batchTransfer)
+		if err := reconciler.Status().Update(ctx, batchTransfer); err != nil {
+			log.Error(err, "unable to update batchTransfer status")
+			return ctrl.Result{}, err
+		}
 	}
 
-	// Make sure that the secret exists
+	// Make sure that the secret exists and contains the current configuration
 	existingSecret := &corev1.Secret{}
 	if err := reconciler.Get(ctx, batchTransfer.ObjectKey(), existingSecret); err != nil {
 		if !kerrors.IsNotFound(err) {
@@ -135,32 +170,75 @@ func (reconciler *BatchTransferReconciler) Reconcile(ctx context.Context, req ct
 				return ctrl.Result{}, err
 			}
 		}
+	} else {
+		if err := reconciler.updateSecret(ctx, batchTransfer, existingSecret); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Start the waiting runs now that the secret holds the watermark of the previous runs
+	if err := reconciler.startSuspendedRuns(ctx, suspendedRuns); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Propagate spec changes to an existing CronJob
+	if batchTransfer.Status.ObservedGeneration != batchTransfer.Generation {
+		if batchTransfer.IsCronJob() && cronJobExists {
+			if err := reconciler.updateCronJob(ctx, batchTransfer); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		batchTransfer.Status.ObservedGeneration = batchTransfer.Generation
+		if err := reconciler.Status().Update(ctx, batchTransfer); err != nil {
+			log.Error(err, "unable to update batchTransfer status")
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Run a finished one-shot job again if requested
+	if !batchTransfer.IsCronJob() && batchTransfer.Spec.Rerun != batchTransfer.Status.ObservedRerun {
+		requeue, err := reconciler.rerunBatchJob(ctx, batchTransfer)
+		if err != nil {
+			log.Error(err, "unable to run batchTransfer again")
+			return ctrl.Result{}, err
+		}
+		if requeue {
+			return ctrl.Result{RequeueAfter: time.Second}, nil
+		}
 	}
 
 	// Create new jobs if job has not yet started
-	if !batchTransfer.HasStarted() {
-		if !batchTransfer.IsCronJob() {
+	if !batchTransfer.IsCronJob() {
+		if !batchTransfer.HasStarted() {
 			// Start normal batch job
 
-			if err := reconciler.CreateBatchJob(batchTransfer); err != nil {
+			if err := reconciler.backend(batchTransfer).create(ctx, batchTransfer); err != nil {
 				if kerrors.IsAlreadyExists(err) {
-					log.Info(fmt.Sprintf("Job %s already exists!", batchTransfer.Name))
+					log.Info(fmt.Sprintf("Workload %s already exists!", batchTransfer.Name))
 				} else {
 					return ctrl.Result{}, err
 				}
 			}
-		} else {
-			// If batch job is suspended don't do anything
-			if batchTransfer.Spec.Suspend {
-				log.V(1).Info("batchjob suspended, skipping")
-				return ctrl.Result{}, nil
-			}
-			if err := reconciler.createCronJob(batchTransfer); err != nil {
+		}
+	} else if !cronJobExists {
+		// If batch job is suspended don't do anything
+		if batchTransfer.Spec.Suspend {
+			log.V(1).Info("batchjob suspended, skipping")
+			return ctrl.Result{}, nil
+		}
+		if err := reconciler.createCronJob(batchTransfer); err != nil {
+			if kerrors.IsAlreadyExists(err) {
+				log.Info(fmt.Sprintf("CronJob %s already exists!", batchTransfer.Name))
+			} else {
 				return ctrl.Result{}, err
 			}
 		}
 	}
 
+	// Poll the progress of an active run
+	if batchTransfer.Status.Active != nil && reconciler.ProgressInterval > 0 {
+		return ctrl.Result{RequeueAfter: reconciler.ProgressInterval}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
@@ -168,22 +246,34 @@ func (reconciler *BatchTransferReconciler) Reconcile(ctx context.Context, req ct
 func (reconciler *BatchTransferReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	numReconciles := environment.GetEnvAsInt(controllers.BatchTransferConcurrentReconcilesConfiguration, controllers.DefaultBatchTransferConcurrentReconciles)
 
-	return ctrl.NewControllerManagedBy(mgr).
+	builder := ctrl.NewControllerManagedBy(mgr).
 		WithOptions(controller.Options{MaxConcurrentReconciles: numReconciles}).
 		For(&motionv1.BatchTransfer{}).
 		Owns(&kbatch.Job{}).
+		Owns(&v1beta1.CronJob{}).
 		Owns(&corev1.Pod{}).
-		Complete(reconciler)
+		Watches(&source.Kind{Type: &kbatch.Job{}}, handler.EnqueueRequestsFromMapFunc(scheduledJobToBatchTransfer))
+
+	// SparkApplications can only be watched if the Spark operator is installed
+	if _, err := mgr.GetRESTMapper().RESTMapping(SparkApplicationGVK.GroupKind(), SparkApplicationGVK.Version); err == nil {
+		sparkApplication := &unstructured.Unstructured{}
+		sparkApplication.SetGroupVersionKind(SparkApplicationGVK)
+		builder = builder.Owns(sparkApplication)
+	} else {
+		reconciler.Log.Info("The Spark operator is not installed, the SparkApplication backend is not available")
+	}
+	return builder.Complete(reconciler)
 }
 
 // NewBatchTransferReconciler creates a new reconciler for BatchTransfer resources
 func NewBatchTransferReconciler(mgr ctrl.Manager, name string) *BatchTransferReconciler {
 	return &BatchTransferReconciler{
-		Reconciler{
+		Reconciler: Reconciler{
 			Client: mgr.GetClient(),
 			Name:   name,
 			Log:    ctrl.Log.WithName("controllers").WithName(name),
 			Scheme: mgr.GetScheme(),
 		},
+		ProgressInterval: environment.GetEnvAsDuration(controllers.BatchTransferProgressIntervalConfiguration, controllers.DefaultBatchTransferProgressInterval),
 	}
 }
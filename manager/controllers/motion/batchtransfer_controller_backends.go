@@ -0,0 +1,278 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package motion
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	kbatch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SparkApplicationGVK is the kind of the resources of the Spark operator that run a SparkApplication backend
+var SparkApplicationGVK = schema.GroupVersionKind{Group: "sparkoperator.k8s.io", Version: "v1beta2", Kind: "SparkApplication"}
+
+// A jobBackend runs the data movement of a one-shot BatchTransfer in a Kubernetes workload
+// that has the same name as the BatchTransfer.
+type jobBackend interface {
+	// newObject returns an empty workload object that is used to fetch the workload of a BatchTransfer
+	newObject() client.Object
+	// create creates the workload that moves the data of the BatchTransfer
+	create(ctx context.Context, batchTransfer *motionv1.BatchTransfer) error
+	// observe returns the progress of an existing workload
+	observe(ctx context.Context, batchTransfer *motionv1.BatchTransfer, workload client.Object) workloadState
+}
+
+// workloadState is the progress of a workload as observed by a jobBackend
+type workloadState struct {
+	// status of the data movement
+	status motionv1.BatchStatus
+	// whether the workload has finished, either successfully or not
+	finished bool
+	// message reported by the transfer once the workload has finished
+	message string
+	// time when the workload has finished successfully
+	completionTime *metav1.Time
+}
+
+// Returns the backend that runs the data movement of the given BatchTransfer
+func (reconciler *BatchTransferReconciler) backend(batchTransfer *motionv1.BatchTransfer) jobBackend {
+	if batchTransfer.Spec.Backend == motionv1.SparkApplicationBackend {
+		return &sparkApplicationBackend{reconciler: reconciler}
+	}
+	return &kubernetesJobBackend{reconciler: reconciler}
+}
+
+// kubernetesJobBackend runs the data movement in a Kubernetes Job
+type kubernetesJobBackend struct {
+	reconciler *BatchTransferReconciler
+}
+
+func (b *kubernetesJobBackend) newObject() client.Object {
+	return &kbatch.Job{}
+}
+
+func (b *kubernetesJobBackend) create(ctx context.Context, batchTransfer *motionv1.BatchTransfer) error {
+	return b.reconciler.CreateBatchJob(batchTransfer)
+}
+
+func (b *kubernetesJobBackend) observe(ctx context.Context, batchTransfer *motionv1.BatchTransfer, workload client.Object) workloadState {
+	job := workload.(*kbatch.Job)
+	_, finishedType := isJobFinished(job)
+	switch finishedType {
+	case kbatch.JobFailed:
+		return workloadState{
+			status:   motionv1.Failed,
+			finished: true,
+			message:  b.reconciler.terminationMessage(batchTransfer, job.Labels["controller-uid"]),
+		}
+	case kbatch.JobComplete:
+		return workloadState{
+			status:         motionv1.Succeeded,
+			finished:       true,
+			message:        b.reconciler.terminationMessage(batchTransfer, job.Labels["controller-uid"]),
+			completionTime: job.Status.CompletionTime,
+		}
+	}
+	// not a finisher, i.e. still on-going.
+	if job.Status.Active == 0 {
+		return workloadState{status: motionv1.Starting}
+	}
+	return workloadState{status: motionv1.Running}
+}
+
+// sparkApplicationBackend runs the data movement as a SparkApplication of the Spark operator.
+// The driver and the executors are sized according to the Spark configuration of the BatchTransfer.
+type sparkApplicationBackend struct {
+	reconciler *BatchTransferReconciler
+}
+
+func (b *sparkApplicationBackend) newObject() client.Object {
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(SparkApplicationGVK)
+	return app
+}
+
+func (b *sparkApplicationBackend) create(ctx context.Context, batchTransfer *motionv1.BatchTransfer) error {
+	app, err := b.reconciler.constructSparkApplication(batchTransfer)
+	if err != nil {
+		return err
+	}
+
+	// ...and create it on the cluster
+	if err := b.reconciler.Create(ctx, app); err != nil {
+		b.reconciler.Log.Error(err, "unable to create SparkApplication for batchTransfer", "application", app.GetName())
+		return err
+	}
+
+	b.reconciler.Log.V(1).Info("created SparkApplication for batchTransfer run", "application", app.GetName())
+	return nil
+}
+
+func (b *sparkApplicationBackend) observe(ctx context.Context, batchTransfer *motionv1.BatchTransfer, workload client.Object) workloadState {
+	app := workload.(*unstructured.Unstructured)
+	state, _, _ := unstructured.NestedString(app.Object, "status", "applicationState", "state")
+	switch state {
+	case "", "NEW", "SUBMITTED", "PENDING_RERUN":
+		return workloadState{status: motionv1.Starting}
+	case "FAILED", "SUBMISSION_FAILED":
+		message := b.driverTerminationMessage(ctx, batchTransfer, app)
+		if message == "" {
+			message, _, _ = unstructured.NestedString(app.Object, "status", "applicationState", "errorMessage")
+		}
+		return workloadState{status: motionv1.Failed, finished: true, message: message}
+	case "COMPLETED":
+		result := workloadState{
+			status:   motionv1.Succeeded,
+			finished: true,
+			message:  b.driverTerminationMessage(ctx, batchTransfer, app),
+		}
+		if terminationTime, found, _ := unstructured.NestedString(app.Object, "status", "terminationTime"); found {
+			if parsed, err := time.Parse(time.RFC3339, terminationTime); err == nil {
+				completionTime := metav1.NewTime(parsed)
+				result.completionTime = &completionTime
+			}
+		}
+		return result
+	}
+	return workloadState{status: motionv1.Running}
+}
+
+// Returns the termination message of the driver of a SparkApplication
+func (b *sparkApplicationBackend) driverTerminationMessage(ctx context.Context, batchTransfer *motionv1.BatchTransfer, app *unstructured.Unstructured) string {
+	podName, _, _ := unstructured.NestedString(app.Object, "status", "driverInfo", "podName")
+	if podName == "" {
+		return ""
+	}
+	pod := &v1.Pod{}
+	if err := b.reconciler.Get(ctx, types.NamespacedName{Namespace: batchTransfer.Namespace, Name: podName}, pod); err != nil {
+		b.reconciler.Log.V(1).Info("unable to fetch the driver of the SparkApplication", "pod", podName, "error", err.Error())
+		return ""
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "spark-kubernetes-driver" && status.State.Terminated != nil {
+			return status.State.Terminated.Message
+		}
+	}
+	return ""
+}
+
+// Returns a SparkApplication that implements the data movement described by the given BatchTransfer.
+// The driver reads the configuration secret of the BatchTransfer like the transfer Job does,
+// while the executors only run the distributed tasks.
+func (reconciler *BatchTransferReconciler) constructSparkApplication(batchTransfer *motionv1.BatchTransfer) (*unstructured.Unstructured, error) {
+	spark := batchTransfer.Spec.Spark
+	if spark == nil {
+		spark = &motionv1.Spark{}
+	}
+	image := spark.Image
+	if image == "" {
+		image = batchTransfer.Spec.Image
+	}
+	imagePullPolicy := spark.ImagePullPolicy
+	if imagePullPolicy == "" {
+		imagePullPolicy = batchTransfer.Spec.ImagePullPolicy
+	}
+
+	volumes, volumeMounts, err := VolumeConfiguration(batchTransfer)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations := map[string]interface{}{"sidecar.istio.io/inject": "false"}
+	driver := map[string]interface{}{
+		"annotations":    annotations,
+		"serviceAccount": spark.ServiceAccount,
+		"volumeMounts":   volumeMounts,
+		"env": []v1.EnvVar{
+			{Name: "OWNER_NAME", Value: batchTransfer.Name},
+			{Name: "OWNER_KIND", Value: batchTransfer.Kind},
+			{Name: "OWNER_UID", Value: string(batchTransfer.UID)},
+		},
+	}
+	executor := map[string]interface{}{
+		"annotations":  annotations,
+		"volumeMounts": volumeMounts,
+	}
+	if initContainers := pluginInitContainers(transformationPlugins(batchTransfer.Spec.Transformation), imagePullPolicy); len(initContainers) > 0 {
+		driver["initContainers"] = initContainers
+		executor["initContainers"] = initContainers
+	}
+	if spark.DriverCores > 0 {
+		driver["cores"] = spark.DriverCores
+	}
+	if spark.DriverMemory > 0 {
+		driver["memory"] = fmt.Sprintf("%dm", spark.DriverMemory)
+	}
+	if spark.NumExecutors > 0 {
+		executor["instances"] = spark.NumExecutors
+	}
+	if spark.ExecutorCores > 0 {
+		executor["cores"] = spark.ExecutorCores
+	}
+	if spark.ExecutorMemory != "" {
+		executor["memory"] = spark.ExecutorMemory
+	}
+
+	sparkConf := map[string]string{}
+	for key, value := range spark.AdditionalOptions {
+		sparkConf[key] = value
+	}
+	if spark.ShufflePartitions > 0 {
+		sparkConf["spark.sql.shuffle.partitions"] = fmt.Sprintf("%d", spark.ShufflePartitions)
+	}
+	if spark.AppName != "" {
+		sparkConf["spark.app.name"] = spark.AppName
+	}
+
+	spec := map[string]interface{}{
+		"type":                "Scala",
+		"mode":                "cluster",
+		"image":               image,
+		"imagePullPolicy":     imagePullPolicy,
+		"mainClass":           spark.MainClass,
+		"mainApplicationFile": spark.MainApplicationFile,
+		"arguments":           []string{motionv1.ConfigSecretMountPath + "/conf.json"},
+		"sparkVersion":        spark.SparkVersion,
+		"sparkConf":           sparkConf,
+		"restartPolicy": map[string]interface{}{
+			"type":                             "OnFailure",
+			"onFailureRetries":                 batchTransfer.Spec.MaxFailedRetries,
+			"onSubmissionFailureRetries":       batchTransfer.Spec.MaxFailedRetries,
+			"onFailureRetryInterval":           10,
+			"onSubmissionFailureRetryInterval": 10,
+		},
+		"volumes":  volumes,
+		"driver":   driver,
+		"executor": executor,
+	}
+	if batchTransfer.Spec.MaxFailedRetries == 0 {
+		spec["restartPolicy"] = map[string]interface{}{"type": "Never"}
+	}
+
+	// Convert the typed values into their unstructured representation
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec)
+	if err != nil {
+		return nil, err
+	}
+
+	app := &unstructured.Unstructured{Object: map[string]interface{}{"spec": content}}
+	app.SetGroupVersionKind(SparkApplicationGVK)
+	app.SetName(batchTransfer.Name)
+	app.SetNamespace(batchTransfer.Namespace)
+	if err := ctrl.SetControllerReference(batchTransfer, app, reconciler.Scheme); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
@@ -5,46 +5,82 @@ package motion
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	kbatch "k8s.io/api/batch/v1"
 	v1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/reference"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
-// Creates a Kubernetes CronJob from a BatchTransfer
+// batchTransferLabel holds the name of the scheduled BatchTransfer on its CronJob and the Jobs spawned by it
+const batchTransferLabel = "motion.fybrik.io/batchTransfer"
+
+// Constructs a Kubernetes CronJob from a BatchTransfer
 // This is used if the schedule field in a BatchTransfer is not empty and a
 // BatchTransfer should be scheduled on a regular basis.
 // This CronJob requires a secret with the same name for the configuration parameters.
 // The specifics of the job template is retrieved from the constructBatchJob method.
-// This function directly creates the cron job and returns an error if something went wrong.
-func (reconciler *BatchTransferReconciler) createCronJob(batchTransfer *motionv1.BatchTransfer) error {
+func (reconciler *BatchTransferReconciler) constructCronJob(batchTransfer *motionv1.BatchTransfer) (*v1beta1.CronJob, error) {
 	successfulJobHistoryLimit := int32(batchTransfer.Spec.SuccessfulJobHistoryLimit)
 	failedJobHistoryLimit := int32(batchTransfer.Spec.FailedJobHistoryLimit)
+	suspend := batchTransfer.Spec.Suspend
 
 	job, err := reconciler.constructBatchJob(batchTransfer)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	labels := map[string]string{batchTransferLabel: batchTransfer.Name}
+	// finished jobs exceeding the history limits are removed by the CronJob controller
 	cronJob := &v1beta1.CronJob{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      batchTransfer.Name,
 			Namespace: batchTransfer.Namespace,
+			Labels:    labels,
 		},
 		Spec: v1beta1.CronJobSpec{
 			Schedule: batchTransfer.Spec.Schedule,
-			Suspend:  &batchTransfer.Spec.Suspend,
+			Suspend:  &suspend,
 			JobTemplate: v1beta1.JobTemplateSpec{
-				Spec: job.Spec,
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       job.Spec,
 			},
 			SuccessfulJobsHistoryLimit: &successfulJobHistoryLimit,
 			FailedJobsHistoryLimit:     &failedJobHistoryLimit,
 		},
 	}
 
+	if batchTransfer.Spec.Incremental != nil {
+		// The runs of an incremental transfer must not overlap and have to start from the watermark of the previous
+		// run. Since the watermark is recorded in the configuration secret after a run finished, the runs are created
+		// suspended and only started once the secret holds the watermark.
+		// Clusters without support for suspended Jobs drop the field, which is reported by reconcileCronJobStatus.
+		runSuspended := true
+		cronJob.Spec.ConcurrencyPolicy = v1beta1.ForbidConcurrent
+		cronJob.Spec.JobTemplate.Spec.Suspend = &runSuspended
+	}
+
 	if err := ctrl.SetControllerReference(batchTransfer, cronJob, reconciler.Scheme); err != nil {
+		return nil, err
+	}
+	return cronJob, nil
+}
+
+// Creates a Kubernetes CronJob from a BatchTransfer
+// This function directly creates the cron job and returns an error if something went wrong.
+func (reconciler *BatchTransferReconciler) createCronJob(batchTransfer *motionv1.BatchTransfer) error {
+	cronJob, err := reconciler.constructCronJob(batchTransfer)
+	if err != nil {
 		return err
 	}
 
@@ -57,3 +93,236 @@ func (reconciler *BatchTransferReconciler) createCronJob(batchTransfer *motionv1
 	reconciler.Log.V(1).Info("created Job for batchTransfer run", "job", *cronJob)
 	return nil
 }
+
+// Updates the existing CronJob of a scheduled BatchTransfer in place after the spec of the BatchTransfer changed.
+// The schedule, the suspension, the concurrency policy, the job template and the history limits are taken over
+// from the spec.
+// Runs that are already active are not affected, the following runs use the updated job template.
+func (reconciler *BatchTransferReconciler) updateCronJob(ctx context.Context, batchTransfer *motionv1.BatchTransfer) error {
+	cronJob := &v1beta1.CronJob{}
+	if err := reconciler.Get(ctx, batchTransfer.ObjectKey(), cronJob); err != nil {
+		return err
+	}
+	desired, err := reconciler.constructCronJob(batchTransfer)
+	if err != nil {
+		return err
+	}
+	cronJob.Labels = desired.Labels
+	cronJob.Spec.Schedule = desired.Spec.Schedule
+	cronJob.Spec.Suspend = desired.Spec.Suspend
+	cronJob.Spec.ConcurrencyPolicy = desired.Spec.ConcurrencyPolicy
+	cronJob.Spec.JobTemplate = desired.Spec.JobTemplate
+	cronJob.Spec.SuccessfulJobsHistoryLimit = desired.Spec.SuccessfulJobsHistoryLimit
+	cronJob.Spec.FailedJobsHistoryLimit = desired.Spec.FailedJobsHistoryLimit
+	if err := reconciler.Update(ctx, cronJob); err != nil {
+		reconciler.Log.Error(err, "unable to update CronJob for batchTransfer", "cronjob", cronJob.Name)
+		return err
+	}
+	reconciler.Log.V(1).Info("updated CronJob for batchTransfer", "cronjob", cronJob.Name)
+	return nil
+}
+
+// Updates the status of a scheduled BatchTransfer from the Jobs spawned by its CronJob.
+// The most recent Job determines the status of the BatchTransfer, and a condition is raised if the consecutive
+// failures exceed MaxFailedRetries.
+// The function returns whether the CronJob exists and the runs that have been created suspended and wait to be started.
+func (reconciler *BatchTransferReconciler) reconcileCronJobStatus(ctx context.Context, batchTransfer *motionv1.BatchTransfer) (bool, []*kbatch.Job, error) {
+	log := reconciler.Log.WithValues("batchtransfer", batchTransfer.Name)
+
+	cronJob := &v1beta1.CronJob{}
+	if err := reconciler.Get(ctx, batchTransfer.ObjectKey(), cronJob); err != nil {
+		// If the cron job was not found it will be created
+		return false, nil, client.IgnoreNotFound(err)
+	}
+
+	var jobList kbatch.JobList
+	if err := reconciler.List(ctx, &jobList, client.InNamespace(batchTransfer.Namespace)); err != nil {
+		return true, nil, err
+	}
+	jobs := []*kbatch.Job{}
+	for i := range jobList.Items {
+		if metav1.IsControlledBy(&jobList.Items[i], cronJob) {
+			jobs = append(jobs, &jobList.Items[i])
+		}
+	}
+	// Most recent jobs first. Job names contain the scheduled time and break ties.
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if !jobs[i].CreationTimestamp.Equal(&jobs[j].CreationTimestamp) {
+			return jobs[j].CreationTimestamp.Before(&jobs[i].CreationTimestamp)
+		}
+		return jobs[i].Name > jobs[j].Name
+	})
+
+	status := &batchTransfer.Status
+	previousFailure := status.LastFailed
+	status.LastScheduleTime = cronJob.Status.LastScheduleTime
+	status.Active = nil
+	status.Progress = nil
+	var successfulJobs, failedJobs, suspendedJobs []*kbatch.Job
+	// unfinished runs and those of them that have not been created suspended
+	var unfinishedRuns, unsuspendedRuns int
+	// failures since the last successful run, most recent first
+	var failureRun []*kbatch.Job
+	// metrics of the most recent successful run, observed once the status has been persisted
+	var runMetrics *motionv1.TransferMetrics
+	lastFinished := true
+	for i, job := range jobs {
+		jobRef, err := reference.GetReference(reconciler.Scheme, job)
+		if err != nil {
+			log.Error(err, "unable to make reference to job", "job", job)
+		}
+		_, finishedType := isJobFinished(job)
+		switch finishedType {
+		case "": // not a finisher, i.e. still on-going.
+			unfinishedRuns++
+			if job.Spec.Suspend == nil {
+				unsuspendedRuns++
+			} else if *job.Spec.Suspend {
+				suspendedJobs = append(suspendedJobs, job)
+			}
+			if i == 0 {
+				if job.Status.Active == 0 {
+					status.Status = motionv1.Starting
+				} else {
+					status.Status = motionv1.Running
+				}
+				// the running job determines the status, not the finished jobs before it
+				lastFinished = false
+			}
+			if status.Active == nil {
+				status.Active = jobRef
+				reconciler.recordProgress(ctx, batchTransfer, job)
+			}
+		case kbatch.JobFailed:
+			if len(failedJobs) == 0 {
+				status.LastFailed = jobRef
+			}
+			if lastFinished {
+				status.Status = motionv1.Failed
+				reconciler.updateBatchErrorMessage(batchTransfer, job.Labels["controller-uid"])
+				lastFinished = false
+			}
+			if len(successfulJobs) == 0 {
+				failureRun = append(failureRun, job)
+			}
+			failedJobs = append(failedJobs, job)
+		case kbatch.JobComplete:
+			if len(successfulJobs) == 0 {
+				status.LastCompleted = jobRef
+				if job.Status.CompletionTime != nil {
+					status.LastSuccessTime = job.Status.CompletionTime
+				}
+				state := (&kubernetesJobBackend{reconciler: reconciler}).observe(ctx, batchTransfer, job)
+				runMetrics = reconciler.recordResult(ctx, batchTransfer, job, state)
+			}
+			if lastFinished {
+				status.Status = motionv1.Succeeded
+				status.Error = ""
+				lastFinished = false
+			}
+			successfulJobs = append(successfulJobs, job)
+		}
+	}
+	status.ConsecutiveFailures = consecutiveFailures(status.ConsecutiveFailures, previousFailure, failureRun, len(successfulJobs) > 0)
+	condition := metav1.Condition{
+		Type:    motionv1.FailedRetriesExceededCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  "WithinRetryLimit",
+		Message: fmt.Sprintf("%d consecutive runs failed", status.ConsecutiveFailures),
+	}
+	if status.ConsecutiveFailures > batchTransfer.Spec.MaxFailedRetries {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "RetryLimitExceeded"
+		condition.Message = fmt.Sprintf("%d consecutive runs failed, exceeding the limit of %d retries",
+			status.ConsecutiveFailures, batchTransfer.Spec.MaxFailedRetries)
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+	reconciler.setSuspendedRunsCondition(batchTransfer, unfinishedRuns, unsuspendedRuns)
+
+	// update the status of our CRD.
+	if err := reconciler.Status().Update(ctx, batchTransfer); err != nil {
+		return true, nil, err
+	}
+	if runMetrics != nil {
+		observeRunMetrics(batchTransfer, runMetrics)
+	}
+	return true, suspendedJobs, nil
+}
+
+// Reports whether the runs of a scheduled incremental BatchTransfer are created suspended.
+// A run that is started by the controller has the suspension field set to false, so a run without the field has been
+// created on a cluster that does not support suspended Jobs and dropped it. The condition is only evaluated while runs
+// are active and removed if the BatchTransfer is not incremental.
+func (reconciler *BatchTransferReconciler) setSuspendedRunsCondition(batchTransfer *motionv1.BatchTransfer, unfinishedRuns, unsuspendedRuns int) {
+	status := &batchTransfer.Status
+	if batchTransfer.Spec.Incremental == nil {
+		meta.RemoveStatusCondition(&status.Conditions, motionv1.SuspendedRunsUnsupportedCondition)
+		return
+	}
+	if unfinishedRuns == 0 {
+		return
+	}
+	condition := metav1.Condition{
+		Type:    motionv1.SuspendedRunsUnsupportedCondition,
+		Status:  metav1.ConditionFalse,
+		Reason:  "RunsSuspended",
+		Message: "runs are created suspended and started after the watermark has been recorded",
+	}
+	if unsuspendedRuns > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "SuspendNotSupported"
+		condition.Message = "runs are not created suspended and may copy data again, suspended Jobs require " +
+			"Kubernetes 1.22 or newer or the JobSuspend feature gate"
+		reconciler.Log.Info("the cluster does not support suspended jobs, incremental runs may copy data again",
+			"batchtransfer", batchTransfer.Name)
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
+// Starts runs of a scheduled BatchTransfer that have been created suspended.
+// It must only be called after the configuration secret has been updated with the watermark of the previous runs.
+func (reconciler *BatchTransferReconciler) startSuspendedRuns(ctx context.Context, jobs []*kbatch.Job) error {
+	for _, job := range jobs {
+		suspend := false
+		job.Spec.Suspend = &suspend
+		if err := reconciler.Update(ctx, job); err != nil {
+			reconciler.Log.Error(err, "unable to start suspended run", "job", job.Name)
+			return err
+		}
+		reconciler.Log.V(1).Info("started suspended run", "job", job.Name)
+	}
+	return nil
+}
+
+// Returns the number of runs that have failed in a row given the failures since the last successful run.
+// Failed jobs may have been removed due to the history limit, thus if no successful run is known the failures are
+// counted incrementally: only failures that are more recent than the previously recorded failure are added.
+func consecutiveFailures(previousCount int, previousFailure *corev1.ObjectReference, failureRun []*kbatch.Job, succeeded bool) int {
+	if succeeded || previousFailure == nil {
+		return len(failureRun)
+	}
+	count := previousCount
+	for _, job := range failureRun {
+		if job.UID == previousFailure.UID {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+// Maps a Job spawned by the CronJob of a scheduled BatchTransfer to the BatchTransfer.
+// The CronJob has the name of the BatchTransfer that owns it, and the Jobs spawned by it are labeled with this name.
+// Jobs of other CronJobs are ignored.
+func scheduledJobToBatchTransfer(object client.Object) []reconcile.Request {
+	owner := metav1.GetControllerOf(object)
+	if owner == nil || owner.Kind != "CronJob" || object.GetLabels()[batchTransferLabel] != owner.Name {
+		return []reconcile.Request{}
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{
+			Name:      owner.Name,
+			Namespace: object.GetNamespace(),
+		}},
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package motion
+
+import (
+	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// transferConfiguration is the content of the configuration secret that is read by the transfer jobs.
+// It consists of the spec of the BatchTransfer and, for incremental transfers, the watermark that the next
+// run starts from. An empty watermark requests a full snapshot of the data.
+type transferConfiguration struct {
+	motionv1.BatchTransferSpec
+	Watermark string           `json:"watermark,omitempty"`
+	Plugins   []transferPlugin `json:"plugins,omitempty"`
+}
+
+// Returns the configuration that is stored in the secret of the BatchTransfer
+func newTransferConfiguration(batchTransfer *motionv1.BatchTransfer) *transferConfiguration {
+	config := &transferConfiguration{
+		BatchTransferSpec: batchTransfer.Spec,
+		Plugins:           transferPlugins(transformationPlugins(batchTransfer.Spec.Transformation)),
+	}
+	if batchTransfer.Spec.Incremental != nil && batchTransfer.Status.Watermark != nil {
+		config.Watermark = batchTransfer.Status.Watermark.Value
+	}
+	return config
+}
+
+// Discards the watermark of an incremental BatchTransfer after its reset counter has been changed,
+// so that the next run moves a full snapshot of the data. The job that recorded the current watermark is
+// remembered so that its result is not recorded again.
+func resetWatermark(batchTransfer *motionv1.BatchTransfer) {
+	var jobUID types.UID
+	if batchTransfer.Status.Watermark != nil {
+		jobUID = batchTransfer.Status.Watermark.JobUID
+	}
+	if batchTransfer.Status.LastCompleted != nil {
+		jobUID = batchTransfer.Status.LastCompleted.UID
+	}
+	now := metav1.Now()
+	batchTransfer.Status.Watermark = &motionv1.Watermark{JobUID: jobUID, RecordTime: &now}
+	batchTransfer.Status.ObservedReset = batchTransfer.Spec.Incremental.Reset
+}
+
+// Records the watermark reported by a successful run of an incremental BatchTransfer.
+// The watermark of a run is recorded only once. If the run does not report a watermark,
+// e.g. because there was no new data, the previous watermark is kept.
+func recordWatermark(batchTransfer *motionv1.BatchTransfer, runUID types.UID, value string) {
+	if batchTransfer.Spec.Incremental == nil {
+		return
+	}
+	previous := batchTransfer.Status.Watermark
+	if previous != nil && previous.JobUID == runUID {
+		return
+	}
+	watermark := &motionv1.Watermark{JobUID: runUID, Value: value}
+	if value == "" && previous != nil {
+		watermark.Value = previous.Value
+	}
+	now := metav1.Now()
+	watermark.RecordTime = &now
+	batchTransfer.Status.Watermark = watermark
+}
@@ -4,14 +4,15 @@
 package motion
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"path"
-	"sort"
 
 	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
 	kbatch "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -74,9 +75,44 @@ func VolumeConfiguration(batchTransfer *motionv1.BatchTransfer) ([]v1.Volume, []
 			})
 		}
 	}
+	// The jars of transformation plugins are copied into a shared volume by init containers
+	pluginVolumes, pluginVolumeMounts := pluginVolumeConfiguration(transformationPlugins(batchTransfer.Spec.Transformation))
+	volumes = append(volumes, pluginVolumes...)
+	volumeMounts = append(volumeMounts, pluginVolumeMounts...)
+	// Persistent volume claims of file system data stores are mounted at /mnt/<claim>
+	source, destination := batchTransfer.Spec.Source.FileSystem, batchTransfer.Spec.Destination.FileSystem
+	if source != nil && source.PersistentVolumeClaim != "" {
+		readOnly := destination == nil || destination.PersistentVolumeClaim != source.PersistentVolumeClaim
+		volumes = append(volumes, persistentVolume("source-filesystem", source.PersistentVolumeClaim, readOnly))
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      "source-filesystem",
+			MountPath: path.Join(motionv1.FileSystemMountRoot, source.PersistentVolumeClaim),
+			ReadOnly:  readOnly,
+		})
+	}
+	if destination != nil && destination.PersistentVolumeClaim != "" &&
+		(source == nil || source.PersistentVolumeClaim != destination.PersistentVolumeClaim) {
+		volumes = append(volumes, persistentVolume("destination-filesystem", destination.PersistentVolumeClaim, false))
+		volumeMounts = append(volumeMounts, v1.VolumeMount{
+			Name:      "destination-filesystem",
+			MountPath: path.Join(motionv1.FileSystemMountRoot, destination.PersistentVolumeClaim),
+		})
+	}
 	return volumes, volumeMounts, nil
 }
 
+func persistentVolume(name string, claimName string, readOnly bool) v1.Volume {
+	return v1.Volume{
+		Name: name,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: claimName,
+				ReadOnly:  readOnly,
+			},
+		},
+	}
+}
+
 // This is a helper method that returns a kubernetes job description (or an error) that implements the data movement
 // described by the given BatchTransfer object. The job is intended to move tabular (relational) data.
 // An error is returned if this controller cannot be made the "owner" of this job.
@@ -110,7 +146,8 @@ func (reconciler *BatchTransferReconciler) createSparkJob(batchTransfer *motionv
 					Annotations: annotations,
 				},
 				Spec: v1.PodSpec{
-					Volumes: volumes,
+					Volumes:        volumes,
+					InitContainers: pluginInitContainers(transformationPlugins(batchTransfer.Spec.Transformation), batchTransfer.Spec.ImagePullPolicy),
 					Containers: []v1.Container{{
 						Name:            "transfer",
 						Image:           batchTransfer.Spec.Image,
@@ -245,14 +282,12 @@ func (reconciler *BatchTransferReconciler) CreateBatchJob(batchTransfer *motionv
 	return nil
 }
 
-// Create the secret that is used for the BatchTransfer object
-// This secret contains the spec of the BatchTransfer object in a JSON file.
-func (reconciler *BatchTransferReconciler) CreateSecret(batchTransfer *motionv1.BatchTransfer) error {
-	log := reconciler.Log.WithValues("batchtransfer", batchTransfer.Name)
-
-	bytes, err := json.Marshal(batchTransfer.Spec) // Write spec into secret
+// Constructs the secret that is used for the BatchTransfer object
+// This secret contains the spec of the BatchTransfer object and the watermark of incremental transfers in a JSON file.
+func (reconciler *BatchTransferReconciler) constructSecret(batchTransfer *motionv1.BatchTransfer) (*v1.Secret, error) {
+	conf, err := json.Marshal(newTransferConfiguration(batchTransfer)) // Write spec into secret
 	if err != nil {
-		log.V(1).Info("Error!")
+		return nil, err
 	}
 
 	secret := &v1.Secret{
@@ -265,8 +300,20 @@ func (reconciler *BatchTransferReconciler) CreateSecret(batchTransfer *motionv1.
 		Data: make(map[string][]byte),
 	}
 
-	secret.Data["conf.json"] = bytes
+	secret.Data["conf.json"] = conf
 	if err := ctrl.SetControllerReference(batchTransfer, secret, reconciler.Scheme); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// Create the secret that is used for the BatchTransfer object
+// This secret contains the spec of the BatchTransfer object in a JSON file.
+func (reconciler *BatchTransferReconciler) CreateSecret(batchTransfer *motionv1.BatchTransfer) error {
+	log := reconciler.Log.WithValues("batchtransfer", batchTransfer.Name)
+
+	secret, err := reconciler.constructSecret(batchTransfer)
+	if err != nil {
 		return err
 	}
 
@@ -280,6 +327,64 @@ func (reconciler *BatchTransferReconciler) CreateSecret(batchTransfer *motionv1.
 	return nil
 }
 
+// Updates the configuration of an existing secret of the BatchTransfer object with the current spec.
+// Jobs that are started afterwards read the updated configuration.
+func (reconciler *BatchTransferReconciler) updateSecret(ctx context.Context, batchTransfer *motionv1.BatchTransfer, existing *v1.Secret) error {
+	secret, err := reconciler.constructSecret(batchTransfer)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(existing.Data["conf.json"], secret.Data["conf.json"]) {
+		return nil
+	}
+	if existing.Data == nil {
+		existing.Data = make(map[string][]byte)
+	}
+	existing.Data["conf.json"] = secret.Data["conf.json"]
+	if err := reconciler.Update(ctx, existing); err != nil {
+		reconciler.Log.Error(err, "unable to update Secret for batchTransfer", "secret", existing.Name)
+		return err
+	}
+	reconciler.Log.V(1).Info("updated Secret for batchTransfer", "secret", existing.Name)
+	return nil
+}
+
+// Prepares another run of a finished one-shot BatchTransfer after its rerun counter has been changed.
+// The finished workload is removed and the status is reset so that a new workload is created once the removal completes.
+// A running workload is not interrupted, the new run is prepared after it finishes.
+// The function returns whether the BatchTransfer should be reconciled again to create the new workload
+// after the removal of the previous one.
+func (reconciler *BatchTransferReconciler) rerunBatchJob(ctx context.Context, batchTransfer *motionv1.BatchTransfer) (bool, error) {
+	backend := reconciler.backend(batchTransfer)
+	workload := backend.newObject()
+	workloadExists := true
+	if err := reconciler.Get(ctx, batchTransfer.ObjectKey(), workload); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return false, err
+		}
+		// There is no workload to replace, the new workload can be created right away
+		workloadExists = false
+	} else {
+		if !backend.observe(ctx, batchTransfer, workload).finished {
+			return false, nil
+		}
+		if workload.GetDeletionTimestamp().IsZero() {
+			err := reconciler.Delete(ctx, workload, client.PropagationPolicy(metav1.DeletePropagationBackground))
+			if err != nil && !kerrors.IsNotFound(err) {
+				return false, err
+			}
+			reconciler.Log.Info("removed the finished workload of batchTransfer for another run", "workload", workload.GetName())
+		}
+	}
+	batchTransfer.Status.Status = motionv1.Starting
+	batchTransfer.Status.Error = ""
+	batchTransfer.Status.Active = nil
+	batchTransfer.Status.LastCompleted = nil
+	batchTransfer.Status.LastFailed = nil
+	batchTransfer.Status.ObservedRerun = batchTransfer.Spec.Rerun
+	return workloadExists, reconciler.Status().Update(ctx, batchTransfer)
+}
+
 // Returns the condition type of the given job
 func isJobFinished(job *kbatch.Job) (bool, kbatch.JobConditionType) {
 	// Conditions is an array of a condition and condition is a struct that defines the type of the job condition
@@ -297,22 +402,7 @@ func isJobFinished(job *kbatch.Job) (bool, kbatch.JobConditionType) {
 // Get the error string from the last pod of the job and set it as error message
 // for the BatchTransfer object.
 func (reconciler *BatchTransferReconciler) updateBatchErrorMessage(transfer *motionv1.BatchTransfer, controllerID string) {
-	log := reconciler.Log.WithValues("batchtransfer", transfer.Name)
-	var podList v1.PodList
-	ns := client.InNamespace(transfer.Namespace)
-	ls := client.MatchingLabels{"controller-uid": controllerID}
-	if err := reconciler.List(context.Background(), &podList, ns, ls); err != nil {
-		log.Error(err, "unable to list child Pods")
-	}
-
-	if len(podList.Items) > 0 {
-		sort.SliceStable(podList.Items, func(i, j int) bool {
-			return podList.Items[i].Status.StartTime.Time.After(podList.Items[j].Status.StartTime.Time)
-		})
-		pod := podList.Items[0]
-		if len(pod.Status.ContainerStatuses) > 0 {
-			message := pod.Status.ContainerStatuses[0].State.Terminated.Message
-			transfer.Status.Error = message
-		}
+	if message := reconciler.terminationMessage(transfer, controllerID); message != "" {
+		transfer.Status.Error = failureMessage(message)
 	}
 }
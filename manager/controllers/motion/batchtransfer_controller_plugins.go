@@ -0,0 +1,120 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package motion
+
+import (
+	"path"
+	"strconv"
+
+	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	"fybrik.io/fybrik/pkg/transformations"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Name of the volume into which the jars of the transformation plugins are copied
+const pluginsVolumeName = "transformation-plugins"
+
+// Returns the plugins that perform the transformations of a transfer and are not built into the data mover.
+// Every plugin is only returned once even if it is used by several transformations.
+func transformationPlugins(transformationList []motionv1.Transformation) []*transformations.Plugin {
+	var plugins []*transformations.Plugin
+	seen := map[string]bool{}
+	for _, transformation := range transformationList {
+		if transformation.Plugin == "" || seen[transformation.Plugin] {
+			continue
+		}
+		plugin := transformations.DefaultRegistry.Get(transformation.Plugin)
+		if plugin == nil || plugin.IsBuiltin() {
+			continue
+		}
+		seen[plugin.ID] = true
+		plugins = append(plugins, plugin)
+	}
+	return plugins
+}
+
+// transferPlugin tells the data mover how to load a transformation plugin
+type transferPlugin struct {
+	ID    string `json:"id"`
+	Jar   string `json:"jar,omitempty"`
+	Class string `json:"class,omitempty"`
+}
+
+// Returns the plugins in the form in which they are passed to the data mover in the transfer configuration
+func transferPlugins(plugins []*transformations.Plugin) []transferPlugin {
+	var result []transferPlugin
+	for _, plugin := range plugins {
+		result = append(result, transferPlugin{ID: plugin.ID, Jar: pluginJar(plugin), Class: plugin.Class})
+	}
+	return result
+}
+
+// Returns the volume that holds the jars of the plugins and its mount in the transfer container.
+// Nothing is returned if no plugin has to be copied from an image.
+func pluginVolumeConfiguration(plugins []*transformations.Plugin) ([]v1.Volume, []v1.VolumeMount) {
+	for _, plugin := range plugins {
+		if plugin.Image == "" {
+			continue
+		}
+		volume := v1.Volume{
+			Name: pluginsVolumeName,
+			VolumeSource: v1.VolumeSource{
+				EmptyDir: &v1.EmptyDirVolumeSource{},
+			},
+		}
+		volumeMount := v1.VolumeMount{
+			Name:      pluginsVolumeName,
+			MountPath: transformations.MountPath,
+			ReadOnly:  true,
+		}
+		return []v1.Volume{volume}, []v1.VolumeMount{volumeMount}
+	}
+	return nil, nil
+}
+
+// Returns the init containers that copy the jars of the plugins from their images into the plugin volume
+func pluginInitContainers(plugins []*transformations.Plugin, pullPolicy v1.PullPolicy) []v1.Container {
+	var containers []v1.Container
+	for i, plugin := range plugins {
+		if plugin.Image == "" {
+			continue
+		}
+		containers = append(containers, v1.Container{
+			Name:            "plugin-" + sanitizedName(plugin.ID, i),
+			Image:           plugin.Image,
+			ImagePullPolicy: pullPolicy,
+			Command:         []string{"cp", "-r", transformations.ImagePluginsPath + "/.", transformations.MountPath},
+			VolumeMounts: []v1.VolumeMount{{
+				Name:      pluginsVolumeName,
+				MountPath: transformations.MountPath,
+			}},
+		})
+	}
+	return containers
+}
+
+// Returns the location of the jar of a plugin as seen by the data mover
+func pluginJar(plugin *transformations.Plugin) string {
+	if plugin.Image == "" || plugin.Jar == "" || path.IsAbs(plugin.Jar) {
+		return plugin.Jar
+	}
+	return path.Join(transformations.MountPath, plugin.Jar)
+}
+
+// Returns a container name for a plugin ID, falling back to the index of the plugin if the ID is not a valid name
+func sanitizedName(id string, index int) string {
+	name := make([]rune, 0, len(id))
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+			name = append(name, c)
+		case c >= 'A' && c <= 'Z':
+			name = append(name, c-'A'+'a')
+		}
+	}
+	if len(name) == 0 || len(name) > 50 || name[0] == '-' || name[len(name)-1] == '-' {
+		return strconv.Itoa(index)
+	}
+	return string(name)
+}
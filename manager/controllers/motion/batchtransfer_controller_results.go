@@ -0,0 +1,280 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package motion
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// ResultConfigMapKey is the key of the transfer result in the result ConfigMap of a run.
+// A transfer reports its result in the termination message of its container. A transfer may additionally
+// report its progress, or a result that exceeds the size limit of termination messages, in a ConfigMap that
+// has the name of the Job (or SparkApplication) of the run.
+const ResultConfigMapKey = "result.json"
+
+// transferResult is the result that a transfer reports to the controller as a JSON document.
+type transferResult struct {
+	// Number of records that have been moved. Superseded by RecordsWritten.
+	NumRecords *int64 `json:"numRecords,omitempty"`
+	// Number of records that have been read from the source
+	RecordsRead int64 `json:"recordsRead,omitempty"`
+	// Number of records that have been written to the destination
+	RecordsWritten *int64 `json:"recordsWritten,omitempty"`
+	// Number of records that have been removed by transformations
+	RecordsFiltered int64 `json:"recordsFiltered,omitempty"`
+	// Number of bytes that have been written to the destination
+	BytesMoved int64 `json:"bytesMoved,omitempty"`
+	// Duration of the run in milliseconds
+	DurationMillis int64 `json:"durationMillis,omitempty"`
+	// Number of records that have been changed or removed by each transformation
+	Transformations []motionv1.TransformationMetrics `json:"transformations,omitempty"`
+	// Watermark up to which the data has been moved
+	Watermark string `json:"watermark,omitempty"`
+	// Error that made the transfer fail
+	Error string `json:"error,omitempty"`
+}
+
+// Parses a transfer result. Returns nil if the message is not a transfer result, e.g. the plain error message
+// of a transfer that does not support the result protocol.
+func parseTransferResult(message string) *transferResult {
+	message = strings.TrimSpace(message)
+	if !strings.HasPrefix(message, "{") {
+		return nil
+	}
+	result := &transferResult{}
+	if err := json.Unmarshal([]byte(message), result); err != nil {
+		return nil
+	}
+	return result
+}
+
+// Returns the number of records written by the transfer or nil if it has not been reported
+func (result *transferResult) recordsWritten() *int64 {
+	if result.RecordsWritten != nil {
+		return result.RecordsWritten
+	}
+	return result.NumRecords
+}
+
+// Returns whether the result reports any metrics
+func (result *transferResult) hasMetrics() bool {
+	return result.recordsWritten() != nil || result.RecordsRead != 0 || result.RecordsFiltered != 0 ||
+		result.BytesMoved != 0 || result.DurationMillis != 0 || len(result.Transformations) != 0
+}
+
+// Returns the metrics of the run with the given UID as reported by the result
+func (result *transferResult) metrics(runUID types.UID) *motionv1.TransferMetrics {
+	now := metav1.Now()
+	transferMetrics := &motionv1.TransferMetrics{
+		JobUID:          runUID,
+		RecordsRead:     result.RecordsRead,
+		RecordsFiltered: result.RecordsFiltered,
+		BytesMoved:      result.BytesMoved,
+		Transformations: result.Transformations,
+		ReportTime:      &now,
+	}
+	if written := result.recordsWritten(); written != nil {
+		transferMetrics.RecordsWritten = *written
+	}
+	if result.DurationMillis > 0 {
+		transferMetrics.Duration = &metav1.Duration{Duration: time.Duration(result.DurationMillis) * time.Millisecond}
+	}
+	return transferMetrics
+}
+
+// Returns the error message reported by a failed transfer
+func failureMessage(message string) string {
+	if result := parseTransferResult(message); result != nil && result.Error != "" {
+		return result.Error
+	}
+	return message
+}
+
+// Returns the result reported by a run in its result ConfigMap or nil if no result has been reported.
+// ConfigMaps that are older than the run have been left by a previous run with the same name and are ignored.
+func (reconciler *BatchTransferReconciler) readResultConfigMap(ctx context.Context, run client.Object) *transferResult {
+	configMap := &v1.ConfigMap{}
+	key := types.NamespacedName{Namespace: run.GetNamespace(), Name: run.GetName()}
+	if err := reconciler.Get(ctx, key, configMap); err != nil {
+		if !kerrors.IsNotFound(err) {
+			reconciler.Log.V(1).Info("unable to fetch the result of the transfer", "configmap", key, "error", err.Error())
+		}
+		return nil
+	}
+	creationTimestamp := run.GetCreationTimestamp()
+	if configMap.CreationTimestamp.Before(&creationTimestamp) {
+		return nil
+	}
+	return parseTransferResult(configMap.Data[ResultConfigMapKey])
+}
+
+// Records the result reported by a successful run of a BatchTransfer: the number of moved records,
+// the metrics of the run and, for incremental transfers, the watermark that the next run starts from.
+// The result is read from the termination message of the run or, if there is none, from its result ConfigMap.
+// The metrics of a run that are recorded for the first time are returned. They have to be observed by the caller with
+// observeRunMetrics once the status has been persisted, so that a run is not counted again if the update fails.
+func (reconciler *BatchTransferReconciler) recordResult(ctx context.Context, batchTransfer *motionv1.BatchTransfer, run client.Object, state workloadState) *motionv1.TransferMetrics {
+	result := parseTransferResult(state.message)
+	if result == nil {
+		if state.message != "" {
+			reconciler.Log.Info("could not read the result of the transfer", "batchtransfer", batchTransfer.Name, "message", state.message)
+		}
+		result = reconciler.readResultConfigMap(ctx, run)
+	}
+	batchTransfer.Status.Progress = nil
+	if result == nil {
+		result = &transferResult{}
+	}
+	if written := result.recordsWritten(); written != nil {
+		batchTransfer.Status.NumRecords = *written
+		batchTransfer.Status.LastRecordTime = state.completionTime
+	}
+	recordWatermark(batchTransfer, run.GetUID(), result.Watermark)
+
+	// The metrics of a run are recorded only once
+	if !result.hasMetrics() {
+		return nil
+	}
+	if previous := batchTransfer.Status.LastRunMetrics; previous != nil && previous.JobUID == run.GetUID() {
+		return nil
+	}
+	runMetrics := result.metrics(run.GetUID())
+	if creationTimestamp := run.GetCreationTimestamp(); runMetrics.Duration == nil && state.completionTime != nil && !creationTimestamp.IsZero() {
+		runMetrics.Duration = &metav1.Duration{Duration: state.completionTime.Sub(creationTimestamp.Time)}
+	}
+	batchTransfer.Status.LastRunMetrics = runMetrics
+	return runMetrics
+}
+
+// Records the progress that an active run of a BatchTransfer has reported in its result ConfigMap
+func (reconciler *BatchTransferReconciler) recordProgress(ctx context.Context, batchTransfer *motionv1.BatchTransfer, run client.Object) {
+	if result := reconciler.readResultConfigMap(ctx, run); result != nil && result.hasMetrics() {
+		batchTransfer.Status.Progress = result.metrics(run.GetUID())
+	}
+}
+
+// Returns the termination message of the most recent pod of a job
+func (reconciler *BatchTransferReconciler) terminationMessage(transfer *motionv1.BatchTransfer, controllerID string) string {
+	log := reconciler.Log.WithValues("batchtransfer", transfer.Name)
+	var podList v1.PodList
+	ns := client.InNamespace(transfer.Namespace)
+	ls := client.MatchingLabels{"controller-uid": controllerID}
+	if err := reconciler.List(context.Background(), &podList, ns, ls); err != nil {
+		log.Error(err, "unable to list child Pods")
+		return ""
+	}
+
+	if len(podList.Items) == 0 {
+		return ""
+	}
+	sort.SliceStable(podList.Items, func(i, j int) bool {
+		if podList.Items[i].Status.StartTime == nil || podList.Items[j].Status.StartTime == nil {
+			return podList.Items[j].Status.StartTime == nil
+		}
+		return podList.Items[i].Status.StartTime.Time.After(podList.Items[j].Status.StartTime.Time)
+	})
+	pod := podList.Items[0]
+	if len(pod.Status.ContainerStatuses) == 0 || pod.Status.ContainerStatuses[0].State.Terminated == nil {
+		return ""
+	}
+	return pod.Status.ContainerStatuses[0].State.Terminated.Message
+}
+
+var (
+	transferLabels = []string{"namespace", "batchtransfer"}
+
+	transferRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_batchtransfer_successful_runs_total",
+		Help: "Number of successful runs of a BatchTransfer that reported metrics",
+	}, transferLabels)
+	transferRecordsRead = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_batchtransfer_records_read_total",
+		Help: "Number of records read from the source by the runs of a BatchTransfer",
+	}, transferLabels)
+	transferRecordsWritten = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_batchtransfer_records_written_total",
+		Help: "Number of records written to the destination by the runs of a BatchTransfer",
+	}, transferLabels)
+	transferRecordsFiltered = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_batchtransfer_records_filtered_total",
+		Help: "Number of records removed by the transformations of the runs of a BatchTransfer",
+	}, transferLabels)
+	transferBytesMoved = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_batchtransfer_bytes_moved_total",
+		Help: "Number of bytes written to the destination by the runs of a BatchTransfer",
+	}, transferLabels)
+	transferTransformationRecords = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_batchtransfer_transformation_records_total",
+		Help: "Number of records changed or removed by a transformation of a BatchTransfer",
+	}, []string{"namespace", "batchtransfer", "transformation"})
+	transferRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "fybrik_batchtransfer_run_duration_seconds",
+		Help:    "Duration of the successful runs of a BatchTransfer",
+		Buckets: prometheus.ExponentialBuckets(10, 2, 12),
+	}, transferLabels)
+
+	// the transformations that have been reported for each BatchTransfer, used to remove their metrics
+	reportedTransformations      = map[types.NamespacedName]map[string]bool{}
+	reportedTransformationsMutex sync.Mutex
+)
+
+func init() {
+	metrics.Registry.MustRegister(transferRuns, transferRecordsRead, transferRecordsWritten, transferRecordsFiltered,
+		transferBytesMoved, transferTransformationRecords, transferRunDuration)
+}
+
+// Adds the metrics of a run of a BatchTransfer to the metrics of the manager
+func observeRunMetrics(batchTransfer *motionv1.BatchTransfer, runMetrics *motionv1.TransferMetrics) {
+	labels := prometheus.Labels{"namespace": batchTransfer.Namespace, "batchtransfer": batchTransfer.Name}
+	transferRuns.With(labels).Inc()
+	transferRecordsRead.With(labels).Add(float64(runMetrics.RecordsRead))
+	transferRecordsWritten.With(labels).Add(float64(runMetrics.RecordsWritten))
+	transferRecordsFiltered.With(labels).Add(float64(runMetrics.RecordsFiltered))
+	transferBytesMoved.With(labels).Add(float64(runMetrics.BytesMoved))
+	if runMetrics.Duration != nil {
+		transferRunDuration.With(labels).Observe(runMetrics.Duration.Seconds())
+	}
+
+	reportedTransformationsMutex.Lock()
+	defer reportedTransformationsMutex.Unlock()
+	key := types.NamespacedName{Namespace: batchTransfer.Namespace, Name: batchTransfer.Name}
+	for _, transformation := range runMetrics.Transformations {
+		transferTransformationRecords.WithLabelValues(batchTransfer.Namespace, batchTransfer.Name, transformation.Name).
+			Add(float64(transformation.RecordsAffected))
+		if reportedTransformations[key] == nil {
+			reportedTransformations[key] = map[string]bool{}
+		}
+		reportedTransformations[key][transformation.Name] = true
+	}
+}
+
+// Removes the metrics of a BatchTransfer that has been deleted
+func forgetRunMetrics(key types.NamespacedName) {
+	for _, vec := range []*prometheus.CounterVec{transferRuns, transferRecordsRead, transferRecordsWritten,
+		transferRecordsFiltered, transferBytesMoved} {
+		vec.DeleteLabelValues(key.Namespace, key.Name)
+	}
+	transferRunDuration.DeleteLabelValues(key.Namespace, key.Name)
+
+	reportedTransformationsMutex.Lock()
+	defer reportedTransformationsMutex.Unlock()
+	for transformation := range reportedTransformations[key] {
+		transferTransformationRecords.DeleteLabelValues(key.Namespace, key.Name, transformation)
+	}
+	delete(reportedTransformations, key)
+}
@@ -5,22 +5,31 @@ package motion
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"fybrik.io/fybrik/manager/controllers/utils"
 
 	"github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	"fybrik.io/fybrik/pkg/transformations"
 
 	kbatch "k8s.io/api/batch/v1"
+	"k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -79,7 +88,7 @@ func TestBatchTransferController(t *testing.T) {
 	cl := fake.NewFakeClientWithScheme(s, objs...)
 	// Create a BatchTransferReconciler object with the scheme and fake client.
 	r := &BatchTransferReconciler{
-		Reconciler{
+		Reconciler: Reconciler{
 			Client: cl,
 			Log:    ctrl.Log.WithName("test-controller"),
 			Scheme: s,
@@ -117,3 +126,851 @@ func TestBatchTransferController(t *testing.T) {
 		t.Fatalf("get secret: (%v)", err)
 	}
 }
+
+// TestScheduledBatchTransferStatus checks that the outcomes of the jobs spawned by the CronJob of a scheduled
+// BatchTransfer are rolled into the BatchTransfer status.
+func TestScheduledBatchTransferStatus(t *testing.T) {
+	t.Parallel()
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		name      = "scheduled-transfer"
+		namespace = "fybrik-system"
+	)
+	batchTransfer := &motionv1.BatchTransfer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: motionv1.BatchTransferSpec{
+			Source: motionv1.DataStore{
+				Database: &motionv1.Database{
+					Db2URL:   "jdbc:db2://host:1234/DB",
+					Table:    "MY.TABLE",
+					User:     "user",
+					Password: "password",
+				},
+			},
+			Destination: motionv1.DataStore{
+				S3: &motionv1.S3{
+					Endpoint:   "my.endpoint",
+					Region:     "eu-gb",
+					Bucket:     "myBucket",
+					AccessKey:  "ab",
+					SecretKey:  "cd",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+			Schedule:                  "0 * * * *",
+			MaxFailedRetries:          1,
+			SuccessfulJobHistoryLimit: 1,
+			FailedJobHistoryLimit:     5,
+		},
+	}
+
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, batchTransfer)
+	r := &BatchTransferReconciler{
+		Reconciler: Reconciler{
+			Client: cl,
+			Log:    ctrl.Log.WithName("test-controller"),
+			Scheme: s,
+		},
+	}
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	cronJob := &v1beta1.CronJob{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, cronJob)).To(gomega.Succeed())
+
+	// simulate runs of the cron job: two successful runs followed by two failures
+	now := time.Now()
+	newJob := func(suffix string, age time.Duration, condition kbatch.JobConditionType) *kbatch.Job {
+		job := &kbatch.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name + "-" + suffix,
+				Namespace:         namespace,
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Status: kbatch.JobStatus{
+				Conditions: []kbatch.JobCondition{{Type: condition, Status: corev1.ConditionTrue}},
+			},
+		}
+		if condition == kbatch.JobComplete {
+			completionTime := metav1.NewTime(now.Add(-age).Add(time.Minute))
+			job.Status.CompletionTime = &completionTime
+		}
+		g.Expect(ctrl.SetControllerReference(cronJob, job, s)).To(gomega.Succeed())
+		g.Expect(cl.Create(context.Background(), job)).To(gomega.Succeed())
+		return job
+	}
+	newJob("1", 4*time.Hour, kbatch.JobComplete)
+	lastCompleted := newJob("2", 3*time.Hour, kbatch.JobComplete)
+	newJob("3", 2*time.Hour, kbatch.JobFailed)
+	lastFailed := newJob("4", time.Hour, kbatch.JobFailed)
+
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	status := batchTransfer.Status
+	g.Expect(status.Status).To(gomega.Equal(motionv1.Failed))
+	g.Expect(status.Active).To(gomega.BeNil())
+	g.Expect(status.LastFailed).ToNot(gomega.BeNil())
+	g.Expect(status.LastFailed.Name).To(gomega.Equal(lastFailed.Name))
+	g.Expect(status.LastCompleted).ToNot(gomega.BeNil())
+	g.Expect(status.LastCompleted.Name).To(gomega.Equal(lastCompleted.Name))
+	g.Expect(status.LastSuccessTime).ToNot(gomega.BeNil())
+	g.Expect(status.ConsecutiveFailures).To(gomega.Equal(2))
+	condition := meta.FindStatusCondition(status.Conditions, motionv1.FailedRetriesExceededCondition)
+	g.Expect(condition).ToNot(gomega.BeNil())
+	g.Expect(condition.Status).To(gomega.Equal(metav1.ConditionTrue))
+
+	// the history limits are left to the CronJob controller
+	jobs := &kbatch.JobList{}
+	g.Expect(cl.List(context.Background(), jobs, client.InNamespace(namespace))).To(gomega.Succeed())
+	g.Expect(jobs.Items).To(gomega.HaveLen(4))
+	g.Expect(*cronJob.Spec.SuccessfulJobsHistoryLimit).To(gomega.Equal(int32(1)))
+	g.Expect(*cronJob.Spec.FailedJobsHistoryLimit).To(gomega.Equal(int32(5)))
+
+	// a new run starts
+	running := newJob("5", time.Minute, "")
+	running.Status = kbatch.JobStatus{Active: 1}
+	g.Expect(cl.Update(context.Background(), running)).To(gomega.Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	g.Expect(batchTransfer.Status.Status).To(gomega.Equal(motionv1.Running))
+	g.Expect(batchTransfer.Status.Active).ToNot(gomega.BeNil())
+	g.Expect(batchTransfer.Status.Active.Name).To(gomega.Equal(running.Name))
+	g.Expect(batchTransfer.Status.ConsecutiveFailures).To(gomega.Equal(2))
+}
+
+// TestScheduledJobToBatchTransfer checks that only the Jobs spawned by the CronJob of a BatchTransfer are mapped to it
+func TestScheduledJobToBatchTransfer(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	controller := true
+	job := &kbatch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "scheduled-transfer-1234",
+			Namespace: "fybrik-system",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "batch/v1beta1",
+				Kind:       "CronJob",
+				Name:       "scheduled-transfer",
+				Controller: &controller,
+			}},
+		},
+	}
+	// a Job of a CronJob that is not owned by a BatchTransfer
+	g.Expect(scheduledJobToBatchTransfer(job)).To(gomega.BeEmpty())
+
+	job.Labels = map[string]string{batchTransferLabel: "scheduled-transfer"}
+	g.Expect(scheduledJobToBatchTransfer(job)).To(gomega.ConsistOf(reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: "scheduled-transfer", Namespace: "fybrik-system"},
+	}))
+}
+
+// TestBatchTransferSpecUpdates checks that spec changes are propagated to the configuration secret and the CronJob,
+// and that a finished one-shot BatchTransfer runs again when its rerun counter is incremented.
+func TestBatchTransferSpecUpdates(t *testing.T) {
+	t.Parallel()
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		name      = "updated-transfer"
+		namespace = "fybrik-system"
+	)
+	batchTransfer := &motionv1.BatchTransfer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 1,
+		},
+		Spec: motionv1.BatchTransferSpec{
+			Source: motionv1.DataStore{
+				Database: &motionv1.Database{
+					Db2URL:   "jdbc:db2://host:1234/DB",
+					Table:    "MY.TABLE",
+					User:     "user",
+					Password: "password",
+				},
+			},
+			Destination: motionv1.DataStore{
+				S3: &motionv1.S3{
+					Endpoint:   "my.endpoint",
+					Region:     "eu-gb",
+					Bucket:     "myBucket",
+					AccessKey:  "ab",
+					SecretKey:  "cd",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+		},
+	}
+
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, batchTransfer)
+	r := &BatchTransferReconciler{
+		Reconciler: Reconciler{
+			Client: cl,
+			Log:    ctrl.Log.WithName("test-controller"),
+			Scheme: s,
+		},
+	}
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// the job completes
+	job := &kbatch.Job{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, job)).To(gomega.Succeed())
+	job.Status.Conditions = []kbatch.JobCondition{{Type: kbatch.JobComplete, Status: corev1.ConditionTrue}}
+	g.Expect(cl.Update(context.Background(), job)).To(gomega.Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	g.Expect(batchTransfer.Status.Status).To(gomega.Equal(motionv1.Succeeded))
+	g.Expect(batchTransfer.Status.ObservedGeneration).To(gomega.Equal(int64(1)))
+
+	// the destination changes and another run is requested
+	batchTransfer.Spec.Destination.S3.ObjectKey = "updated.parq"
+	batchTransfer.Spec.Rerun = 1
+	batchTransfer.Generation = 2
+	g.Expect(cl.Update(context.Background(), batchTransfer)).To(gomega.Succeed())
+	res, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(res.RequeueAfter).ToNot(gomega.BeZero())
+
+	secret := &corev1.Secret{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, secret)).To(gomega.Succeed())
+	g.Expect(string(secret.Data["conf.json"])).To(gomega.ContainSubstring("updated.parq"))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	g.Expect(batchTransfer.Status.ObservedGeneration).To(gomega.Equal(int64(2)))
+	g.Expect(batchTransfer.Status.ObservedRerun).To(gomega.Equal(int64(1)))
+	g.Expect(batchTransfer.Status.LastCompleted).To(gomega.BeNil())
+
+	// a new job is created for the next run
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	job = &kbatch.Job{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, job)).To(gomega.Succeed())
+	finished, _ := isJobFinished(job)
+	g.Expect(finished).To(gomega.BeFalse())
+
+	// a scheduled BatchTransfer updates its CronJob in place
+	batchTransfer.Spec.Schedule = "0 * * * *"
+	scheduled := &motionv1.BatchTransfer{
+		ObjectMeta: metav1.ObjectMeta{Name: "scheduled-" + name, Namespace: namespace, Generation: 1},
+		Spec:       batchTransfer.Spec,
+	}
+	g.Expect(cl.Create(context.Background(), scheduled)).To(gomega.Succeed())
+	req.Name = scheduled.Name
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, scheduled)).To(gomega.Succeed())
+	scheduled.Spec.Schedule = "30 * * * *"
+	scheduled.Spec.Suspend = true
+	scheduled.Generation = 2
+	g.Expect(cl.Update(context.Background(), scheduled)).To(gomega.Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	cronJob := &v1beta1.CronJob{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, cronJob)).To(gomega.Succeed())
+	g.Expect(cronJob.Spec.Schedule).To(gomega.Equal("30 * * * *"))
+	g.Expect(*cronJob.Spec.Suspend).To(gomega.BeTrue())
+}
+
+// TestIncrementalBatchTransfer checks that the watermark reported by a transfer job is recorded in the status
+// and passed to the next run, and that a reset discards it.
+func TestIncrementalBatchTransfer(t *testing.T) {
+	t.Parallel()
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		name      = "incremental-transfer"
+		namespace = "fybrik-system"
+	)
+	batchTransfer := &motionv1.BatchTransfer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       name,
+			Namespace:  namespace,
+			Generation: 1,
+		},
+		Spec: motionv1.BatchTransferSpec{
+			Source: motionv1.DataStore{
+				Database: &motionv1.Database{
+					Db2URL:   "jdbc:db2://host:1234/DB",
+					Table:    "MY.TABLE",
+					User:     "user",
+					Password: "password",
+				},
+			},
+			Destination: motionv1.DataStore{
+				S3: &motionv1.S3{
+					Endpoint:   "my.endpoint",
+					Region:     "eu-gb",
+					Bucket:     "myBucket",
+					AccessKey:  "ab",
+					SecretKey:  "cd",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+			WriteOperation: motionv1.Append,
+			Incremental:    &motionv1.IncrementalTransfer{WatermarkColumn: "updated_at"},
+		},
+	}
+
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, batchTransfer)
+	r := &BatchTransferReconciler{
+		Reconciler: Reconciler{
+			Client: cl,
+			Log:    ctrl.Log.WithName("test-controller"),
+			Scheme: s,
+		},
+	}
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// the first run is a full snapshot
+	secret := &corev1.Secret{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, secret)).To(gomega.Succeed())
+	g.Expect(string(secret.Data["conf.json"])).ToNot(gomega.ContainSubstring("\"watermark\""))
+
+	// the job completes and reports a watermark
+	job := &kbatch.Job{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, job)).To(gomega.Succeed())
+	job.UID = "job-1"
+	job.Labels = map[string]string{"controller-uid": "job-1"}
+	job.Status.Conditions = []kbatch.JobCondition{{Type: kbatch.JobComplete, Status: corev1.ConditionTrue}}
+	g.Expect(cl.Update(context.Background(), job)).To(gomega.Succeed())
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-pod",
+			Namespace: namespace,
+			Labels:    map[string]string{"controller-uid": "job-1"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Message: `{"watermark":"2021-10-01T00:00:00Z"}`},
+				},
+			}},
+		},
+	}
+	g.Expect(cl.Create(context.Background(), pod)).To(gomega.Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	g.Expect(batchTransfer.Status.Watermark).ToNot(gomega.BeNil())
+	g.Expect(batchTransfer.Status.Watermark.Value).To(gomega.Equal("2021-10-01T00:00:00Z"))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, secret)).To(gomega.Succeed())
+	g.Expect(string(secret.Data["conf.json"])).To(gomega.ContainSubstring(`"watermark":"2021-10-01T00:00:00Z"`))
+
+	// a reset requests a full copy again
+	batchTransfer.Spec.Incremental.Reset = 1
+	batchTransfer.Generation = 2
+	g.Expect(cl.Update(context.Background(), batchTransfer)).To(gomega.Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	g.Expect(batchTransfer.Status.ObservedReset).To(gomega.Equal(int64(1)))
+	g.Expect(batchTransfer.Status.Watermark.Value).To(gomega.BeEmpty())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, secret)).To(gomega.Succeed())
+	g.Expect(string(secret.Data["conf.json"])).ToNot(gomega.ContainSubstring("\"watermark\""))
+}
+
+// TestScheduledIncrementalBatchTransfer checks that the runs of a scheduled incremental transfer do not overlap and
+// are only started once the watermark of the previous run is recorded in the configuration secret.
+func TestScheduledIncrementalBatchTransfer(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		name      = "scheduled-incremental-transfer"
+		namespace = "fybrik-system"
+	)
+	batchTransfer := &motionv1.BatchTransfer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: motionv1.BatchTransferSpec{
+			Source: motionv1.DataStore{
+				Database: &motionv1.Database{
+					Db2URL:   "jdbc:db2://host:1234/DB",
+					Table:    "MY.TABLE",
+					User:     "user",
+					Password: "password",
+				},
+			},
+			Destination: motionv1.DataStore{
+				S3: &motionv1.S3{
+					Endpoint:   "my.endpoint",
+					Region:     "eu-gb",
+					Bucket:     "myBucket",
+					AccessKey:  "ab",
+					SecretKey:  "cd",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+			Schedule:                  "0 * * * *",
+			SuccessfulJobHistoryLimit: 1,
+			FailedJobHistoryLimit:     1,
+			WriteOperation:            motionv1.Append,
+			Incremental:               &motionv1.IncrementalTransfer{WatermarkColumn: "updated_at"},
+		},
+	}
+
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, batchTransfer)
+	r := &BatchTransferReconciler{
+		Reconciler: Reconciler{
+			Client: cl,
+			Log:    ctrl.Log.WithName("test-controller"),
+			Scheme: s,
+		},
+	}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: namespace}}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	cronJob := &v1beta1.CronJob{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, cronJob)).To(gomega.Succeed())
+	g.Expect(cronJob.Spec.ConcurrencyPolicy).To(gomega.Equal(v1beta1.ForbidConcurrent))
+	g.Expect(*cronJob.Spec.JobTemplate.Spec.Suspend).To(gomega.BeTrue())
+
+	// the first run completed and reported a watermark, the CronJob created the next run
+	newJob := func(suffix string, age time.Duration) *kbatch.Job {
+		job := &kbatch.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name + "-" + suffix,
+				Namespace:         namespace,
+				UID:               types.UID(suffix),
+				Labels:            map[string]string{"controller-uid": suffix},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+			Spec: *cronJob.Spec.JobTemplate.Spec.DeepCopy(),
+		}
+		g.Expect(ctrl.SetControllerReference(cronJob, job, s)).To(gomega.Succeed())
+		return job
+	}
+	completed := newJob("1", 2*time.Hour)
+	completed.Status.Conditions = []kbatch.JobCondition{{Type: kbatch.JobComplete, Status: corev1.ConditionTrue}}
+	g.Expect(cl.Create(context.Background(), completed)).To(gomega.Succeed())
+	g.Expect(cl.Create(context.Background(), &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-1-pod",
+			Namespace: namespace,
+			Labels:    map[string]string{"controller-uid": "1"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Message: `{"watermark":"2021-10-01T00:00:00Z"}`},
+				},
+			}},
+		},
+	})).To(gomega.Succeed())
+	next := newJob("2", time.Hour)
+	g.Expect(cl.Create(context.Background(), next)).To(gomega.Succeed())
+
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// the next run is started with the recorded watermark
+	secret := &corev1.Secret{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, secret)).To(gomega.Succeed())
+	g.Expect(string(secret.Data["conf.json"])).To(gomega.ContainSubstring(`"watermark":"2021-10-01T00:00:00Z"`))
+	g.Expect(cl.Get(context.Background(), client.ObjectKeyFromObject(next), next)).To(gomega.Succeed())
+	g.Expect(*next.Spec.Suspend).To(gomega.BeFalse())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	condition := meta.FindStatusCondition(batchTransfer.Status.Conditions, motionv1.SuspendedRunsUnsupportedCondition)
+	g.Expect(condition).ToNot(gomega.BeNil())
+	g.Expect(condition.Status).To(gomega.Equal(metav1.ConditionFalse))
+
+	// a cluster without support for suspended jobs drops the field and the run starts right away
+	dropped := newJob("3", time.Minute)
+	dropped.Spec.Suspend = nil
+	g.Expect(cl.Create(context.Background(), dropped)).To(gomega.Succeed())
+
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	condition = meta.FindStatusCondition(batchTransfer.Status.Conditions, motionv1.SuspendedRunsUnsupportedCondition)
+	g.Expect(condition).ToNot(gomega.BeNil())
+	g.Expect(condition.Status).To(gomega.Equal(metav1.ConditionTrue))
+}
+
+// TestSparkApplicationBatchTransfer checks that a BatchTransfer with the SparkApplication backend is run as a
+// SparkApplication that is sized according to the Spark configuration, and that its state is mapped to the status.
+func TestSparkApplicationBatchTransfer(t *testing.T) {
+	t.Parallel()
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		name      = "spark-transfer"
+		namespace = "fybrik-system"
+	)
+	batchTransfer := &motionv1.BatchTransfer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: motionv1.BatchTransferSpec{
+			Source: motionv1.DataStore{
+				Database: &motionv1.Database{
+					Db2URL:   "jdbc:db2://host:1234/DB",
+					Table:    "MY.TABLE",
+					User:     "user",
+					Password: "password",
+				},
+			},
+			Destination: motionv1.DataStore{
+				S3: &motionv1.S3{
+					Endpoint:   "my.endpoint",
+					Region:     "eu-gb",
+					Bucket:     "myBucket",
+					AccessKey:  "ab",
+					SecretKey:  "cd",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+			Image:   "ghcr.io/fybrik/mover:latest",
+			Backend: motionv1.SparkApplicationBackend,
+			Spark: &motionv1.Spark{
+				DriverCores:       1,
+				NumExecutors:      4,
+				ExecutorMemory:    "4g",
+				ShufflePartitions: 20,
+				MainClass:         motionv1.DefaultSparkMainClass,
+				SparkVersion:      motionv1.DefaultSparkVersion,
+			},
+		},
+	}
+
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, batchTransfer)
+	r := &BatchTransferReconciler{
+		Reconciler: Reconciler{
+			Client: cl,
+			Log:    ctrl.Log.WithName("test-controller"),
+			Scheme: s,
+		},
+	}
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// no Job is created for the transfer
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, &kbatch.Job{})).ToNot(gomega.Succeed())
+
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(SparkApplicationGVK)
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, app)).To(gomega.Succeed())
+	g.Expect(app.GetOwnerReferences()).To(gomega.HaveLen(1))
+	instances, _, _ := unstructured.NestedInt64(app.Object, "spec", "executor", "instances")
+	g.Expect(instances).To(gomega.Equal(int64(4)))
+	memory, _, _ := unstructured.NestedString(app.Object, "spec", "executor", "memory")
+	g.Expect(memory).To(gomega.Equal("4g"))
+	mainClass, _, _ := unstructured.NestedString(app.Object, "spec", "mainClass")
+	g.Expect(mainClass).To(gomega.Equal(motionv1.DefaultSparkMainClass))
+	partitions, _, _ := unstructured.NestedString(app.Object, "spec", "sparkConf", "spark.sql.shuffle.partitions")
+	g.Expect(partitions).To(gomega.Equal("20"))
+
+	// the application completes and its driver reports the number of moved records
+	driver := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name + "-driver", Namespace: namespace},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name: "spark-kubernetes-driver",
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Message: `{"numRecords":42}`},
+				},
+			}},
+		},
+	}
+	g.Expect(cl.Create(context.Background(), driver)).To(gomega.Succeed())
+	g.Expect(unstructured.SetNestedField(app.Object, "COMPLETED", "status", "applicationState", "state")).To(gomega.Succeed())
+	g.Expect(unstructured.SetNestedField(app.Object, driver.Name, "status", "driverInfo", "podName")).To(gomega.Succeed())
+	g.Expect(unstructured.SetNestedField(app.Object, "2021-10-01T12:00:00Z", "status", "terminationTime")).To(gomega.Succeed())
+	g.Expect(cl.Update(context.Background(), app)).To(gomega.Succeed())
+
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	g.Expect(batchTransfer.Status.Status).To(gomega.Equal(motionv1.Succeeded))
+	g.Expect(batchTransfer.Status.LastCompleted).ToNot(gomega.BeNil())
+	g.Expect(batchTransfer.Status.LastCompleted.Kind).To(gomega.Equal("SparkApplication"))
+	g.Expect(batchTransfer.Status.NumRecords).To(gomega.Equal(int64(42)))
+	g.Expect(batchTransfer.Status.LastRecordTime).ToNot(gomega.BeNil())
+}
+
+// TestBatchTransferRunMetrics checks that the progress and the metrics reported by a run are recorded in the status
+// of the BatchTransfer and in the metrics of the manager.
+func TestBatchTransferRunMetrics(t *testing.T) {
+	t.Parallel()
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		name      = "metrics-transfer"
+		namespace = "fybrik-system"
+	)
+	batchTransfer := &motionv1.BatchTransfer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: motionv1.BatchTransferSpec{
+			Source: motionv1.DataStore{
+				Database: &motionv1.Database{
+					Db2URL:   "jdbc:db2://host:1234/DB",
+					Table:    "MY.TABLE",
+					User:     "user",
+					Password: "password",
+				},
+			},
+			Destination: motionv1.DataStore{
+				S3: &motionv1.S3{
+					Endpoint:   "my.endpoint",
+					Region:     "eu-gb",
+					Bucket:     "myBucket",
+					AccessKey:  "ab",
+					SecretKey:  "cd",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+			Transformation: []motionv1.Transformation{{Name: "filter-eu", Action: motionv1.FilterRows}},
+			NoFinalizer:    true,
+		},
+	}
+
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, batchTransfer)
+	r := &BatchTransferReconciler{
+		Reconciler: Reconciler{
+			Client: cl,
+			Log:    ctrl.Log.WithName("test-controller"),
+			Scheme: s,
+		},
+		ProgressInterval: 10 * time.Second,
+	}
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// the running job reports its progress in the result ConfigMap
+	job := &kbatch.Job{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, job)).To(gomega.Succeed())
+	job.UID = "job-1"
+	job.Labels = map[string]string{"controller-uid": "job-1"}
+	job.Status.Active = 1
+	g.Expect(cl.Update(context.Background(), job)).To(gomega.Succeed())
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string]string{ResultConfigMapKey: `{"recordsRead":500,"recordsWritten":400}`},
+	}
+	g.Expect(cl.Create(context.Background(), configMap)).To(gomega.Succeed())
+
+	res, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(res.RequeueAfter).To(gomega.Equal(10 * time.Second))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	g.Expect(batchTransfer.Status.Status).To(gomega.Equal(motionv1.Running))
+	g.Expect(batchTransfer.Status.Progress).ToNot(gomega.BeNil())
+	g.Expect(batchTransfer.Status.Progress.RecordsRead).To(gomega.Equal(int64(500)))
+	g.Expect(batchTransfer.Status.LastRunMetrics).To(gomega.BeNil())
+
+	// the job completes and reports its result in the termination message
+	job.Status.Active = 0
+	job.Status.Conditions = []kbatch.JobCondition{{Type: kbatch.JobComplete, Status: corev1.ConditionTrue}}
+	g.Expect(cl.Update(context.Background(), job)).To(gomega.Succeed())
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-pod",
+			Namespace: namespace,
+			Labels:    map[string]string{"controller-uid": "job-1"},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				State: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Message: `{"recordsRead":1000,"recordsWritten":800,` +
+						`"recordsFiltered":200,"bytesMoved":65536,"durationMillis":90000,` +
+						`"transformations":[{"name":"filter-eu","recordsAffected":200}]}`},
+				},
+			}},
+		},
+	}
+	g.Expect(cl.Create(context.Background(), pod)).To(gomega.Succeed())
+
+	// the metrics are not observed if the status update of the completed run conflicts
+	r.Client = &conflictingStatusClient{Client: cl, conflicts: 1}
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(kerrors.IsConflict(err)).To(gomega.BeTrue())
+	g.Expect(testutil.ToFloat64(transferRuns.WithLabelValues(namespace, name))).To(gomega.BeZero())
+
+	// the metrics of the run are recorded once
+	for i := 0; i < 2; i++ {
+		res, err = r.Reconcile(context.Background(), req)
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		g.Expect(res.RequeueAfter).To(gomega.BeZero())
+	}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, batchTransfer)).To(gomega.Succeed())
+	g.Expect(batchTransfer.Status.Status).To(gomega.Equal(motionv1.Succeeded))
+	g.Expect(batchTransfer.Status.Progress).To(gomega.BeNil())
+	g.Expect(batchTransfer.Status.NumRecords).To(gomega.Equal(int64(800)))
+	runMetrics := batchTransfer.Status.LastRunMetrics
+	g.Expect(runMetrics).ToNot(gomega.BeNil())
+	g.Expect(runMetrics.JobUID).To(gomega.Equal(types.UID("job-1")))
+	g.Expect(runMetrics.RecordsFiltered).To(gomega.Equal(int64(200)))
+	g.Expect(runMetrics.BytesMoved).To(gomega.Equal(int64(65536)))
+	g.Expect(runMetrics.Duration.Duration).To(gomega.Equal(90 * time.Second))
+	g.Expect(runMetrics.Transformations).To(gomega.ConsistOf(motionv1.TransformationMetrics{Name: "filter-eu", RecordsAffected: 200}))
+
+	g.Expect(testutil.ToFloat64(transferRuns.WithLabelValues(namespace, name))).To(gomega.Equal(1.0))
+	g.Expect(testutil.ToFloat64(transferRecordsWritten.WithLabelValues(namespace, name))).To(gomega.Equal(800.0))
+	g.Expect(testutil.ToFloat64(transferTransformationRecords.WithLabelValues(namespace, name, "filter-eu"))).To(gomega.Equal(200.0))
+
+	// the metrics are removed with the BatchTransfer
+	g.Expect(cl.Delete(context.Background(), batchTransfer)).To(gomega.Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(testutil.ToFloat64(transferRuns.WithLabelValues(namespace, name))).To(gomega.BeZero())
+}
+
+// TestTransformationPluginJob checks that the jars of transformation plugins are copied into the transfer pod
+// by init containers and that the plugins are passed to the data mover in the transfer configuration.
+func TestTransformationPluginJob(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(transformations.DefaultRegistry.Register(&transformations.Plugin{
+		ID:    "test-tokenize-ID",
+		Image: "registry.example.com/plugins/tokenize:1.0",
+		Jar:   "tokenize.jar",
+		Class: "com.example.transformations.Tokenize",
+	})).To(gomega.Succeed())
+
+	batchTransfer := &motionv1.BatchTransfer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "plugin-transfer",
+			Namespace: "fybrik-system",
+		},
+		Spec: motionv1.BatchTransferSpec{
+			Source: motionv1.DataStore{
+				S3: &motionv1.S3{Endpoint: "my.endpoint", Bucket: "source", ObjectKey: "in.parq", DataFormat: "parquet"},
+			},
+			Destination: motionv1.DataStore{
+				S3: &motionv1.S3{Endpoint: "my.endpoint", Bucket: "destination", ObjectKey: "out.parq", DataFormat: "parquet"},
+			},
+			Transformation: []motionv1.Transformation{
+				{Name: "redact", Action: motionv1.RedactColumns, Columns: []string{"name"}},
+				{Name: "tokenize", Plugin: "test-tokenize-ID", Columns: []string{"ssn"}},
+				{Name: "tokenize again", Plugin: "test-tokenize-ID", Columns: []string{"phone"}},
+			},
+			Image:           "ghcr.io/fybrik/mover:latest",
+			ImagePullPolicy: corev1.PullIfNotPresent,
+		},
+	}
+
+	s := utils.NewScheme(g)
+	r := &BatchTransferReconciler{
+		Reconciler: Reconciler{
+			Client: fake.NewFakeClientWithScheme(s),
+			Log:    ctrl.Log.WithName("test-controller"),
+			Scheme: s,
+		},
+	}
+	job, err := r.createSparkJob(batchTransfer)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	podSpec := job.Spec.Template.Spec
+	g.Expect(podSpec.InitContainers).To(gomega.HaveLen(1))
+	g.Expect(podSpec.InitContainers[0].Name).To(gomega.Equal("plugin-test-tokenize-id"))
+	g.Expect(podSpec.InitContainers[0].Image).To(gomega.Equal("registry.example.com/plugins/tokenize:1.0"))
+	g.Expect(podSpec.Containers[0].VolumeMounts).To(gomega.ContainElement(corev1.VolumeMount{
+		Name:      pluginsVolumeName,
+		MountPath: transformations.MountPath,
+		ReadOnly:  true,
+	}))
+
+	config := newTransferConfiguration(batchTransfer)
+	g.Expect(config.Plugins).To(gomega.Equal([]transferPlugin{{
+		ID:    "test-tokenize-ID",
+		Jar:   transformations.MountPath + "/tokenize.jar",
+		Class: "com.example.transformations.Tokenize",
+	}}))
+}
+
+// conflictingStatusClient fails the given number of status updates with a conflict
+type conflictingStatusClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingStatusClient) Status() client.StatusWriter {
+	return &conflictingStatusWriter{StatusWriter: c.Client.Status(), client: c}
+}
+
+type conflictingStatusWriter struct {
+	client.StatusWriter
+	client *conflictingStatusClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if w.client.conflicts > 0 {
+		w.client.conflicts--
+		return kerrors.NewConflict(motionv1.GroupVersion.WithResource("batchtransfers").GroupResource(), obj.GetName(),
+			errors.New("the object has been modified"))
+	}
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
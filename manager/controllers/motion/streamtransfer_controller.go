@@ -6,11 +6,14 @@ package motion
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"fybrik.io/fybrik/manager/controllers"
 	"fybrik.io/fybrik/pkg/environment"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/reference"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -22,15 +25,17 @@ import (
 // It is "derived" from the Reconciler object
 type StreamTransferReconciler struct {
 	Reconciler
+	// Interval in which the health of running streams is checked, polling is disabled if not positive
+	HealthInterval time.Duration
 }
 
 // Reconcile StreamTransfers
-// A first version of this StreamTransfer is based on K8s Deployment objects.
+// A StreamTransfer is based on K8s Deployment objects.
 // These manage pod failures themselves and restart them in case of failure.
-// Thus in this first version that does not handle errors yet a stream will be started
-// if it does not exist (including a persistent checkpoint storage) and otherwise be left running.
-// A more involved version that is handling errors and is discovering crash loops may have to be based
-// on a Pod directly in order to discover errors on a finer granular level.
+// A stream will be started if it does not exist (including a persistent checkpoint storage) and otherwise be left running.
+// A running stream periodically reports its lag, throughput and errors in a ConfigMap. A stream that exceeds the
+// health thresholds of its spec is failing and is restarted with an exponential backoff.
+// A suspended stream is scaled to zero replicas.
 func (reconciler *StreamTransferReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := reconciler.Log.WithValues("streamtransfer", req.NamespacedName)
 
@@ -38,6 +43,8 @@ func (reconciler *StreamTransferReconciler) Reconcile(ctx context.Context, req c
 	if err := reconciler.Get(ctx, req.NamespacedName, streamTransfer); err != nil {
 		if err.(*kerrors.StatusError).ErrStatus.Code != 404 {
 			log.Error(err, "Unable to fetch StreamTransfer")
+		} else {
+			forgetStreamMetrics(req.NamespacedName)
 		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
@@ -59,20 +66,28 @@ func (reconciler *StreamTransferReconciler) Reconcile(ctx context.Context, req c
 	}
 
 	// Reconcile status
+	var requeueAfter time.Duration
 	deployment := &apps.Deployment{}
 	if err := reconciler.Get(ctx, streamTransfer.ObjectKey(), deployment); err != nil {
 		if !kerrors.IsNotFound(err) {
 			log.Error(err, fmt.Sprintf("could not fetch deployment for StreamTransfer %s", streamTransfer.Name))
 			return ctrl.Result{}, nil
 		}
+		// The deployment is (re)created below
+		streamTransfer.Status.Active = nil
 	} else {
-		// Update state of the StreamJob depending on if the deployment is running or not.
-		if deployment.Status.AvailableReplicas == *(deployment.Spec.Replicas) {
-			streamTransfer.Status.Status = motionv1.StreamRunning
-		} else {
-			streamTransfer.Status.Status = motionv1.StreamStarting
+		deploymentRef, err := reference.GetReference(reconciler.Scheme, deployment)
+		if err != nil {
+			log.Error(err, "unable to make reference to deployment", "deployment", deployment.Name)
+		}
+		streamTransfer.Status.Active = deploymentRef
+
+		// Update state of the stream depending on the deployment and the health reported by the stream.
+		// The deployment is scaled according to the suspend flag and restarted if the stream is failing.
+		requeueAfter, err = reconciler.reconcileStreamHealth(ctx, streamTransfer, deployment)
+		if err != nil {
+			return ctrl.Result{}, err
 		}
-		// TODO more granular status for a failing/stopped stream
 
 		if err := reconciler.Status().Update(ctx, streamTransfer); err != nil {
 			log.Error(err, "unable to update streamTransfer status")
@@ -128,7 +143,7 @@ func (reconciler *StreamTransferReconciler) Reconcile(ctx context.Context, req c
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (reconciler *StreamTransferReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -144,11 +159,12 @@ func (reconciler *StreamTransferReconciler) SetupWithManager(mgr ctrl.Manager) e
 // NewStreamTransferReconciler creates a new reconciler for StreamTransfer resources
 func NewStreamTransferReconciler(mgr ctrl.Manager, name string) *StreamTransferReconciler {
 	return &StreamTransferReconciler{
-		Reconciler{
+		Reconciler: Reconciler{
 			Client: mgr.GetClient(),
 			Name:   name,
 			Log:    ctrl.Log.WithName("controllers").WithName(name),
 			Scheme: mgr.GetScheme(),
 		},
+		HealthInterval: environment.GetEnvAsDuration(controllers.StreamTransferHealthIntervalConfiguration, controllers.DefaultStreamTransferHealthInterval),
 	}
 }
@@ -0,0 +1,235 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package motion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// HealthConfigMapKey is the key of the health report in the ConfigMap that a stream periodically writes.
+// The ConfigMap has the name of the StreamTransfer. After a restart of the stream only reports with a timestamp are
+// considered, since reports without one cannot be told apart from those of the previous run.
+const HealthConfigMapKey = "health.json"
+
+// RestartedAtAnnotation is set on the pod template of the Deployment of a stream to restart the stream
+const RestartedAtAnnotation = "motion.fybrik.io/restartedAt"
+
+const (
+	// delay between the first and the second restart of a failing stream, doubled with every further restart
+	initialRestartBackoff = 10 * time.Second
+	// maximum delay between two restarts of a failing stream
+	maxRestartBackoff = 5 * time.Minute
+)
+
+// streamReport is the health report of a running stream
+type streamReport struct {
+	// Number of records that the stream lags behind the source, e.g. the consumer lag of a Kafka source
+	Lag *int64 `json:"lag,omitempty"`
+	// Number of records per second that the stream has moved recently
+	RecordsPerSecond float64 `json:"recordsPerSecond,omitempty"`
+	// Number of micro batches that have failed in a row
+	ConsecutiveErrors int32 `json:"consecutiveErrors,omitempty"`
+	// Error of the last failed micro batch
+	Error string `json:"error,omitempty"`
+	// Time of the report
+	Timestamp *metav1.Time `json:"timestamp,omitempty"`
+}
+
+// Returns the last health report of a stream or nil if the stream has not reported its health yet
+func (reconciler *StreamTransferReconciler) readStreamReport(ctx context.Context, streamTransfer *motionv1.StreamTransfer) *streamReport {
+	configMap := &v1.ConfigMap{}
+	if err := reconciler.Get(ctx, streamTransfer.ObjectKey(), configMap); err != nil {
+		if !kerrors.IsNotFound(err) {
+			reconciler.Log.V(1).Info("unable to fetch the health of the stream", "streamtransfer", streamTransfer.Name, "error", err.Error())
+		}
+		return nil
+	}
+	data := strings.TrimSpace(configMap.Data[HealthConfigMapKey])
+	if data == "" {
+		return nil
+	}
+	report := &streamReport{}
+	if err := json.Unmarshal([]byte(data), report); err != nil {
+		reconciler.Log.Info("could not read the health of the stream", "streamtransfer", streamTransfer.Name, "report", data)
+		return nil
+	}
+	return report
+}
+
+// Returns why a stream is failing according to its report and health thresholds, or an empty string if it is healthy
+func streamFailure(health *motionv1.StreamHealth, report *streamReport) string {
+	if health == nil {
+		return ""
+	}
+	if health.MaxErrors > 0 && report.ConsecutiveErrors >= health.MaxErrors {
+		message := fmt.Sprintf("%d micro batches failed in a row", report.ConsecutiveErrors)
+		if report.Error != "" {
+			message += ": " + report.Error
+		}
+		return message
+	}
+	if health.MaxLag > 0 && report.Lag != nil && *report.Lag > health.MaxLag {
+		return fmt.Sprintf("the stream lags %d records behind the source, exceeding the limit of %d records", *report.Lag, health.MaxLag)
+	}
+	return ""
+}
+
+// Returns the delay after the given number of restarts until a failing stream is restarted again
+func restartBackoff(restarts int32) time.Duration {
+	if restarts <= 0 {
+		return 0
+	}
+	backoff := float64(initialRestartBackoff) * math.Pow(2, float64(restarts-1))
+	if backoff > float64(maxRestartBackoff) {
+		return maxRestartBackoff
+	}
+	return time.Duration(backoff)
+}
+
+// Updates the status of a stream from its Deployment and its health report, scales the Deployment according to the
+// suspend flag and restarts a failing stream. Returns the delay after which the stream should be checked again.
+func (reconciler *StreamTransferReconciler) reconcileStreamHealth(ctx context.Context, streamTransfer *motionv1.StreamTransfer, deployment *apps.Deployment) (time.Duration, error) {
+	log := reconciler.Log.WithValues("streamtransfer", streamTransfer.Name)
+	status := &streamTransfer.Status
+
+	replicas := int32(1)
+	if streamTransfer.Spec.Suspend {
+		replicas = 0
+	}
+	if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != replicas {
+		deployment.Spec.Replicas = &replicas
+		if err := reconciler.Update(ctx, deployment); err != nil {
+			log.Error(err, "unable to scale the deployment of the stream")
+			return 0, err
+		}
+		log.V(1).Info("scaled the deployment of the stream", "replicas", replicas)
+	}
+
+	if streamTransfer.Spec.Suspend {
+		if deployment.Status.Replicas == 0 {
+			status.Status = motionv1.StreamStopped
+			status.Error = ""
+		}
+		status.Restarts = 0
+		return 0, nil
+	}
+
+	if deployment.Status.AvailableReplicas < replicas {
+		status.Status = motionv1.StreamStarting
+	} else {
+		status.Status = motionv1.StreamRunning
+	}
+
+	// Reports written before the last restart describe the previous run of the stream and are ignored. Once the
+	// stream has been restarted, reports without a timestamp cannot be told apart from those of the previous run
+	// and are ignored as well, as they would otherwise restart the stream again and again.
+	report := reconciler.readStreamReport(ctx, streamTransfer)
+	if report != nil && (status.LastRestartTime == nil || (report.Timestamp != nil && !report.Timestamp.Before(status.LastRestartTime))) {
+		status.Lag = report.Lag
+		status.RecordsPerSecond = int64(math.Round(report.RecordsPerSecond))
+		if report.Timestamp != nil {
+			status.LastReportTime = report.Timestamp
+		} else {
+			now := metav1.Now()
+			status.LastReportTime = &now
+		}
+		observeStreamReport(streamTransfer, report)
+
+		if failure := streamFailure(streamTransfer.Spec.Health, report); failure != "" {
+			status.Status = motionv1.StreamFailing
+			status.Error = failure
+		} else {
+			status.Error = ""
+		}
+	}
+
+	if status.Status == motionv1.StreamFailing {
+		return reconciler.restartStream(ctx, streamTransfer, deployment)
+	}
+
+	// A stream that has been running long enough after its last restart is considered recovered
+	if status.Status == motionv1.StreamRunning && status.Restarts > 0 && status.LastRestartTime != nil &&
+		time.Since(status.LastRestartTime.Time) > 2*maxRestartBackoff {
+		status.Restarts = 0
+	}
+	return reconciler.HealthInterval, nil
+}
+
+// Restarts a failing stream by changing the pod template of its Deployment, unless the stream has already been
+// restarted the maximum number of times in a row or the backoff since the last restart has not passed yet.
+func (reconciler *StreamTransferReconciler) restartStream(ctx context.Context, streamTransfer *motionv1.StreamTransfer, deployment *apps.Deployment) (time.Duration, error) {
+	status := &streamTransfer.Status
+	health := streamTransfer.Spec.Health
+	if health == nil || status.Restarts >= health.MaxRestarts {
+		return reconciler.HealthInterval, nil
+	}
+	if status.LastRestartTime != nil {
+		if wait := time.Until(status.LastRestartTime.Add(restartBackoff(status.Restarts))); wait > 0 {
+			return wait, nil
+		}
+	}
+
+	now := metav1.Now()
+	if deployment.Spec.Template.Annotations == nil {
+		deployment.Spec.Template.Annotations = map[string]string{}
+	}
+	deployment.Spec.Template.Annotations[RestartedAtAnnotation] = now.Format(time.RFC3339)
+	if err := reconciler.Update(ctx, deployment); err != nil {
+		reconciler.Log.Error(err, "unable to restart the stream", "streamtransfer", streamTransfer.Name)
+		return 0, err
+	}
+	reconciler.Log.Info("restarted the failing stream", "streamtransfer", streamTransfer.Name, "error", status.Error)
+	status.Restarts++
+	status.LastRestartTime = &now
+	streamRestarts.WithLabelValues(streamTransfer.Namespace, streamTransfer.Name).Inc()
+	return restartBackoff(status.Restarts), nil
+}
+
+var (
+	streamLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fybrik_streamtransfer_lag_records",
+		Help: "Number of records that a StreamTransfer lags behind its source",
+	}, []string{"namespace", "streamtransfer"})
+	streamThroughput = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "fybrik_streamtransfer_records_per_second",
+		Help: "Number of records per second that a StreamTransfer has recently moved",
+	}, []string{"namespace", "streamtransfer"})
+	streamRestarts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_streamtransfer_restarts_total",
+		Help: "Number of times that a failing StreamTransfer has been restarted",
+	}, []string{"namespace", "streamtransfer"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(streamLag, streamThroughput, streamRestarts)
+}
+
+// Exposes the health report of a stream in the metrics of the manager
+func observeStreamReport(streamTransfer *motionv1.StreamTransfer, report *streamReport) {
+	if report.Lag != nil {
+		streamLag.WithLabelValues(streamTransfer.Namespace, streamTransfer.Name).Set(float64(*report.Lag))
+	}
+	streamThroughput.WithLabelValues(streamTransfer.Namespace, streamTransfer.Name).Set(report.RecordsPerSecond)
+}
+
+// Removes the metrics of a StreamTransfer that has been deleted
+func forgetStreamMetrics(key types.NamespacedName) {
+	streamLag.DeleteLabelValues(key.Namespace, key.Name)
+	streamThroughput.DeleteLabelValues(key.Namespace, key.Name)
+	streamRestarts.DeleteLabelValues(key.Namespace, key.Name)
+}
@@ -0,0 +1,164 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package motion
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
+	"fybrik.io/fybrik/manager/controllers/utils"
+	"github.com/onsi/gomega"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestStreamTransferHealth checks that the lag reported by a stream is recorded in the status, that a stream
+// exceeding the maximum lag is failing and restarted with a backoff, and that a suspended stream is scaled to zero.
+func TestStreamTransferHealth(t *testing.T) {
+	t.Parallel()
+	// Set the logger to development mode for verbose logs.
+	logf.SetLogger(zap.New(zap.UseDevMode(true)))
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		name      = "health-stream"
+		namespace = "fybrik-system"
+	)
+	streamTransfer := &motionv1.StreamTransfer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: motionv1.StreamTransferSpec{
+			Source: motionv1.DataStore{
+				Kafka: &motionv1.Kafka{
+					KafkaBrokers:      "broker:9092",
+					SchemaRegistryURL: "http://registry:8081/ccompat",
+					KafkaTopic:        "topic",
+					DataFormat:        "avro",
+				},
+			},
+			Destination: motionv1.DataStore{
+				S3: &motionv1.S3{
+					Endpoint:   "my.endpoint",
+					Bucket:     "myBucket",
+					ObjectKey:  "obj.parq",
+					DataFormat: "parquet",
+				},
+			},
+			Health:      &motionv1.StreamHealth{MaxLag: 1000, MaxRestarts: 2},
+			NoFinalizer: true,
+		},
+	}
+
+	s := utils.NewScheme(g)
+	cl := fake.NewFakeClientWithScheme(s, streamTransfer)
+	r := &StreamTransferReconciler{
+		Reconciler: Reconciler{
+			Client: cl,
+			Log:    ctrl.Log.WithName("test-controller"),
+			Scheme: s,
+		},
+		HealthInterval: 30 * time.Second,
+	}
+	req := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	// the stream runs and reports a lag within the limit
+	deployment := &apps.Deployment{}
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, deployment)).To(gomega.Succeed())
+	deployment.Status.Replicas = 1
+	deployment.Status.AvailableReplicas = 1
+	g.Expect(cl.Update(context.Background(), deployment)).To(gomega.Succeed())
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Data:       map[string]string{HealthConfigMapKey: `{"lag":100,"recordsPerSecond":250.4}`},
+	}
+	g.Expect(cl.Create(context.Background(), configMap)).To(gomega.Succeed())
+
+	res, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(res.RequeueAfter).To(gomega.Equal(30 * time.Second))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, streamTransfer)).To(gomega.Succeed())
+	g.Expect(streamTransfer.Status.Status).To(gomega.Equal(motionv1.StreamRunning))
+	g.Expect(streamTransfer.Status.Active).ToNot(gomega.BeNil())
+	g.Expect(*streamTransfer.Status.Lag).To(gomega.Equal(int64(100)))
+	g.Expect(streamTransfer.Status.RecordsPerSecond).To(gomega.Equal(int64(250)))
+
+	// the stream falls behind and is restarted
+	configMap.Data[HealthConfigMapKey] = `{"lag":5000,"recordsPerSecond":10}`
+	g.Expect(cl.Update(context.Background(), configMap)).To(gomega.Succeed())
+	res, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(res.RequeueAfter).To(gomega.Equal(initialRestartBackoff))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, streamTransfer)).To(gomega.Succeed())
+	g.Expect(streamTransfer.Status.Status).To(gomega.Equal(motionv1.StreamFailing))
+	g.Expect(streamTransfer.Status.Error).To(gomega.ContainSubstring("5000 records"))
+	g.Expect(streamTransfer.Status.Restarts).To(gomega.Equal(int32(1)))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, deployment)).To(gomega.Succeed())
+	g.Expect(deployment.Spec.Template.Annotations).To(gomega.HaveKey(RestartedAtAnnotation))
+
+	// the report of the previous run has no timestamp and does not restart the stream again
+	res, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(res.RequeueAfter).To(gomega.Equal(30 * time.Second))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, streamTransfer)).To(gomega.Succeed())
+	g.Expect(streamTransfer.Status.Status).To(gomega.Equal(motionv1.StreamRunning))
+	g.Expect(streamTransfer.Status.Restarts).To(gomega.Equal(int32(1)))
+
+	// the restarted stream still falls behind but is not restarted again before the backoff has passed
+	configMap.Data[HealthConfigMapKey] = `{"lag":5000,"recordsPerSecond":10,"timestamp":"` +
+		time.Now().Add(time.Second).UTC().Format(time.RFC3339) + `"}`
+	g.Expect(cl.Update(context.Background(), configMap)).To(gomega.Succeed())
+	res, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(res.RequeueAfter).To(gomega.BeNumerically(">", 0))
+	g.Expect(res.RequeueAfter).To(gomega.BeNumerically("<=", initialRestartBackoff))
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, streamTransfer)).To(gomega.Succeed())
+	g.Expect(streamTransfer.Status.Status).To(gomega.Equal(motionv1.StreamFailing))
+	g.Expect(streamTransfer.Status.Restarts).To(gomega.Equal(int32(1)))
+
+	// a suspended stream is scaled to zero
+	streamTransfer.Spec.Suspend = true
+	g.Expect(cl.Update(context.Background(), streamTransfer)).To(gomega.Succeed())
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, deployment)).To(gomega.Succeed())
+	g.Expect(*deployment.Spec.Replicas).To(gomega.Equal(int32(0)))
+
+	deployment.Status.Replicas = 0
+	deployment.Status.AvailableReplicas = 0
+	g.Expect(cl.Update(context.Background(), deployment)).To(gomega.Succeed())
+	res, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(res.RequeueAfter).To(gomega.BeZero())
+	g.Expect(cl.Get(context.Background(), req.NamespacedName, streamTransfer)).To(gomega.Succeed())
+	g.Expect(streamTransfer.Status.Status).To(gomega.Equal(motionv1.StreamStopped))
+	g.Expect(streamTransfer.Status.Restarts).To(gomega.BeZero())
+}
+
+func TestRestartBackoff(t *testing.T) {
+	t.Parallel()
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(restartBackoff(0)).To(gomega.BeZero())
+	g.Expect(restartBackoff(1)).To(gomega.Equal(10 * time.Second))
+	g.Expect(restartBackoff(3)).To(gomega.Equal(40 * time.Second))
+	g.Expect(restartBackoff(20)).To(gomega.Equal(maxRestartBackoff))
+}
@@ -16,6 +16,9 @@ const (
 	CatalogConnectorServiceAddressKey string = "CATALOG_CONNECTOR_URL"
 	VaultAddressKey                   string = "VAULT_ADDRESS"
 	VaultModulesRole                  string = "VAULT_MODULES_ROLE"
+	VaultScopedPoliciesKey            string = "VAULT_SCOPED_POLICIES"
+	VaultPolicyTTLKey                 string = "VAULT_POLICY_TTL"
+	VaultTokenKey                     string = "VAULT_TOKEN"
 )
 
 // GetSystemNamespace returns the namespace of control plane
@@ -33,6 +36,17 @@ func GetModulesRole() string {
 	return os.Getenv(VaultModulesRole)
 }
 
+// IsVaultScopedPoliciesEnabled returns true if every application gets a Vault role and policy of its own
+// that restrict its modules to the credentials of its datasets
+func IsVaultScopedPoliciesEnabled() bool {
+	return os.Getenv(VaultScopedPoliciesKey) == "true"
+}
+
+// GetVaultPolicyTTL returns the TTL of the Vault tokens that modules obtain with the role of their application
+func GetVaultPolicyTTL() string {
+	return os.Getenv(VaultPolicyTTLKey)
+}
+
 // GetVaultAddress returns the address and port of the vault system,
 // which is used for managing data set credentials
 func GetVaultAddress() string {
@@ -8,6 +8,7 @@ import (
 	motionv1 "fybrik.io/fybrik/manager/apis/motion/v1alpha1"
 	"github.com/onsi/gomega"
 	kbatch "k8s.io/api/batch/v1"
+	kbatchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -26,6 +27,10 @@ func NewScheme(g *gomega.WithT) *runtime.Scheme {
 	if g != nil {
 		g.Expect(err).NotTo(gomega.HaveOccurred())
 	}
+	err = kbatchv1beta1.AddToScheme(s)
+	if g != nil {
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
 	err = app.AddToScheme(s)
 	if g != nil {
 		g.Expect(err).NotTo(gomega.HaveOccurred())
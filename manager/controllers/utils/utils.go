@@ -13,6 +13,7 @@ import (
 	dc "fybrik.io/fybrik/pkg/connectors/protobuf"
 	"runtime"
 	"sort"
+	"sync"
 )
 
 // GetProtocol returns the existing data protocol
@@ -24,6 +25,10 @@ func GetProtocol(info *dc.DatasetDetails) (string, error) {
 		return app.Kafka, nil
 	case dc.DataStore_DB2:
 		return app.JdbcDb2, nil
+	case dc.DataStore_JDBC:
+		return app.Jdbc, nil
+	case dc.DataStore_LOCAL:
+		return app.File, nil
 	}
 	return "", errors.New("unknown protocol")
 }
@@ -88,7 +93,11 @@ func CreateDataSetIdentifier(datasetID string) string {
 }
 
 // Generating release name based on blueprint module
+// A cluster scoped module is shared by all the applications, thus its release name does not depend on the application
 func GetReleaseName(applicationName string, namespace string, blueprintModule app.BlueprintModule) string {
+	if blueprintModule.Scope == app.Cluster {
+		return HelmConformName(blueprintModule.InstanceName)
+	}
 	return GetReleaseNameByStepName(applicationName, namespace, blueprintModule.InstanceName)
 }
 
@@ -169,3 +178,36 @@ func GetModuleCapabilities(module *app.FybrikModule, requestedCapability app.Cap
 	}
 	return capFound, capList
 }
+
+// RunConcurrently calls f for every index in [0, n), running at most limit calls at the same time
+func RunConcurrently(n int, limit int, f func(i int)) {
+	if limit < 1 {
+		limit = 1
+	}
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// GetCapabilityScope returns the scope of the given module capability
+// If the module does not declare the capability, e.g. a dependency, the scope of its first capability is used
+// If not indicated, the scope is assumed to be asset
+func GetCapabilityScope(module *app.FybrikModule, requestedCapability app.CapabilityType) app.CapabilityScope {
+	capabilities := module.Spec.Capabilities
+	if hasCapability, caps := GetModuleCapabilities(module, requestedCapability); hasCapability {
+		capabilities = caps
+	}
+	if len(capabilities) == 0 || capabilities[0].Scope == "" {
+		return app.Asset
+	}
+	return capabilities[0].Scope
+}
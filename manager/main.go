@@ -8,6 +8,7 @@ import (
 	"fybrik.io/fybrik/manager/controllers"
 	"fybrik.io/fybrik/pkg/environment"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -17,9 +18,14 @@ import (
 
 	connectors "fybrik.io/fybrik/pkg/connectors/clients"
 	"fybrik.io/fybrik/pkg/multicluster"
+	"fybrik.io/fybrik/pkg/multicluster/kubeconfig"
 	"fybrik.io/fybrik/pkg/multicluster/local"
 	"fybrik.io/fybrik/pkg/multicluster/razee"
+	"fybrik.io/fybrik/pkg/secrets"
 	"fybrik.io/fybrik/pkg/storage"
+	"fybrik.io/fybrik/pkg/taxonomy/validate"
+	"fybrik.io/fybrik/pkg/transformations"
+	"fybrik.io/fybrik/pkg/vault"
 
 	"fybrik.io/fybrik/manager/controllers/motion"
 
@@ -94,6 +100,15 @@ func run(namespace string, metricsAddr string, enableLeaderElection bool,
 		return 1
 	}
 
+	// Register the transformation plugins of the data movers
+	if pluginsDir := os.Getenv(transformations.PluginsDirEnv); pluginsDir != "" {
+		if err := transformations.DefaultRegistry.LoadDirectory(pluginsDir); err != nil {
+			setupLog.Error(err, "unable to load transformation plugins", "directory", pluginsDir)
+			return 1
+		}
+		setupLog.Info("loaded transformation plugins", "plugins", transformations.DefaultRegistry.IDs())
+	}
+
 	// Initialize ClusterManager
 	setupLog.Info("creating cluster manager")
 	var clusterManager multicluster.ClusterManager
@@ -134,15 +149,41 @@ func run(namespace string, metricsAddr string, enableLeaderElection bool,
 
 		// Initiate the FybrikApplication Controller
 		applicationController := app.NewFybrikApplicationReconciler(mgr, "FybrikApplication", policyManager, catalog, clusterManager, storage.NewProvisionImpl(mgr.GetClient()))
+		secretProvider, err := secrets.NewProvider(os.Getenv(secrets.ProviderEnv), utils.GetVaultAddress(), utils.GetModulesRole())
+		if err != nil {
+			setupLog.Error(err, "unable to create secret provider", "controller", "FybrikApplication")
+			return 1
+		}
+		applicationController.SecretProvider = secretProvider
+		if utils.IsVaultScopedPoliciesEnabled() {
+			vaultConnection, err := vault.InitConnection(utils.GetVaultAddress(), os.Getenv(utils.VaultTokenKey))
+			if err != nil {
+				setupLog.Error(err, "unable to connect to vault", "controller", "FybrikApplication")
+				return 1
+			}
+			applicationController.VaultConnection = vaultConnection
+			applicationController.VaultPolicyTTL = utils.GetVaultPolicyTTL()
+		}
 		if err := applicationController.SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "FybrikApplication")
 			return 1
 		}
 		if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+			// fail fast instead of rejecting every admission request if the taxonomy is missing or invalid
+			for _, schema := range []string{"fybrik_application.json", "fybrik_module.json"} {
+				if err := validate.DefaultValidator.Load(filepath.Join(controllers.TaxonomyDirectory, schema)); err != nil {
+					setupLog.Error(err, "unable to load taxonomy", "schema", schema)
+					return 1
+				}
+			}
 			if err := (&appv1.FybrikApplication{}).SetupWebhookWithManager(mgr); err != nil {
 				setupLog.Error(err, "unable to create webhook", "webhook", "FybrikApplication")
 				return 1
 			}
+			if err := (&appv1.FybrikModule{}).SetupWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "FybrikModule")
+				return 1
+			}
 		}
 	}
 
@@ -241,6 +282,17 @@ func newDataCatalog() (connectors.DataCatalog, error) {
 	if err != nil {
 		return nil, err
 	}
+	validationMode, err := getConnectorsValidationMode()
+	if err != nil {
+		return nil, err
+	}
+	if validationMode != validate.Disabled {
+		connector = connectors.NewValidatingDataCatalog(connector,
+			filepath.Join(controllers.TaxonomyDirectory, "catalog_dataset_details.json"), validationMode)
+	}
+	if cacheTTL := getConnectorsCacheTTL(); cacheTTL > 0 {
+		return connectors.NewCachingDataCatalog(connector, cacheTTL), nil
+	}
 	return connector, nil
 }
 
@@ -260,8 +312,21 @@ func newPolicyManager() (connectors.PolicyManager, error) {
 	} else {
 		policyManager, err = connectors.NewGrpcPolicyManager(mainPolicyManagerName, mainPolicyManagerURL, connectionTimeout)
 	}
-
-	return policyManager, err
+	if err != nil {
+		return nil, err
+	}
+	validationMode, err := getConnectorsValidationMode()
+	if err != nil {
+		return nil, err
+	}
+	if validationMode != validate.Disabled {
+		policyManager = connectors.NewValidatingPolicyManager(policyManager,
+			filepath.Join(controllers.TaxonomyDirectory, "policy_manager_response.json"), validationMode)
+	}
+	if cacheTTL := getConnectorsCacheTTL(); cacheTTL > 0 {
+		return connectors.NewCachingPolicyManager(policyManager, cacheTTL), nil
+	}
+	return policyManager, nil
 }
 
 // newClusterManager decides based on the environment variables that are set which
@@ -282,12 +347,29 @@ func newClusterManager(mgr manager.Manager) (multicluster.ClusterManager, error)
 
 		razeeURL := strings.TrimSpace(os.Getenv("RAZEE_URL"))
 		return razee.NewRazeeOAuthManager(strings.TrimSpace(razeeURL), strings.TrimSpace(apiKey), multiClusterGroup)
+	} else if os.Getenv("MULTICLUSTER_KUBECONFIG_SECRETS") == "true" {
+		setupLog.Info("Using kubeconfig secrets of member clusters")
+		// Secrets of the system namespace are not in the cache of the manager
+		return kubeconfig.NewManager(mgr.GetAPIReader(), utils.GetSystemNamespace())
 	} else {
 		setupLog.Info("Using local cluster manager")
 		return local.NewManager(mgr.GetClient(), utils.GetSystemNamespace())
 	}
 }
 
+func getConnectorsCacheTTL() time.Duration {
+	cacheTTL := environment.GetEnvAsDuration(controllers.ConnectorsCacheTTLConfiguration, controllers.DefaultConnectorsCacheTTL)
+	setupLog.Info("setting connectors cache", "TTL", cacheTTL)
+	return cacheTTL
+}
+
+// getConnectorsValidationMode returns how catalog and policy manager responses that do not conform to the taxonomy are handled
+func getConnectorsValidationMode() (validate.Mode, error) {
+	validationMode, err := validate.ParseMode(os.Getenv(controllers.ConnectorsTaxonomyValidationConfiguration))
+	setupLog.Info("setting connectors taxonomy validation", "Mode", validationMode)
+	return validationMode, err
+}
+
 func getConnectionTimeout() (time.Duration, error) {
 	connectionTimeout := os.Getenv("CONNECTION_TIMEOUT")
 	timeOutInSeconds, err := strconv.Atoi(connectionTimeout)
@@ -0,0 +1,293 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	openapiclientmodels "fybrik.io/fybrik/pkg/taxonomy/model/base"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// CacheTTLHint is the key of a hint that a connector may return in a response to override the cache expiration of
+// the response. The value is a duration string (e.g. "30s"), a non-positive duration disables caching of the response.
+// Catalog connectors return the hint in the dataset named metadata, policy managers in the properties of the actions
+// or, for batch decisions, in the arguments of the enforcement actions.
+const CacheTTLHint = "fybrik.io/cache-ttl"
+
+// CacheInvalidator is implemented by connector facades that cache responses
+type CacheInvalidator interface {
+	// Invalidate removes the cached responses related to the given dataset
+	Invalidate(datasetID string)
+	// InvalidateAll removes all cached responses
+	InvalidateAll()
+}
+
+var (
+	cacheHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_connector_cache_hits_total",
+		Help: "Number of connector requests served from the cache",
+	}, []string{"connector"})
+	cacheMisses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_connector_cache_misses_total",
+		Help: "Number of connector requests not found in the cache",
+	}, []string{"connector"})
+	cacheInvalidations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "fybrik_connector_cache_invalidations_total",
+		Help: "Number of cached connector responses removed by an explicit invalidation",
+	}, []string{"connector"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(cacheHits, cacheMisses, cacheInvalidations)
+}
+
+type cacheEntry struct {
+	value      interface{}
+	datasetIDs []string
+	expiration time.Time
+}
+
+// ttlCache is a thread safe cache whose entries expire after a time to live
+type ttlCache struct {
+	mutex     sync.Mutex
+	connector string
+	ttl       time.Duration
+	entries   map[string]cacheEntry
+	now       func() time.Time
+}
+
+func newTTLCache(connector string, ttl time.Duration) *ttlCache {
+	return &ttlCache{
+		connector: connector,
+		ttl:       ttl,
+		entries:   map[string]cacheEntry{},
+		now:       time.Now,
+	}
+}
+
+func (c *ttlCache) get(key string) (interface{}, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, found := c.entries[key]
+	if found && c.now().Before(entry.expiration) {
+		cacheHits.WithLabelValues(c.connector).Inc()
+		return entry.value, true
+	}
+	if found {
+		delete(c.entries, key)
+	}
+	cacheMisses.WithLabelValues(c.connector).Inc()
+	return nil, false
+}
+
+// set stores a value related to the given datasets.
+// A cache hint, if provided and valid, overrides the default time to live.
+func (c *ttlCache) set(key string, value interface{}, hint string, datasetIDs ...string) {
+	ttl := c.ttl
+	if hint != "" {
+		if hinted, err := time.ParseDuration(hint); err == nil {
+			ttl = hinted
+		}
+	}
+	if ttl <= 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	// remove expired entries so that the cache does not grow unbounded
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiration) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{value: value, datasetIDs: datasetIDs, expiration: now.Add(ttl)}
+}
+
+func (c *ttlCache) invalidate(datasetID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, entry := range c.entries {
+		for _, id := range entry.datasetIDs {
+			if id == datasetID {
+				delete(c.entries, key)
+				cacheInvalidations.WithLabelValues(c.connector).Inc()
+				break
+			}
+		}
+	}
+}
+
+func (c *ttlCache) invalidateAll() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	cacheInvalidations.WithLabelValues(c.connector).Add(float64(len(c.entries)))
+	c.entries = map[string]cacheEntry{}
+}
+
+// Ensure that cachingDataCatalog implements the DataCatalog and CacheInvalidator interfaces
+var _ DataCatalog = (*cachingDataCatalog)(nil)
+var _ CacheInvalidator = (*cachingDataCatalog)(nil)
+
+type cachingDataCatalog struct {
+	DataCatalog
+	cache *ttlCache
+}
+
+// NewCachingDataCatalog creates a DataCatalog facade that caches the dataset information returned by the given catalog.
+// Responses expire after the given time to live unless the catalog returns a cache hint.
+// A non-positive time to live disables caching of responses that do not carry a cache hint.
+func NewCachingDataCatalog(catalog DataCatalog, ttl time.Duration) DataCatalog {
+	return &cachingDataCatalog{
+		DataCatalog: catalog,
+		cache:       newTTLCache("catalog", ttl),
+	}
+}
+
+func (c *cachingDataCatalog) GetDatasetInfo(ctx context.Context, in *pb.CatalogDatasetRequest) (*pb.CatalogDatasetInfo, error) {
+	key := in.GetCredentialPath() + "/" + in.GetDatasetId()
+	if value, found := c.cache.get(key); found {
+		return value.(*pb.CatalogDatasetInfo), nil
+	}
+	response, err := c.DataCatalog.GetDatasetInfo(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	hint := response.GetDetails().GetMetadata().GetDatasetNamedMetadata()[CacheTTLHint]
+	c.cache.set(key, response, hint, in.GetDatasetId())
+	return response, nil
+}
+
+func (c *cachingDataCatalog) Invalidate(datasetID string) {
+	c.cache.invalidate(datasetID)
+}
+
+func (c *cachingDataCatalog) InvalidateAll() {
+	c.cache.invalidateAll()
+}
+
+// Ensure that cachingPolicyManager implements the PolicyManager and CacheInvalidator interfaces
+var _ PolicyManager = (*cachingPolicyManager)(nil)
+var _ CacheInvalidator = (*cachingPolicyManager)(nil)
+
+type cachingPolicyManager struct {
+	PolicyManager
+	cache *ttlCache
+}
+
+// cachingBatchPolicyManager additionally caches batch decisions of policy managers that support them
+type cachingBatchPolicyManager struct {
+	*cachingPolicyManager
+	batch BatchPolicyManager
+}
+
+// NewCachingPolicyManager creates a PolicyManager facade that caches the decisions returned by the given policy manager.
+// Responses expire after the given time to live unless the policy manager returns a cache hint.
+// A non-positive time to live disables caching of responses that do not carry a cache hint.
+// The returned facade supports batch decisions if the given policy manager does.
+func NewCachingPolicyManager(policyManager PolicyManager, ttl time.Duration) PolicyManager {
+	manager := &cachingPolicyManager{
+		PolicyManager: policyManager,
+		cache:         newTTLCache("policymanager", ttl),
+	}
+	if batch, ok := policyManager.(BatchPolicyManager); ok {
+		return &cachingBatchPolicyManager{cachingPolicyManager: manager, batch: batch}
+	}
+	return manager
+}
+
+func (m *cachingPolicyManager) GetPoliciesDecisions(in *openapiclientmodels.PolicyManagerRequest, creds string) (*openapiclientmodels.PolicyManagerResponse, error) {
+	request, err := json.Marshal(in)
+	if err != nil {
+		return m.PolicyManager.GetPoliciesDecisions(in, creds)
+	}
+	key := creds + "/" + string(request)
+	if value, found := m.cache.get(key); found {
+		return value.(*openapiclientmodels.PolicyManagerResponse), nil
+	}
+	response, err := m.PolicyManager.GetPoliciesDecisions(in, creds)
+	if err != nil {
+		return nil, err
+	}
+	m.cache.set(key, response, responseCacheHint(response), in.Resource.Name)
+	return response, nil
+}
+
+// responseCacheHint returns the shortest cache hint of the actions in the response
+func responseCacheHint(response *openapiclientmodels.PolicyManagerResponse) string {
+	hints := []string{}
+	for _, item := range response.Result {
+		if value, ok := item.Action.AdditionalProperties[CacheTTLHint].(string); ok {
+			hints = append(hints, value)
+		}
+	}
+	return shortestCacheHint(hints)
+}
+
+// batchCacheHint returns the shortest cache hint of the enforcement actions for all the datasets in the response
+func batchCacheHint(response *pb.PoliciesDecisions) string {
+	hints := []string{}
+	for _, datasetDecision := range response.GetDatasetDecisions() {
+		for _, decision := range datasetDecision.GetDecisions() {
+			for _, action := range decision.GetEnforcementActions() {
+				if value, ok := action.GetArgs()[CacheTTLHint]; ok {
+					hints = append(hints, value)
+				}
+			}
+		}
+	}
+	return shortestCacheHint(hints)
+}
+
+// shortestCacheHint returns the hint with the shortest duration, ignoring hints that are not valid durations
+func shortestCacheHint(hints []string) string {
+	hint := ""
+	var shortest time.Duration
+	for _, value := range hints {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			continue
+		}
+		if hint == "" || ttl < shortest {
+			hint, shortest = value, ttl
+		}
+	}
+	return hint
+}
+
+func (m *cachingPolicyManager) Invalidate(datasetID string) {
+	m.cache.invalidate(datasetID)
+}
+
+func (m *cachingPolicyManager) InvalidateAll() {
+	m.cache.invalidateAll()
+}
+
+func (m *cachingBatchPolicyManager) GetPoliciesDecisionsBatch(in *pb.ApplicationContext) (*pb.PoliciesDecisions, error) {
+	request, err := protojson.Marshal(in)
+	if err != nil {
+		return m.batch.GetPoliciesDecisionsBatch(in)
+	}
+	key := "batch/" + string(request)
+	if value, found := m.cache.get(key); found {
+		return value.(*pb.PoliciesDecisions), nil
+	}
+	response, err := m.batch.GetPoliciesDecisionsBatch(in)
+	if err != nil {
+		return nil, err
+	}
+	datasetIDs := []string{}
+	for _, dataset := range in.GetDatasets() {
+		datasetIDs = append(datasetIDs, dataset.GetDataset().GetDatasetId())
+	}
+	m.cache.set(key, response, batchCacheHint(response), datasetIDs...)
+	return response, nil
+}
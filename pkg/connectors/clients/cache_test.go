@@ -0,0 +1,144 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package clients_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"fybrik.io/fybrik/pkg/connectors/clients"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	openapiclientmodels "fybrik.io/fybrik/pkg/taxonomy/model/base"
+)
+
+type countingCatalog struct {
+	pb.UnimplementedDataCatalogServiceServer
+	calls    int
+	metadata map[string]string
+}
+
+func (c *countingCatalog) GetDatasetInfo(ctx context.Context, in *pb.CatalogDatasetRequest) (*pb.CatalogDatasetInfo, error) {
+	c.calls++
+	return &pb.CatalogDatasetInfo{
+		DatasetId: in.DatasetId,
+		Details:   &pb.DatasetDetails{Metadata: &pb.DatasetMetadata{DatasetNamedMetadata: c.metadata}},
+	}, nil
+}
+
+func (c *countingCatalog) Close() error {
+	return nil
+}
+
+type countingPolicyManager struct {
+	calls int
+	hint  string
+}
+
+func (m *countingPolicyManager) GetPoliciesDecisions(in *openapiclientmodels.PolicyManagerRequest, creds string) (*openapiclientmodels.PolicyManagerResponse, error) {
+	m.calls++
+	action := openapiclientmodels.Action{Name: "Deny"}
+	if m.hint != "" {
+		action.AdditionalProperties = map[string]interface{}{clients.CacheTTLHint: m.hint}
+	}
+	return &openapiclientmodels.PolicyManagerResponse{Result: []openapiclientmodels.ResultItem{{Policy: "deny", Action: action}}}, nil
+}
+
+func (m *countingPolicyManager) Close() error {
+	return nil
+}
+
+var _ = Describe("Connector cache", func() {
+	request := &pb.CatalogDatasetRequest{CredentialPath: "creds", DatasetId: "dataset"}
+
+	Describe("data catalog", func() {
+		It("should serve repeated requests from the cache", func() {
+			catalog := &countingCatalog{}
+			cached := clients.NewCachingDataCatalog(catalog, time.Minute)
+			for i := 0; i < 3; i++ {
+				info, err := cached.GetDatasetInfo(context.Background(), request)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(info.DatasetId).To(Equal("dataset"))
+			}
+			Expect(catalog.calls).To(Equal(1))
+		})
+
+		It("should query the catalog again after an invalidation", func() {
+			catalog := &countingCatalog{}
+			cached := clients.NewCachingDataCatalog(catalog, time.Minute)
+			_, _ = cached.GetDatasetInfo(context.Background(), request)
+			cached.(clients.CacheInvalidator).Invalidate("another-dataset")
+			_, _ = cached.GetDatasetInfo(context.Background(), request)
+			Expect(catalog.calls).To(Equal(1))
+			cached.(clients.CacheInvalidator).Invalidate("dataset")
+			_, _ = cached.GetDatasetInfo(context.Background(), request)
+			Expect(catalog.calls).To(Equal(2))
+		})
+
+		It("should honor cache hints", func() {
+			catalog := &countingCatalog{metadata: map[string]string{clients.CacheTTLHint: "0s"}}
+			cached := clients.NewCachingDataCatalog(catalog, time.Minute)
+			_, _ = cached.GetDatasetInfo(context.Background(), request)
+			_, _ = cached.GetDatasetInfo(context.Background(), request)
+			Expect(catalog.calls).To(Equal(2))
+		})
+	})
+
+	Describe("policy manager", func() {
+		input := &openapiclientmodels.PolicyManagerRequest{Resource: openapiclientmodels.Resource{Name: "dataset"}}
+
+		It("should serve repeated requests from the cache", func() {
+			policyManager := &countingPolicyManager{}
+			cached := clients.NewCachingPolicyManager(policyManager, time.Minute)
+			_, _ = cached.GetPoliciesDecisions(input, "creds")
+			response, err := cached.GetPoliciesDecisions(input, "creds")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Result).To(HaveLen(1))
+			Expect(policyManager.calls).To(Equal(1))
+			_, _ = cached.GetPoliciesDecisions(input, "other-creds")
+			Expect(policyManager.calls).To(Equal(2))
+			cached.(clients.CacheInvalidator).InvalidateAll()
+			_, _ = cached.GetPoliciesDecisions(input, "creds")
+			Expect(policyManager.calls).To(Equal(3))
+		})
+
+		It("should honor cache hints", func() {
+			policyManager := &countingPolicyManager{hint: "0"}
+			cached := clients.NewCachingPolicyManager(policyManager, time.Minute)
+			_, _ = cached.GetPoliciesDecisions(input, "creds")
+			_, _ = cached.GetPoliciesDecisions(input, "creds")
+			Expect(policyManager.calls).To(Equal(2))
+		})
+
+		It("should honor the shortest cache hint of batch decisions", func() {
+			appContext := &pb.ApplicationContext{Datasets: []*pb.DatasetContext{
+				{Dataset: &pb.DatasetIdentifier{DatasetId: "dataset1"}},
+				{Dataset: &pb.DatasetIdentifier{DatasetId: "dataset2"}},
+			}}
+			policyManager := &batchPolicyManager{action: "Deny", hints: map[string]string{"dataset1": "1h", "dataset2": "0s"}}
+			cached := clients.NewCachingPolicyManager(policyManager, time.Minute).(clients.BatchPolicyManager)
+			_, _ = cached.GetPoliciesDecisionsBatch(appContext)
+			_, _ = cached.GetPoliciesDecisionsBatch(appContext)
+			Expect(policyManager.calls).To(Equal(2))
+
+			policyManager = &batchPolicyManager{action: "Deny", hints: map[string]string{"dataset1": "1h"}}
+			cached = clients.NewCachingPolicyManager(policyManager, 0).(clients.BatchPolicyManager)
+			_, _ = cached.GetPoliciesDecisionsBatch(appContext)
+			_, _ = cached.GetPoliciesDecisionsBatch(appContext)
+			Expect(policyManager.calls).To(Equal(1))
+		})
+
+		It("should not cache when disabled", func() {
+			policyManager := &countingPolicyManager{}
+			cached := clients.NewCachingPolicyManager(policyManager, 0)
+			_, _ = cached.GetPoliciesDecisions(input, "creds")
+			_, _ = cached.GetPoliciesDecisions(input, "creds")
+			Expect(policyManager.calls).To(Equal(2))
+			_, batch := cached.(clients.BatchPolicyManager)
+			Expect(batch).To(BeFalse())
+		})
+	})
+})
@@ -16,6 +16,11 @@ type PolicyManager interface {
 	io.Closer
 }
 
+// BatchPolicyManager is implemented by policy managers that evaluate the decisions for several datasets in a single call.
+type BatchPolicyManager interface {
+	GetPoliciesDecisionsBatch(in *pb.ApplicationContext) (*pb.PoliciesDecisions, error)
+}
+
 func MergePoliciesDecisions(in ...*pb.PoliciesDecisions) *pb.PoliciesDecisions {
 	result := &pb.PoliciesDecisions{}
 
@@ -20,6 +20,7 @@ import (
 )
 
 var _ PolicyManager = (*grpcPolicyManager)(nil)
+var _ BatchPolicyManager = (*grpcPolicyManager)(nil)
 
 type grpcPolicyManager struct {
 	pb.UnimplementedPolicyManagerServiceServer
@@ -27,6 +28,8 @@ type grpcPolicyManager struct {
 	name       string
 	connection *grpc.ClientConn
 	client     pb.PolicyManagerServiceClient
+	// timeout bounds the duration of each call to the policy manager
+	timeout time.Duration
 }
 
 // NewGrpcPolicyManager creates a PolicyManager facade that connects to a GRPC service
@@ -42,6 +45,7 @@ func NewGrpcPolicyManager(name string, connectionURL string, connectionTimeout t
 		name:       name,
 		client:     pb.NewPolicyManagerServiceClient(connection),
 		connection: connection,
+		timeout:    connectionTimeout,
 	}, nil
 }
 
@@ -51,7 +55,9 @@ func (m *grpcPolicyManager) GetPoliciesDecisions(
 	appContext, _ := ConvertOpenAPIReqToGrpcReq(in, creds)
 	log.Println("grpc application context to be used for getting policy decisions: ", appContext)
 
-	result, err := m.client.GetPoliciesDecisions(context.Background(), appContext)
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	result, err := m.client.GetPoliciesDecisions(ctx, appContext)
 	if err != nil {
 		log.Println("Error while obtaining get policies decisions: ", err)
 		return nil, err
@@ -73,6 +79,20 @@ func (m *grpcPolicyManager) GetPoliciesDecisions(
 	return policyManagerResp, nil
 }
 
+// GetPoliciesDecisionsBatch evaluates the decisions for all the datasets of the application context in a single call
+func (m *grpcPolicyManager) GetPoliciesDecisionsBatch(in *pb.ApplicationContext) (*pb.PoliciesDecisions, error) {
+	log.Println("grpc application context to be used for getting batched policy decisions: ", in)
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+	result, err := m.client.GetPoliciesDecisions(ctx, in)
+	if err != nil {
+		log.Println("Error while obtaining get policies decisions: ", err)
+		return nil, err
+	}
+	log.Println("GRPC result returned from GetPoliciesDecisions:", result)
+	return result, nil
+}
+
 func (m *grpcPolicyManager) Close() error {
 	return m.connection.Close()
 }
@@ -0,0 +1,156 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+
+	"emperror.dev/errors"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	openapiclientmodels "fybrik.io/fybrik/pkg/taxonomy/model/base"
+	"fybrik.io/fybrik/pkg/taxonomy/validate"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var invalidResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "fybrik_connector_invalid_responses_total",
+	Help: "Number of connector responses that do not conform to the taxonomy",
+}, []string{"connector"})
+
+func init() {
+	metrics.Registry.MustRegister(invalidResponses)
+}
+
+// responseValidator validates connector responses against a taxonomy schema
+type responseValidator struct {
+	connector string
+	schema    string
+	mode      validate.Mode
+}
+
+// check validates a response. Responses that do not conform to the schema are counted and logged, and an error is
+// returned for them in the reject mode.
+func (v *responseValidator) check(response interface{}, description string) error {
+	if v.mode == validate.Disabled {
+		return nil
+	}
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	allErrs, err := validate.TaxonomyCheck(responseJSON, v.schema)
+	if err != nil {
+		return errors.Wrapf(err, "could not validate the %s response", v.connector)
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	invalidResponses.WithLabelValues(v.connector).Inc()
+	message := "the " + v.connector + " response for " + description + " does not conform to the taxonomy: " + errorsToString(allErrs)
+	if v.mode == validate.Reject {
+		return errors.New(message)
+	}
+	log.Println(message)
+	return nil
+}
+
+func errorsToString(allErrs []*field.Error) string {
+	messages := make([]string, 0, len(allErrs))
+	for _, err := range allErrs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Ensure that validatingDataCatalog implements the DataCatalog interface
+var _ DataCatalog = (*validatingDataCatalog)(nil)
+
+type validatingDataCatalog struct {
+	DataCatalog
+	validator *responseValidator
+}
+
+// NewValidatingDataCatalog creates a DataCatalog facade that validates the dataset details returned by the given
+// catalog against the given schema, e.g. catalog_dataset_details.json.
+func NewValidatingDataCatalog(catalog DataCatalog, schema string, mode validate.Mode) DataCatalog {
+	return &validatingDataCatalog{
+		DataCatalog: catalog,
+		validator:   &responseValidator{connector: "catalog", schema: schema, mode: mode},
+	}
+}
+
+func (c *validatingDataCatalog) GetDatasetInfo(ctx context.Context, in *pb.CatalogDatasetRequest) (*pb.CatalogDatasetInfo, error) {
+	response, err := c.DataCatalog.GetDatasetInfo(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.validator.check(response.GetDetails(), "dataset "+in.GetDatasetId()); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Ensure that validatingPolicyManager implements the PolicyManager interface
+var _ PolicyManager = (*validatingPolicyManager)(nil)
+
+type validatingPolicyManager struct {
+	PolicyManager
+	validator *responseValidator
+}
+
+// validatingBatchPolicyManager additionally validates batch decisions of policy managers that support them
+type validatingBatchPolicyManager struct {
+	*validatingPolicyManager
+	batch BatchPolicyManager
+}
+
+// NewValidatingPolicyManager creates a PolicyManager facade that validates the decisions returned by the given
+// policy manager against the given schema, e.g. policy_manager_response.json.
+// The returned facade supports batch decisions if the given policy manager does.
+func NewValidatingPolicyManager(policyManager PolicyManager, schema string, mode validate.Mode) PolicyManager {
+	manager := &validatingPolicyManager{
+		PolicyManager: policyManager,
+		validator:     &responseValidator{connector: "policymanager", schema: schema, mode: mode},
+	}
+	if batch, ok := policyManager.(BatchPolicyManager); ok {
+		return &validatingBatchPolicyManager{validatingPolicyManager: manager, batch: batch}
+	}
+	return manager
+}
+
+func (m *validatingPolicyManager) GetPoliciesDecisions(in *openapiclientmodels.PolicyManagerRequest, creds string) (*openapiclientmodels.PolicyManagerResponse, error) {
+	response, err := m.PolicyManager.GetPoliciesDecisions(in, creds)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.validator.check(response, "dataset "+in.Resource.Name); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// GetPoliciesDecisionsBatch validates the decisions for every dataset in the form of a single dataset response.
+// In the reject mode, the whole batch fails if the decisions for one of the datasets do not conform to the schema.
+func (m *validatingBatchPolicyManager) GetPoliciesDecisionsBatch(in *pb.ApplicationContext) (*pb.PoliciesDecisions, error) {
+	response, err := m.batch.GetPoliciesDecisionsBatch(in)
+	if err != nil {
+		return nil, err
+	}
+	for _, datasetDecision := range response.GetDatasetDecisions() {
+		decisions := &pb.PoliciesDecisions{DatasetDecisions: []*pb.DatasetDecision{datasetDecision}}
+		datasetResponse, err := ConvertGrpcRespToOpenAPIResp(decisions)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.validator.check(datasetResponse, "dataset "+datasetDecision.GetDataset().GetDatasetId()); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package clients_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"fybrik.io/fybrik/pkg/connectors/clients"
+	pb "fybrik.io/fybrik/pkg/connectors/protobuf"
+	openapiclientmodels "fybrik.io/fybrik/pkg/taxonomy/model/base"
+	"fybrik.io/fybrik/pkg/taxonomy/validate"
+)
+
+// restrictedTaxonomy allows only the csv data format and the Deny action
+const restrictedTaxonomy = `{"definitions": {
+	"Action": {"type": "object", "properties": {"name": {"type": "string", "enum": ["Deny"]}}, "required": ["name"]},
+	"Dataformat": {"type": "string", "enum": ["csv"]},
+	"ProcessingLocation": {"type": "string"},
+	"Tags": {"type": "object"}
+}}`
+
+type formatCatalog struct {
+	format string
+}
+
+func (c *formatCatalog) GetDatasetInfo(ctx context.Context, in *pb.CatalogDatasetRequest) (*pb.CatalogDatasetInfo, error) {
+	return &pb.CatalogDatasetInfo{DatasetId: in.DatasetId, Details: &pb.DatasetDetails{DataFormat: c.format}}, nil
+}
+
+func (c *formatCatalog) Close() error {
+	return nil
+}
+
+// batchPolicyManager returns the given action for every dataset of a batch, with the cache hints of the datasets
+type batchPolicyManager struct {
+	countingPolicyManager
+	action string
+	hints  map[string]string
+}
+
+func (m *batchPolicyManager) GetPoliciesDecisionsBatch(in *pb.ApplicationContext) (*pb.PoliciesDecisions, error) {
+	m.calls++
+	decisions := &pb.PoliciesDecisions{}
+	for _, dataset := range in.GetDatasets() {
+		args := map[string]string{}
+		if hint, ok := m.hints[dataset.GetDataset().GetDatasetId()]; ok {
+			args[clients.CacheTTLHint] = hint
+		}
+		decisions.DatasetDecisions = append(decisions.DatasetDecisions, &pb.DatasetDecision{
+			Dataset: dataset.GetDataset(),
+			Decisions: []*pb.OperationDecision{{
+				Operation:          dataset.GetOperation(),
+				EnforcementActions: []*pb.EnforcementAction{{Name: m.action, Level: pb.EnforcementAction_DATASET, Args: args}},
+			}},
+		})
+	}
+	return decisions, nil
+}
+
+var _ = Describe("Connector taxonomy validation", func() {
+	var taxonomyDir string
+
+	BeforeEach(func() {
+		var err error
+		taxonomyDir, err = ioutil.TempDir("", "taxonomy")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(taxonomyDir, "taxonomy.json"), []byte(restrictedTaxonomy), 0600)).To(Succeed())
+		for _, schema := range []string{"catalog_dataset_details.json", "policy_manager_response.json"} {
+			content, err := ioutil.ReadFile(filepath.Join("../../../charts/fybrik/files/taxonomy", schema))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(taxonomyDir, schema), content, 0600)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(taxonomyDir)).To(Succeed())
+	})
+
+	Describe("data catalog", func() {
+		request := &pb.CatalogDatasetRequest{DatasetId: "dataset"}
+
+		It("should accept dataset details that conform to the taxonomy", func() {
+			schema := filepath.Join(taxonomyDir, "catalog_dataset_details.json")
+			catalog := clients.NewValidatingDataCatalog(&formatCatalog{format: "csv"}, schema, validate.Reject)
+			info, err := catalog.GetDatasetInfo(context.Background(), request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.GetDetails().GetDataFormat()).To(Equal("csv"))
+		})
+
+		It("should reject dataset details that do not conform to the taxonomy", func() {
+			schema := filepath.Join(taxonomyDir, "catalog_dataset_details.json")
+			catalog := clients.NewValidatingDataCatalog(&formatCatalog{format: "parquet"}, schema, validate.Reject)
+			_, err := catalog.GetDatasetInfo(context.Background(), request)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should only flag dataset details that do not conform to the taxonomy", func() {
+			schema := filepath.Join(taxonomyDir, "catalog_dataset_details.json")
+			catalog := clients.NewValidatingDataCatalog(&formatCatalog{format: "parquet"}, schema, validate.Flag)
+			info, err := catalog.GetDatasetInfo(context.Background(), request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.GetDetails().GetDataFormat()).To(Equal("parquet"))
+		})
+	})
+
+	Describe("policy manager", func() {
+		input := &openapiclientmodels.PolicyManagerRequest{Resource: openapiclientmodels.Resource{Name: "dataset"}}
+
+		It("should accept decisions that conform to the taxonomy", func() {
+			schema := filepath.Join(taxonomyDir, "policy_manager_response.json")
+			policyManager := clients.NewValidatingPolicyManager(&countingPolicyManager{}, schema, validate.Reject)
+			response, err := policyManager.GetPoliciesDecisions(input, "creds")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response.Result).To(HaveLen(1))
+		})
+
+		It("should reject decisions that do not conform to the taxonomy", func() {
+			schema := filepath.Join(taxonomyDir, "policy_manager_response.json")
+			// the cache hint is not a property of the actions in the taxonomy
+			Expect(ioutil.WriteFile(filepath.Join(taxonomyDir, "taxonomy.json"), []byte(`{"definitions": {
+				"Action": {"type": "object", "properties": {"name": {"type": "string"}}, "additionalProperties": false}}}`), 0600)).To(Succeed())
+			policyManager := clients.NewValidatingPolicyManager(&countingPolicyManager{hint: "1m"}, schema, validate.Reject)
+			_, err := policyManager.GetPoliciesDecisions(input, "creds")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should validate batch decisions", func() {
+			schema := filepath.Join(taxonomyDir, "policy_manager_response.json")
+			appContext := &pb.ApplicationContext{Datasets: []*pb.DatasetContext{
+				{Dataset: &pb.DatasetIdentifier{DatasetId: "dataset1"}, Operation: &pb.AccessOperation{Type: pb.AccessOperation_READ}},
+				{Dataset: &pb.DatasetIdentifier{DatasetId: "dataset2"}, Operation: &pb.AccessOperation{Type: pb.AccessOperation_READ}},
+			}}
+
+			policyManager := clients.NewValidatingPolicyManager(&batchPolicyManager{action: "Deny"}, schema, validate.Reject)
+			batch, ok := policyManager.(clients.BatchPolicyManager)
+			Expect(ok).To(BeTrue())
+			decisions, err := batch.GetPoliciesDecisionsBatch(appContext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decisions.GetDatasetDecisions()).To(HaveLen(2))
+
+			// the taxonomy only allows the Deny action
+			policyManager = clients.NewValidatingPolicyManager(&batchPolicyManager{action: "Allow"}, schema, validate.Reject)
+			_, err = policyManager.(clients.BatchPolicyManager).GetPoliciesDecisionsBatch(appContext)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -31,6 +31,7 @@ const (
 	DataStore_S3      DataStore_DataStoreType = 2
 	DataStore_DB2     DataStore_DataStoreType = 3
 	DataStore_KAFKA   DataStore_DataStoreType = 4
+	DataStore_JDBC    DataStore_DataStoreType = 5
 )
 
 // Enum value maps for DataStore_DataStoreType.
@@ -41,6 +42,7 @@ var (
 		2: "S3",
 		3: "DB2",
 		4: "KAFKA",
+		5: "JDBC",
 	}
 	DataStore_DataStoreType_value = map[string]int32{
 		"UNKNOWN": 0,
@@ -48,6 +50,7 @@ var (
 		"S3":      2,
 		"DB2":     3,
 		"KAFKA":   4,
+		"JDBC":    5,
 	}
 )
 
@@ -75,7 +78,7 @@ func (x DataStore_DataStoreType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use DataStore_DataStoreType.Descriptor instead.
 func (DataStore_DataStoreType) EnumDescriptor() ([]byte, []int) {
-	return file_dataset_details_proto_rawDescGZIP(), []int{5, 0}
+	return file_dataset_details_proto_rawDescGZIP(), []int{6, 0}
 }
 
 type DataComponentMetadata struct {
@@ -471,6 +474,69 @@ func (x *KafkaDataStore) GetSslTruststorePassword() string {
 	return ""
 }
 
+type JdbcDataStore struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Url    string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`       // JDBC connection URL, e.g. jdbc:postgresql://host:5432/database
+	Table  string `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`   // table name as expected by the database, e.g. SCHEMA.TABLE
+	Driver string `protobuf:"bytes,3,opt,name=driver,proto3" json:"driver,omitempty"` // class name of the JDBC driver, may be empty for well-known databases
+}
+
+func (x *JdbcDataStore) Reset() {
+	*x = JdbcDataStore{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_dataset_details_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *JdbcDataStore) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JdbcDataStore) ProtoMessage() {}
+
+func (x *JdbcDataStore) ProtoReflect() protoreflect.Message {
+	mi := &file_dataset_details_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JdbcDataStore.ProtoReflect.Descriptor instead.
+func (*JdbcDataStore) Descriptor() ([]byte, []int) {
+	return file_dataset_details_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *JdbcDataStore) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *JdbcDataStore) GetTable() string {
+	if x != nil {
+		return x.Table
+	}
+	return ""
+}
+
+func (x *JdbcDataStore) GetDriver() string {
+	if x != nil {
+		return x.Driver
+	}
+	return ""
+}
+
 type DataStore struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -483,12 +549,13 @@ type DataStore struct {
 	Db2   *Db2DataStore   `protobuf:"bytes,3,opt,name=db2,proto3" json:"db2,omitempty"`
 	S3    *S3DataStore    `protobuf:"bytes,4,opt,name=s3,proto3" json:"s3,omitempty"`
 	Kafka *KafkaDataStore `protobuf:"bytes,5,opt,name=kafka,proto3" json:"kafka,omitempty"`
+	Jdbc  *JdbcDataStore  `protobuf:"bytes,6,opt,name=jdbc,proto3" json:"jdbc,omitempty"`
 }
 
 func (x *DataStore) Reset() {
 	*x = DataStore{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dataset_details_proto_msgTypes[5]
+		mi := &file_dataset_details_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -501,7 +568,7 @@ func (x *DataStore) String() string {
 func (*DataStore) ProtoMessage() {}
 
 func (x *DataStore) ProtoReflect() protoreflect.Message {
-	mi := &file_dataset_details_proto_msgTypes[5]
+	mi := &file_dataset_details_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -514,7 +581,7 @@ func (x *DataStore) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DataStore.ProtoReflect.Descriptor instead.
 func (*DataStore) Descriptor() ([]byte, []int) {
-	return file_dataset_details_proto_rawDescGZIP(), []int{5}
+	return file_dataset_details_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *DataStore) GetType() DataStore_DataStoreType {
@@ -552,6 +619,13 @@ func (x *DataStore) GetKafka() *KafkaDataStore {
 	return nil
 }
 
+func (x *DataStore) GetJdbc() *JdbcDataStore {
+	if x != nil {
+		return x.Jdbc
+	}
+	return nil
+}
+
 type CredentialsInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -563,7 +637,7 @@ type CredentialsInfo struct {
 func (x *CredentialsInfo) Reset() {
 	*x = CredentialsInfo{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dataset_details_proto_msgTypes[6]
+		mi := &file_dataset_details_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -576,7 +650,7 @@ func (x *CredentialsInfo) String() string {
 func (*CredentialsInfo) ProtoMessage() {}
 
 func (x *CredentialsInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_dataset_details_proto_msgTypes[6]
+	mi := &file_dataset_details_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -589,7 +663,7 @@ func (x *CredentialsInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CredentialsInfo.ProtoReflect.Descriptor instead.
 func (*CredentialsInfo) Descriptor() ([]byte, []int) {
-	return file_dataset_details_proto_rawDescGZIP(), []int{6}
+	return file_dataset_details_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CredentialsInfo) GetVaultSecretPath() string {
@@ -617,7 +691,7 @@ type DatasetDetails struct {
 func (x *DatasetDetails) Reset() {
 	*x = DatasetDetails{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_dataset_details_proto_msgTypes[7]
+		mi := &file_dataset_details_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -630,7 +704,7 @@ func (x *DatasetDetails) String() string {
 func (*DatasetDetails) ProtoMessage() {}
 
 func (x *DatasetDetails) ProtoReflect() protoreflect.Message {
-	mi := &file_dataset_details_proto_msgTypes[7]
+	mi := &file_dataset_details_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -643,7 +717,7 @@ func (x *DatasetDetails) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DatasetDetails.ProtoReflect.Descriptor instead.
 func (*DatasetDetails) Descriptor() ([]byte, []int) {
-	return file_dataset_details_proto_rawDescGZIP(), []int{7}
+	return file_dataset_details_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *DatasetDetails) GetName() string {
@@ -783,54 +857,63 @@ var file_dataset_details_proto_rawDesc = []byte{
 	0x73, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x36, 0x0a, 0x17, 0x73, 0x73, 0x6c, 0x5f, 0x74, 0x72, 0x75,
 	0x73, 0x74, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x5f, 0x70, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64,
 	0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x15, 0x73, 0x73, 0x6c, 0x54, 0x72, 0x75, 0x73, 0x74,
-	0x73, 0x74, 0x6f, 0x72, 0x65, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22, 0xa4, 0x02,
-	0x0a, 0x09, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x37, 0x0a, 0x04, 0x74,
-	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x63, 0x6f, 0x6e, 0x6e,
-	0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65,
-	0x2e, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04,
-	0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x03, 0x64, 0x62, 0x32, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f,
-	0x72, 0x73, 0x2e, 0x44, 0x62, 0x32, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52,
-	0x03, 0x64, 0x62, 0x32, 0x12, 0x27, 0x0a, 0x02, 0x73, 0x33, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x17, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x53, 0x33,
-	0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x02, 0x73, 0x33, 0x12, 0x30, 0x0a,
-	0x05, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x63,
-	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4b, 0x61, 0x66, 0x6b, 0x61, 0x44,
-	0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x05, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x22,
-	0x43, 0x0a, 0x0d, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x54, 0x79, 0x70, 0x65,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x50, 0x61, 0x73, 0x73, 0x77, 0x6f, 0x72, 0x64, 0x22, 0x4f, 0x0a,
+	0x0d, 0x4a, 0x64, 0x62, 0x63, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x10,
+	0x0a, 0x03, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x75, 0x72, 0x6c,
+	0x12, 0x14, 0x0a, 0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x05, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x72, 0x69, 0x76, 0x65, 0x72, 0x22, 0xdd,
+	0x02, 0x0a, 0x09, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x37, 0x0a, 0x04,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72,
+	0x65, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x03, 0x64, 0x62, 0x32,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74,
+	0x6f, 0x72, 0x73, 0x2e, 0x44, 0x62, 0x32, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65,
+	0x52, 0x03, 0x64, 0x62, 0x32, 0x12, 0x27, 0x0a, 0x02, 0x73, 0x33, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x17, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x53,
+	0x33, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x02, 0x73, 0x33, 0x12, 0x30,
+	0x0a, 0x05, 0x6b, 0x61, 0x66, 0x6b, 0x61, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e,
+	0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4b, 0x61, 0x66, 0x6b, 0x61,
+	0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x05, 0x6b, 0x61, 0x66, 0x6b, 0x61,
+	0x12, 0x2d, 0x0a, 0x04, 0x6a, 0x64, 0x62, 0x63, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x4a, 0x64, 0x62, 0x63,
+	0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x04, 0x6a, 0x64, 0x62, 0x63, 0x22,
+	0x4d, 0x0a, 0x0d, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x54, 0x79, 0x70, 0x65,
 	0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x09, 0x0a,
 	0x05, 0x4c, 0x4f, 0x43, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x06, 0x0a, 0x02, 0x53, 0x33, 0x10, 0x02,
 	0x12, 0x07, 0x0a, 0x03, 0x44, 0x42, 0x32, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x4b, 0x41, 0x46,
-	0x4b, 0x41, 0x10, 0x04, 0x22, 0x3d, 0x0a, 0x0f, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69,
-	0x61, 0x6c, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x2a, 0x0a, 0x11, 0x76, 0x61, 0x75, 0x6c, 0x74,
-	0x5f, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0f, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x50,
-	0x61, 0x74, 0x68, 0x22, 0xad, 0x02, 0x0a, 0x0e, 0x44, 0x61, 0x74, 0x61, 0x73, 0x65, 0x74, 0x44,
-	0x65, 0x74, 0x61, 0x69, 0x6c, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x64, 0x61,
-	0x74, 0x61, 0x5f, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x64, 0x61, 0x74, 0x61, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x34, 0x0a, 0x0a, 0x64, 0x61, 0x74,
-	0x61, 0x5f, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e,
-	0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x53,
-	0x74, 0x6f, 0x72, 0x65, 0x52, 0x09, 0x64, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12,
-	0x1f, 0x0a, 0x0b, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x04,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74,
-	0x12, 0x10, 0x0a, 0x03, 0x67, 0x65, 0x6f, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x67,
-	0x65, 0x6f, 0x12, 0x37, 0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x07,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72,
-	0x73, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x73, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
-	0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x46, 0x0a, 0x10, 0x63,
-	0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18,
-	0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f,
-	0x72, 0x73, 0x2e, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x49, 0x6e,
-	0x66, 0x6f, 0x52, 0x0f, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x49,
-	0x6e, 0x66, 0x6f, 0x42, 0x4d, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x2e, 0x64, 0x61, 0x74, 0x6d, 0x65,
-	0x73, 0x68, 0x5a, 0x3e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d,
-	0x65, 0x73, 0x68, 0x2d, 0x66, 0x6f, 0x72, 0x2d, 0x64, 0x61, 0x74, 0x61, 0x2f, 0x6d, 0x65, 0x73,
-	0x68, 0x2d, 0x66, 0x6f, 0x72, 0x2d, 0x64, 0x61, 0x74, 0x61, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x63,
-	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x4b, 0x41, 0x10, 0x04, 0x12, 0x08, 0x0a, 0x04, 0x4a, 0x44, 0x42, 0x43, 0x10, 0x05, 0x22, 0x3d,
+	0x0a, 0x0f, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x49, 0x6e, 0x66,
+	0x6f, 0x12, 0x2a, 0x0a, 0x11, 0x76, 0x61, 0x75, 0x6c, 0x74, 0x5f, 0x73, 0x65, 0x63, 0x72, 0x65,
+	0x74, 0x5f, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x76, 0x61,
+	0x75, 0x6c, 0x74, 0x53, 0x65, 0x63, 0x72, 0x65, 0x74, 0x50, 0x61, 0x74, 0x68, 0x22, 0xad, 0x02,
+	0x0a, 0x0e, 0x44, 0x61, 0x74, 0x61, 0x73, 0x65, 0x74, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x6f, 0x77, 0x6e,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x64, 0x61, 0x74, 0x61, 0x4f, 0x77,
+	0x6e, 0x65, 0x72, 0x12, 0x34, 0x0a, 0x0a, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x74, 0x6f, 0x72,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x6f, 0x72, 0x73, 0x2e, 0x44, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x52, 0x09,
+	0x64, 0x61, 0x74, 0x61, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x61, 0x74,
+	0x61, 0x5f, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a,
+	0x64, 0x61, 0x74, 0x61, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x67, 0x65,
+	0x6f, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x67, 0x65, 0x6f, 0x12, 0x37, 0x0a, 0x08,
+	0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b,
+	0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x44, 0x61, 0x74, 0x61,
+	0x73, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x46, 0x0a, 0x10, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74,
+	0x69, 0x61, 0x6c, 0x73, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1b, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x2e, 0x43, 0x72, 0x65,
+	0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0f, 0x63, 0x72,
+	0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x42, 0x4d, 0x0a,
+	0x0b, 0x63, 0x6f, 0x6d, 0x2e, 0x64, 0x61, 0x74, 0x6d, 0x65, 0x73, 0x68, 0x5a, 0x3e, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6d, 0x65, 0x73, 0x68, 0x2d, 0x66, 0x6f,
+	0x72, 0x2d, 0x64, 0x61, 0x74, 0x61, 0x2f, 0x6d, 0x65, 0x73, 0x68, 0x2d, 0x66, 0x6f, 0x72, 0x2d,
+	0x64, 0x61, 0x74, 0x61, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74,
+	0x6f, 0x72, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -846,7 +929,7 @@ func file_dataset_details_proto_rawDescGZIP() []byte {
 }
 
 var file_dataset_details_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_dataset_details_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_dataset_details_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
 var file_dataset_details_proto_goTypes = []interface{}{
 	(DataStore_DataStoreType)(0),  // 0: connectors.DataStore.DataStoreType
 	(*DataComponentMetadata)(nil), // 1: connectors.DataComponentMetadata
@@ -854,30 +937,32 @@ var file_dataset_details_proto_goTypes = []interface{}{
 	(*Db2DataStore)(nil),          // 3: connectors.Db2DataStore
 	(*S3DataStore)(nil),           // 4: connectors.S3DataStore
 	(*KafkaDataStore)(nil),        // 5: connectors.KafkaDataStore
-	(*DataStore)(nil),             // 6: connectors.DataStore
-	(*CredentialsInfo)(nil),       // 7: connectors.CredentialsInfo
-	(*DatasetDetails)(nil),        // 8: connectors.DatasetDetails
-	nil,                           // 9: connectors.DataComponentMetadata.NamedMetadataEntry
-	nil,                           // 10: connectors.DatasetMetadata.DatasetNamedMetadataEntry
-	nil,                           // 11: connectors.DatasetMetadata.ComponentsMetadataEntry
+	(*JdbcDataStore)(nil),         // 6: connectors.JdbcDataStore
+	(*DataStore)(nil),             // 7: connectors.DataStore
+	(*CredentialsInfo)(nil),       // 8: connectors.CredentialsInfo
+	(*DatasetDetails)(nil),        // 9: connectors.DatasetDetails
+	nil,                           // 10: connectors.DataComponentMetadata.NamedMetadataEntry
+	nil,                           // 11: connectors.DatasetMetadata.DatasetNamedMetadataEntry
+	nil,                           // 12: connectors.DatasetMetadata.ComponentsMetadataEntry
 }
 var file_dataset_details_proto_depIdxs = []int32{
-	9,  // 0: connectors.DataComponentMetadata.named_metadata:type_name -> connectors.DataComponentMetadata.NamedMetadataEntry
-	10, // 1: connectors.DatasetMetadata.dataset_named_metadata:type_name -> connectors.DatasetMetadata.DatasetNamedMetadataEntry
-	11, // 2: connectors.DatasetMetadata.components_metadata:type_name -> connectors.DatasetMetadata.ComponentsMetadataEntry
+	10, // 0: connectors.DataComponentMetadata.named_metadata:type_name -> connectors.DataComponentMetadata.NamedMetadataEntry
+	11, // 1: connectors.DatasetMetadata.dataset_named_metadata:type_name -> connectors.DatasetMetadata.DatasetNamedMetadataEntry
+	12, // 2: connectors.DatasetMetadata.components_metadata:type_name -> connectors.DatasetMetadata.ComponentsMetadataEntry
 	0,  // 3: connectors.DataStore.type:type_name -> connectors.DataStore.DataStoreType
 	3,  // 4: connectors.DataStore.db2:type_name -> connectors.Db2DataStore
 	4,  // 5: connectors.DataStore.s3:type_name -> connectors.S3DataStore
 	5,  // 6: connectors.DataStore.kafka:type_name -> connectors.KafkaDataStore
-	6,  // 7: connectors.DatasetDetails.data_store:type_name -> connectors.DataStore
-	2,  // 8: connectors.DatasetDetails.metadata:type_name -> connectors.DatasetMetadata
-	7,  // 9: connectors.DatasetDetails.credentials_info:type_name -> connectors.CredentialsInfo
-	1,  // 10: connectors.DatasetMetadata.ComponentsMetadataEntry.value:type_name -> connectors.DataComponentMetadata
-	11, // [11:11] is the sub-list for method output_type
-	11, // [11:11] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	6,  // 7: connectors.DataStore.jdbc:type_name -> connectors.JdbcDataStore
+	7,  // 8: connectors.DatasetDetails.data_store:type_name -> connectors.DataStore
+	2,  // 9: connectors.DatasetDetails.metadata:type_name -> connectors.DatasetMetadata
+	8,  // 10: connectors.DatasetDetails.credentials_info:type_name -> connectors.CredentialsInfo
+	1,  // 11: connectors.DatasetMetadata.ComponentsMetadataEntry.value:type_name -> connectors.DataComponentMetadata
+	12, // [12:12] is the sub-list for method output_type
+	12, // [12:12] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_dataset_details_proto_init() }
@@ -947,7 +1032,7 @@ func file_dataset_details_proto_init() {
 			}
 		}
 		file_dataset_details_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*DataStore); i {
+			switch v := v.(*JdbcDataStore); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -959,7 +1044,7 @@ func file_dataset_details_proto_init() {
 			}
 		}
 		file_dataset_details_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CredentialsInfo); i {
+			switch v := v.(*DataStore); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -971,6 +1056,18 @@ func file_dataset_details_proto_init() {
 			}
 		}
 		file_dataset_details_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CredentialsInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_dataset_details_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*DatasetDetails); i {
 			case 0:
 				return &v.state
@@ -989,7 +1086,7 @@ func file_dataset_details_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_dataset_details_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   11,
+			NumMessages:   12,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -6,6 +6,7 @@ package environment
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 // Returns the integer value of an environment variable.
@@ -31,3 +32,15 @@ func GetEnvAsFloat32(key string, defaultValue float32) float32 {
 	}
 	return defaultValue
 }
+
+// Returns the duration value of an environment variable (e.g. "30s").
+// If the environment variable is not set or cannot be parsed the default value is returned.
+func GetEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if env, isSet := os.LookupEnv(key); isSet {
+		d, err := time.ParseDuration(env)
+		if err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
@@ -83,8 +83,9 @@ func (r *Fake) Uninstall(kubeNamespace string, releaseName string) (*release.Uni
 // Install helm release
 func (r *Fake) Install(chart *chart.Chart, kubeNamespace string, releaseName string, vals map[string]interface{}) (*release.Release, error) {
 	r.release = &release.Release{
-		Name: releaseName,
-		Info: &release.Info{Status: release.StatusDeployed},
+		Name:   releaseName,
+		Info:   &release.Info{Status: release.StatusDeployed},
+		Config: vals,
 	}
 	return r.release, nil
 }
@@ -92,8 +93,9 @@ func (r *Fake) Install(chart *chart.Chart, kubeNamespace string, releaseName str
 // Upgrade helm release
 func (r *Fake) Upgrade(chart *chart.Chart, kubeNamespace string, releaseName string, vals map[string]interface{}) (*release.Release, error) {
 	r.release = &release.Release{
-		Name: releaseName,
-		Info: &release.Info{Status: release.StatusDeployed},
+		Name:   releaseName,
+		Info:   &release.Info{Status: release.StatusDeployed},
+		Config: vals,
 	}
 	return r.release, nil
 }
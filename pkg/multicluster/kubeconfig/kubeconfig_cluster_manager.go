@@ -0,0 +1,264 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"emperror.dev/errors"
+	"fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/pkg/multicluster"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ClusterLabel marks the Secrets that hold the kubeconfig of a member cluster
+	ClusterLabel string = "fybrik.io/cluster-kubeconfig"
+	// KubeconfigKey is the key of the kubeconfig in the Secret of a member cluster
+	KubeconfigKey string = "kubeconfig"
+	// MetadataNamespaceAnnotation overrides the namespace of the cluster-metadata ConfigMap in a member cluster
+	MetadataNamespaceAnnotation string = "fybrik.io/metadata-namespace"
+
+	clusterMetadataConfigmapName string = "cluster-metadata"
+	defaultMetadataNamespace     string = "fybrik-system"
+)
+
+// memberCluster is a member cluster together with the client to access it
+type memberCluster struct {
+	cluster multicluster.Cluster
+	client  client.Client
+	// resource version of the Secret from which the client was created
+	secretVersion string
+}
+
+// ClusterManager manages blueprints on member clusters that are registered with kubeconfig Secrets.
+// Every Secret in the namespace of the manager that is labeled with fybrik.io/cluster-kubeconfig=true holds
+// the kubeconfig of a member cluster. The name, region and zone of a member cluster are read from the
+// cluster-metadata ConfigMap in the cluster.
+type ClusterManager struct {
+	// Reader for the Secrets of the member clusters
+	Reader    client.Reader
+	Namespace string
+	Scheme    *runtime.Scheme
+	Log       logr.Logger
+
+	mutex sync.Mutex
+	// clients of the member clusters by the name of their Secret
+	members map[string]*memberCluster
+}
+
+// newClusterClient creates a client for a member cluster from its kubeconfig
+func newClusterClient(kubeconfig []byte, scheme *runtime.Scheme) (client.Client, error) {
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid kubeconfig")
+	}
+	return client.New(config, client.Options{Scheme: scheme})
+}
+
+// refreshMembers reads the Secrets of the member clusters and creates clients for new or changed clusters.
+// Clusters whose Secret has been removed are forgotten. A cluster whose changed Secret cannot be used, e.g. because
+// its metadata cannot be read at the moment, keeps its previous client until the Secret can be used again.
+// The clients are created without holding the lock as reading the metadata of the clusters accesses the network.
+func (cm *ClusterManager) refreshMembers() (map[string]*memberCluster, error) {
+	secrets := &corev1.SecretList{}
+	if err := cm.Reader.List(context.Background(), secrets, client.InNamespace(cm.Namespace),
+		client.MatchingLabels{ClusterLabel: "true"}); err != nil {
+		return nil, errors.Wrap(err, "error while listing the secrets of member clusters")
+	}
+
+	cm.mutex.Lock()
+	previous := cm.members
+	cm.mutex.Unlock()
+
+	members := map[string]*memberCluster{}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		existing, exists := previous[secret.Name]
+		if exists && existing.secretVersion == secret.ResourceVersion {
+			members[secret.Name] = existing
+			continue
+		}
+		member, err := cm.newMember(secret)
+		if err != nil {
+			// A broken Secret must not prevent the management of the other clusters
+			if exists {
+				cm.Log.Error(err, "Keeping the previous member cluster", "secret", secret.Name)
+				members[secret.Name] = existing
+			} else {
+				cm.Log.Error(err, "Skipping member cluster", "secret", secret.Name)
+			}
+			continue
+		}
+		members[secret.Name] = member
+	}
+
+	cm.mutex.Lock()
+	cm.members = members
+	cm.mutex.Unlock()
+	return members, nil
+}
+
+// newMember creates the client of a member cluster from its Secret and reads the metadata of the cluster
+func (cm *ClusterManager) newMember(secret *corev1.Secret) (*memberCluster, error) {
+	kubeconfig, exists := secret.Data[KubeconfigKey]
+	if !exists {
+		return nil, fmt.Errorf("secret %s has no %s key", secret.Name, KubeconfigKey)
+	}
+	memberClient, err := newClusterClient(kubeconfig, cm.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	metadataNamespace := defaultMetadataNamespace
+	if namespace, exists := secret.Annotations[MetadataNamespaceAnnotation]; exists {
+		metadataNamespace = namespace
+	}
+	configMap := &corev1.ConfigMap{}
+	key := types.NamespacedName{Name: clusterMetadataConfigmapName, Namespace: metadataNamespace}
+	if err := memberClient.Get(context.Background(), key, configMap); err != nil {
+		return nil, errors.Wrap(err, "error while reading the cluster metadata")
+	}
+	cluster := multicluster.CreateCluster(configMap.Data)
+	if cluster.Name == "" {
+		return nil, fmt.Errorf("the cluster metadata of secret %s has no cluster name", secret.Name)
+	}
+	cm.Log.Info("Registered member cluster", "cluster", cluster.Name, "secret", secret.Name)
+	return &memberCluster{cluster: cluster, client: memberClient, secretVersion: secret.ResourceVersion}, nil
+}
+
+// GetClusters returns the member clusters sorted by name, so that the choice between equivalent clusters is stable
+func (cm *ClusterManager) GetClusters() ([]multicluster.Cluster, error) {
+	members, err := cm.refreshMembers()
+	if err != nil {
+		return nil, err
+	}
+	var clusters []multicluster.Cluster
+	for _, member := range members {
+		clusters = append(clusters, member.cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+	return clusters, nil
+}
+
+// clusterClient returns the client of the member cluster with the given name
+func (cm *ClusterManager) clusterClient(cluster string) (client.Client, error) {
+	cm.mutex.Lock()
+	for _, member := range cm.members {
+		if member.cluster.Name == cluster {
+			cm.mutex.Unlock()
+			return member.client, nil
+		}
+	}
+	cm.mutex.Unlock()
+
+	// The cluster may have been registered since the members were read last
+	members, err := cm.refreshMembers()
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if member.cluster.Name == cluster {
+			return member.client, nil
+		}
+	}
+	return nil, fmt.Errorf("unregistered cluster: %s", cluster)
+}
+
+// GetBlueprint returns the blueprint with the given name and namespace in a member cluster or nil if it
+// does not exist
+func (cm *ClusterManager) GetBlueprint(cluster string, namespace string, name string) (*v1alpha1.Blueprint, error) {
+	clusterClient, err := cm.clusterClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	blueprint := &v1alpha1.Blueprint{}
+	if err := clusterClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: namespace}, blueprint); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return blueprint, nil
+}
+
+// CreateBlueprint creates a blueprint in a member cluster or updates an existing one
+func (cm *ClusterManager) CreateBlueprint(cluster string, blueprint *v1alpha1.Blueprint) error {
+	return cm.UpdateBlueprint(cluster, blueprint)
+}
+
+// UpdateBlueprint updates a blueprint in a member cluster or creates it if it does not exist
+func (cm *ClusterManager) UpdateBlueprint(cluster string, blueprint *v1alpha1.Blueprint) error {
+	clusterClient, err := cm.clusterClient(cluster)
+	if err != nil {
+		return err
+	}
+	resource := &v1alpha1.Blueprint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      blueprint.Name,
+			Namespace: blueprint.Namespace,
+		},
+	}
+	_, err = ctrl.CreateOrUpdate(context.Background(), clusterClient, resource, func() error {
+		resource.Spec = blueprint.Spec
+		resource.ObjectMeta.Labels = blueprint.ObjectMeta.Labels
+		return nil
+	})
+	return err
+}
+
+// DeleteBlueprint deletes a blueprint in a member cluster. Deleting a blueprint that does not exist is not an error.
+func (cm *ClusterManager) DeleteBlueprint(cluster string, namespace string, name string) error {
+	clusterClient, err := cm.clusterClient(cluster)
+	if err != nil {
+		return err
+	}
+	blueprint := &v1alpha1.Blueprint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	return client.IgnoreNotFound(clusterClient.Delete(context.Background(), blueprint))
+}
+
+// NewScheme returns the scheme with the resources that are accessed in member clusters
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// NewManager creates a ClusterManager for the member clusters that are registered with Secrets in the given namespace.
+// The reader should not be restricted by the cache of the manager as the Secrets are read from the namespace of the manager.
+func NewManager(reader client.Reader, namespace string) (multicluster.ClusterManager, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	return &ClusterManager{
+		Reader:    reader,
+		Namespace: namespace,
+		Scheme:    scheme,
+		Log:       ctrl.Log.WithName("KubeconfigClusterManager"),
+		members:   map[string]*memberCluster{},
+	}, nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package kubeconfig
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/pkg/multicluster"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+)
+
+var _ multicluster.ClusterManager = &ClusterManager{}
+
+// The member clusters of the tests. The coordinator holds the kubeconfig Secrets and is a member itself.
+var (
+	clusterNames = []string{"coordinator", "remote"}
+	clients      = map[string]client.Client{}
+	kubeconfigs  = map[string][]byte{}
+)
+
+func TestMain(m *testing.M) {
+	path, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+	scheme, err := NewScheme()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var environments []*envtest.Environment
+	for _, name := range clusterNames {
+		env := &envtest.Environment{
+			CRDDirectoryPaths:     []string{filepath.Join(path, "..", "..", "..", "charts", "fybrik-crd", "templates")},
+			ErrorIfCRDPathMissing: true,
+		}
+		cfg, err := env.Start()
+		if err != nil {
+			log.Fatal(err)
+		}
+		environments = append(environments, env)
+		if clients[name], err = client.New(cfg, client.Options{Scheme: scheme}); err != nil {
+			log.Fatal(err)
+		}
+		if kubeconfigs[name], err = kubeconfigFromConfig(cfg); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	code := m.Run()
+	for _, env := range environments {
+		_ = env.Stop()
+	}
+	os.Exit(code)
+}
+
+// kubeconfigFromConfig writes a kubeconfig that gives access to the API server of a test environment
+func kubeconfigFromConfig(cfg *rest.Config) ([]byte, error) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["envtest"] = &clientcmdapi.Cluster{
+		Server:                   cfg.Host,
+		CertificateAuthorityData: cfg.CAData,
+		InsecureSkipTLSVerify:    cfg.Insecure,
+	}
+	config.AuthInfos["envtest"] = &clientcmdapi.AuthInfo{
+		ClientCertificateData: cfg.CertData,
+		ClientKeyData:         cfg.KeyData,
+		Token:                 cfg.BearerToken,
+		Username:              cfg.Username,
+		Password:              cfg.Password,
+	}
+	config.Contexts["envtest"] = &clientcmdapi.Context{Cluster: "envtest", AuthInfo: "envtest"}
+	config.CurrentContext = "envtest"
+	return clientcmd.Write(*config)
+}
+
+func createNamespace(g *gomega.WithT, cl client.Client, name string) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	g.Expect(client.IgnoreAlreadyExists(cl.Create(context.Background(), namespace))).To(gomega.Succeed())
+}
+
+func TestKubeconfigClusterManager(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := context.Background()
+	coordinator := clients["coordinator"]
+
+	// every cluster describes itself in its cluster-metadata ConfigMap
+	for i, name := range clusterNames {
+		createNamespace(g, clients[name], defaultMetadataNamespace)
+		createNamespace(g, clients[name], "fybrik-blueprints")
+		g.Expect(clients[name].Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterMetadataConfigmapName, Namespace: defaultMetadataNamespace},
+			Data: map[string]string{
+				"ClusterName": name,
+				"Region":      []string{"theshire", "neverland"}[i],
+				"Zone":        "zone-" + name,
+			},
+		})).To(gomega.Succeed())
+	}
+
+	// the coordinator holds a kubeconfig Secret for every member cluster and a broken one
+	secrets := map[string][]byte{"broken": []byte("not a kubeconfig")}
+	for name, kubeconfig := range kubeconfigs {
+		secrets[name] = kubeconfig
+	}
+	for name, kubeconfig := range secrets {
+		g.Expect(coordinator.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: defaultMetadataNamespace,
+				Labels:    map[string]string{ClusterLabel: "true"},
+			},
+			Data: map[string][]byte{KubeconfigKey: kubeconfig},
+		})).To(gomega.Succeed())
+	}
+
+	manager, err := NewManager(coordinator, defaultMetadataNamespace)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+
+	clusters, err := manager.GetClusters()
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(clusters).To(gomega.HaveLen(2))
+	// the clusters are sorted by name
+	g.Expect(clusters[0].Name).To(gomega.Equal("coordinator"))
+	g.Expect(clusters[0].Metadata.Region).To(gomega.Equal("theshire"))
+	g.Expect(clusters[1].Name).To(gomega.Equal("remote"))
+	g.Expect(clusters[1].Metadata.Region).To(gomega.Equal("neverland"))
+	g.Expect(clusters[1].Metadata.Zone).To(gomega.Equal("zone-remote"))
+
+	// blueprints are managed in the remote cluster only
+	blueprint := &v1alpha1.Blueprint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "plotter",
+			Namespace: "fybrik-blueprints",
+			Labels:    map[string]string{v1alpha1.ApplicationNameLabel: "notebook"},
+		},
+		Spec: v1alpha1.BlueprintSpec{Cluster: "remote", Modules: []v1alpha1.BlueprintModule{}},
+	}
+	g.Expect(manager.GetBlueprint("remote", "fybrik-blueprints", "plotter")).To(gomega.BeNil())
+	g.Expect(manager.CreateBlueprint("remote", blueprint)).To(gomega.Succeed())
+
+	remote, err := manager.GetBlueprint("remote", "fybrik-blueprints", "plotter")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(remote).ToNot(gomega.BeNil())
+	g.Expect(remote.Labels).To(gomega.HaveKeyWithValue(v1alpha1.ApplicationNameLabel, "notebook"))
+	g.Expect(manager.GetBlueprint("coordinator", "fybrik-blueprints", "plotter")).To(gomega.BeNil())
+
+	blueprint.Labels[v1alpha1.ApplicationNameLabel] = "notebook-2"
+	g.Expect(manager.UpdateBlueprint("remote", blueprint)).To(gomega.Succeed())
+	stored := &v1alpha1.Blueprint{}
+	g.Expect(clients["remote"].Get(ctx, client.ObjectKeyFromObject(blueprint), stored)).To(gomega.Succeed())
+	g.Expect(stored.Labels).To(gomega.HaveKeyWithValue(v1alpha1.ApplicationNameLabel, "notebook-2"))
+
+	g.Expect(manager.DeleteBlueprint("remote", "fybrik-blueprints", "plotter")).To(gomega.Succeed())
+	g.Expect(manager.GetBlueprint("remote", "fybrik-blueprints", "plotter")).To(gomega.BeNil())
+	g.Expect(manager.DeleteBlueprint("remote", "fybrik-blueprints", "plotter")).To(gomega.Succeed())
+
+	// unknown clusters are rejected
+	g.Expect(manager.CreateBlueprint("unknown", blueprint)).ToNot(gomega.Succeed())
+
+	// a cluster keeps its previous client while its changed Secret cannot be used
+	coordinatorSecret := &corev1.Secret{}
+	g.Expect(coordinator.Get(ctx, client.ObjectKey{Name: "coordinator", Namespace: defaultMetadataNamespace},
+		coordinatorSecret)).To(gomega.Succeed())
+	coordinatorSecret.Annotations = map[string]string{MetadataNamespaceAnnotation: "missing"}
+	g.Expect(coordinator.Update(ctx, coordinatorSecret)).To(gomega.Succeed())
+	clusters, err = manager.GetClusters()
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(clusters).To(gomega.HaveLen(2))
+	// the clusters are sorted by name
+	g.Expect(manager.GetBlueprint("coordinator", "fybrik-blueprints", "plotter")).To(gomega.BeNil())
+
+	// a cluster is forgotten once its Secret is removed
+	g.Expect(coordinator.Delete(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: defaultMetadataNamespace},
+	})).To(gomega.Succeed())
+	clusters, err = manager.GetClusters()
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(clusters).To(gomega.HaveLen(1))
+	_, err = manager.GetBlueprint("remote", "fybrik-blueprints", "plotter")
+	g.Expect(err).To(gomega.HaveOccurred())
+}
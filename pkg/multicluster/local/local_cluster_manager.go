@@ -37,14 +37,7 @@ func (cm *ClusterManager) GetClusters() ([]multicluster.Cluster, error) {
 		return nil, errors.Wrap(err, "error in GetClusters")
 	}
 	var clusters []multicluster.Cluster
-	cluster := multicluster.Cluster{
-		Name: clusterMetadataConfigmap.Data["ClusterName"],
-		Metadata: multicluster.ClusterMetadata{
-			Region:        clusterMetadataConfigmap.Data["Region"],
-			Zone:          clusterMetadataConfigmap.Data["Zone"],
-			VaultAuthPath: clusterMetadataConfigmap.Data["VaultAuthPath"],
-		},
-	}
+	cluster := multicluster.CreateCluster(clusterMetadataConfigmap.Data)
 	clusters = append(clusters, cluster)
 	return clusters, nil
 }
@@ -30,6 +30,7 @@ func TestLocalClusterManager(t *testing.T) {
 				"ClusterName": "remote-cluster",
 				"Region":      "Region-1",
 				"Zone":        "Zone-1",
+				"Connectors":  "kafka, jdbc",
 			},
 		},
 	}
@@ -47,8 +48,9 @@ func TestLocalClusterManager(t *testing.T) {
 		{
 			Name: "remote-cluster",
 			Metadata: multicluster.ClusterMetadata{
-				Region: "Region-1",
-				Zone:   "Zone-1",
+				Region:     "Region-1",
+				Zone:       "Zone-1",
+				Connectors: []string{"kafka", "jdbc"},
 			},
 		},
 	}
@@ -4,6 +4,8 @@
 package multicluster
 
 import (
+	"strings"
+
 	"fybrik.io/fybrik/manager/apis/app/v1alpha1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
@@ -25,6 +27,10 @@ type ClusterMetadata struct {
 	Region        string
 	Zone          string
 	VaultAuthPath string
+	// Connectors available in the cluster
+	Connectors []string
+	// Features of the control plane enabled in the cluster
+	Features []string
 }
 
 type Cluster struct {
@@ -32,6 +38,51 @@ type Cluster struct {
 	Metadata ClusterMetadata
 }
 
+// CreateCluster creates a cluster from the data of the cluster-metadata configmap
+// Connectors and Features are given as comma separated lists
+func CreateCluster(data map[string]string) Cluster {
+	return Cluster{
+		Name: data["ClusterName"],
+		Metadata: ClusterMetadata{
+			Region:        data["Region"],
+			Zone:          data["Zone"],
+			VaultAuthPath: data["VaultAuthPath"],
+			Connectors:    splitList(data["Connectors"]),
+			Features:      splitList(data["Features"]),
+		},
+	}
+}
+
+// SupportsDependency checks whether a connector or a feature the module depends on is available in the cluster
+// Module dependencies do not depend on the cluster
+func (c *Cluster) SupportsDependency(dependency v1alpha1.Dependency) bool {
+	var available []string
+	switch dependency.Type {
+	case v1alpha1.Connector:
+		available = c.Metadata.Connectors
+	case v1alpha1.Feature:
+		available = c.Metadata.Features
+	default:
+		return true
+	}
+	for _, name := range available {
+		if name == dependency.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // Decode json into runtime.Object, which is a pointer (such as &corev1.ConfigMapList)
 func Decode(json string, scheme *runtime.Scheme, object runtime.Object) error {
 	decoder := serializer.NewCodecFactory(scheme).UniversalDecoder()
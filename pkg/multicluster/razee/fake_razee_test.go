@@ -0,0 +1,257 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package razee
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+)
+
+// fakeRazee is a local fake of the parts of the Razee GraphQL API that are used by the cluster manager.
+// It keeps clusters, groups, channels, versions, subscriptions and reported resources in memory.
+type fakeRazee struct {
+	mutex sync.Mutex
+	// cluster name -> cluster ID
+	clusters map[string]string
+	// group name -> group UUID
+	groups map[string]string
+	// channel name -> channel
+	channels map[string]*fakeChannel
+	// subscription UUID -> subscription
+	subscriptions map[string]*fakeSubscription
+	// cluster ID + self link -> reported content
+	resources map[string]string
+	// number of requests that are rejected as unavailable before requests are served again
+	unavailable int
+	// number of requests that were received including rejected ones
+	requests int
+	nextID   int
+}
+
+type fakeChannel struct {
+	UUID     string
+	Name     string
+	Versions map[string]string // version UUID -> name
+}
+
+type fakeSubscription struct {
+	UUID        string   `json:"uuid"`
+	Name        string   `json:"name"`
+	Groups      []string `json:"groups"`
+	ChannelUUID string   `json:"channelUuid"`
+	VersionUUID string   `json:"versionUuid"`
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// The first field that is selected by a query or mutation determines the operation
+var operationRegexp = regexp.MustCompile(`\{\s*(\w+)\s*[\({]`)
+
+func newFakeRazee(clusters map[string]string) *fakeRazee {
+	return &fakeRazee{
+		clusters:      clusters,
+		groups:        map[string]string{},
+		channels:      map[string]*fakeChannel{},
+		subscriptions: map[string]*fakeSubscription{},
+		resources:     map[string]string{},
+	}
+}
+
+func (f *fakeRazee) start() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(f.serve))
+}
+
+func (f *fakeRazee) id(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s-%d", prefix, f.nextID)
+}
+
+func (f *fakeRazee) serve(w http.ResponseWriter, req *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.requests++
+	if f.unavailable > 0 {
+		f.unavailable--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	request := graphQLRequest{}
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	match := operationRegexp.FindStringSubmatch(request.Query)
+	if match == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	operation := match[1]
+	result, err := f.execute(operation, request.Variables)
+
+	response := map[string]interface{}{"data": map[string]interface{}{operation: result}}
+	if err != nil {
+		response = map[string]interface{}{
+			"data":   map[string]interface{}{operation: nil},
+			"errors": []map[string]interface{}{{"message": err.Error()}},
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// Returns the first of the given variables that is set
+func variable(variables map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		if value, ok := variables[name].(string); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func list(variables map[string]interface{}, name string) []string {
+	var result []string
+	if values, ok := variables[name].([]interface{}); ok {
+		for _, value := range values {
+			result = append(result, fmt.Sprint(value))
+		}
+	}
+	return result
+}
+
+func success(uuid string) map[string]interface{} {
+	return map[string]interface{}{"uuid": uuid, "success": true}
+}
+
+//nolint:gocyclo
+func (f *fakeRazee) execute(operation string, variables map[string]interface{}) (interface{}, error) {
+	switch operation {
+	case "me":
+		return map[string]interface{}{"id": "user", "orgId": "org"}, nil
+	case "clusterByName":
+		name := variable(variables, "clusterName", "name")
+		if id, ok := f.clusters[name]; ok {
+			return map[string]interface{}{"id": id, "orgId": "org", "clusterId": id, "name": name}, nil
+		}
+		return nil, fmt.Errorf("Query clusterByName error. Could not find the cluster with name %s.", name)
+	case "groupByName":
+		name := variable(variables, "name")
+		uuid, ok := f.groups[name]
+		if !ok {
+			return nil, fmt.Errorf("Cannot destructure property 'req_id' of 'context' as it is undefined.")
+		}
+		return map[string]interface{}{"uuid": uuid, "orgId": "org", "name": name}, nil
+	case "addGroup":
+		uuid := f.id("group")
+		f.groups[variable(variables, "name")] = uuid
+		return map[string]interface{}{"uuid": uuid}, nil
+	case "groupClusters":
+		return map[string]interface{}{"modified": len(list(variables, "clusters"))}, nil
+	case "removeGroup":
+		uuid := variable(variables, "uuid")
+		for name, groupUUID := range f.groups {
+			if groupUUID == uuid {
+				delete(f.groups, name)
+				return success(uuid), nil
+			}
+		}
+		return nil, fmt.Errorf("could not find the group with uuid %s", uuid)
+	case "channelByName":
+		name := variable(variables, "name")
+		channel, ok := f.channels[name]
+		if !ok {
+			return nil, fmt.Errorf("Query channelByName error. Could not find the channel with name %s.", name)
+		}
+		versions := []map[string]interface{}{}
+		for uuid, versionName := range channel.Versions {
+			versions = append(versions, map[string]interface{}{"uuid": uuid, "name": versionName})
+		}
+		subscriptions := []map[string]interface{}{}
+		for _, s := range f.subscriptions {
+			if s.ChannelUUID == channel.UUID {
+				subscriptions = append(subscriptions, map[string]interface{}{"uuid": s.UUID, "name": s.Name, "groups": s.Groups})
+			}
+		}
+		return map[string]interface{}{"uuid": channel.UUID, "orgId": "org", "name": name,
+			"versions": versions, "subscriptions": subscriptions}, nil
+	case "addChannel":
+		channel := &fakeChannel{UUID: f.id("channel"), Name: variable(variables, "name"), Versions: map[string]string{}}
+		f.channels[channel.Name] = channel
+		return map[string]interface{}{"uuid": channel.UUID}, nil
+	case "removeChannel":
+		uuid := variable(variables, "uuid")
+		for name, channel := range f.channels {
+			if channel.UUID == uuid {
+				delete(f.channels, name)
+				return success(uuid), nil
+			}
+		}
+		return nil, fmt.Errorf("channel uuid %s not found", uuid)
+	case "addChannelVersion":
+		channelUUID := variable(variables, "channelUuid", "channelUUID")
+		for _, channel := range f.channels {
+			if channel.UUID == channelUUID {
+				uuid := f.id("version")
+				channel.Versions[uuid] = variable(variables, "name")
+				return map[string]interface{}{"versionUuid": uuid, "success": true}, nil
+			}
+		}
+		return nil, fmt.Errorf("channel uuid %s not found", channelUUID)
+	case "removeChannelVersion":
+		uuid := variable(variables, "uuid")
+		for _, channel := range f.channels {
+			if _, ok := channel.Versions[uuid]; ok {
+				delete(channel.Versions, uuid)
+				return success(uuid), nil
+			}
+		}
+		return nil, fmt.Errorf("version uuid %s not found", uuid)
+	case "addSubscription":
+		s := &fakeSubscription{
+			UUID:        f.id("subscription"),
+			Name:        variable(variables, "name"),
+			Groups:      list(variables, "groups"),
+			ChannelUUID: variable(variables, "channelUuid", "channelUUID"),
+			VersionUUID: variable(variables, "versionUuid", "versionUUID"),
+		}
+		f.subscriptions[s.UUID] = s
+		return map[string]interface{}{"uuid": s.UUID}, nil
+	case "setSubscription":
+		uuid := variable(variables, "uuid", "subscriptionUuid")
+		s, ok := f.subscriptions[uuid]
+		if !ok {
+			return nil, fmt.Errorf("subscription uuid %s not found", uuid)
+		}
+		s.VersionUUID = variable(variables, "versionUuid", "versionUUID")
+		return success(uuid), nil
+	case "removeSubscription":
+		uuid := variable(variables, "uuid")
+		if _, ok := f.subscriptions[uuid]; !ok {
+			return nil, fmt.Errorf("subscription uuid %s not found", uuid)
+		}
+		delete(f.subscriptions, uuid)
+		return success(uuid), nil
+	case "subscriptions":
+		result := []*fakeSubscription{}
+		for _, s := range f.subscriptions {
+			result = append(result, s)
+		}
+		return result, nil
+	case "resourceContent":
+		content, ok := f.resources[variable(variables, "clusterId")+variable(variables, "resourceSelfLink")]
+		if !ok {
+			return nil, nil
+		}
+		return map[string]interface{}{"id": "resource", "histId": "resource", "content": content}, nil
+	}
+	return nil, fmt.Errorf("operation %s is not supported by the fake", operation)
+}
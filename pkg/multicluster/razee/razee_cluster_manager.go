@@ -5,7 +5,6 @@ package razee
 
 import (
 	"fmt"
-	"net/http"
 	"strconv"
 	"strings"
 
@@ -28,6 +27,11 @@ import (
 
 const (
 	clusterMetadataConfigMapSL string = "/api/v1/namespaces/fybrik-system/configmaps/cluster-metadata"
+
+	// The razee watch-keeper of a cluster reports resources with this label to Razee.
+	// With the detail level the complete blueprint including its status is reported.
+	watchResourceLabel string = "razee/watch-resource"
+	watchResourceLevel string = "detail"
 )
 
 var (
@@ -82,14 +86,7 @@ func (r *ClusterManager) GetClusters() ([]multicluster.Cluster, error) {
 		if err != nil {
 			return nil, err
 		}
-		cluster := multicluster.Cluster{
-			Name: clusterMetadataConfigmap.Data["ClusterName"],
-			Metadata: multicluster.ClusterMetadata{
-				Region:        clusterMetadataConfigmap.Data["Region"],
-				Zone:          clusterMetadataConfigmap.Data["Zone"],
-				VaultAuthPath: clusterMetadataConfigmap.Data["VaultAuthPath"],
-			},
-		}
+		cluster := multicluster.CreateCluster(clusterMetadataConfigmap.Data)
 		clusters = append(clusters, cluster)
 	}
 	return clusters, nil
@@ -99,6 +96,8 @@ func createBluePrintSelfLink(namespace string, name string) string {
 	return fmt.Sprintf("/apis/app.fybrik.io/v1alpha1/namespaces/%s/blueprints/%s", namespace, name)
 }
 
+// GetBlueprint returns the blueprint as reported by the watch-keeper of the cluster or nil if it has
+// not been reported yet
 func (r *ClusterManager) GetBlueprint(clusterName string, namespace string, name string) (*v1alpha1.Blueprint, error) {
 	selfLink := createBluePrintSelfLink(namespace, name)
 	cluster, err := r.con.Clusters.ClusterByName(r.orgID, clusterName)
@@ -145,6 +144,7 @@ func (r *ClusterManager) CreateBlueprint(cluster string, blueprint *v1alpha1.Blu
 	groupName := getGroupName(cluster)
 	channelName := channelName(cluster, blueprint.Name)
 	version := "0"
+	blueprint = watchedBlueprint(blueprint)
 
 	content, err := yaml.Marshal(blueprint)
 	if err != nil {
@@ -163,7 +163,7 @@ func (r *ClusterManager) CreateBlueprint(cluster string, blueprint *v1alpha1.Blu
 	// check group exists
 	group, err := r.con.Groups.GroupByName(r.orgID, groupName)
 	if err != nil {
-		if err.Error() == "Cannot destructure property 'req_id' of 'context' as it is undefined." {
+		if isGroupNotFound(err) {
 			r.log.Info("Group does not exist. Creating group.")
 		} else {
 			r.log.Error(err, "Error while fetching group by name", "group", groupName)
@@ -190,27 +190,42 @@ func (r *ClusterManager) CreateBlueprint(cluster string, blueprint *v1alpha1.Blu
 	// Check if channel exists
 	existingChannel, err := r.con.Channels.ChannelByName(r.orgID, channelName)
 	if err != nil {
-		if !strings.HasPrefix(err.Error(), "Query channelByName error.") {
+		if !isChannelNotFound(err) {
 			return err
 		}
 	}
+	var channelUUID string
 	if existingChannel != nil {
-		// Channel already exists. Update channel instead of creating
-		r.log.Info("Channel already exists! Updating channel version...", "existingChannel", existingChannel)
-		return r.UpdateBlueprint(cluster, blueprint)
-	}
-
-	// create channel
-	channel, err := r.con.Channels.AddChannel(r.orgID, channelName)
-	if err != nil {
-		return err
+		if len(existingChannel.Subscriptions) > 0 {
+			// Channel already exists. Update channel instead of creating
+			r.log.Info("Channel already exists! Updating channel version...", "existingChannel", existingChannel)
+			return r.UpdateBlueprint(cluster, blueprint)
+		}
+		// A channel without subscription remains if the outcome of a previous creation is unknown.
+		// Its creation is completed instead of creating the channel again.
+		r.log.Info("Channel exists without subscription! Completing its creation...", "existingChannel", existingChannel)
+		channelUUID = existingChannel.UUID
+		next := 0
+		for _, v := range existingChannel.Versions {
+			if n, err := strconv.Atoi(v.Name); err == nil && n >= next {
+				next = n + 1
+			}
+		}
+		version = strconv.Itoa(next)
+	} else {
+		// create channel
+		channel, err := r.con.Channels.AddChannel(r.orgID, channelName)
+		if err != nil {
+			return err
+		}
+		channelUUID = channel.UUID
 	}
 
 	// create channel version
-	channelVersion, err := r.con.Versions.AddChannelVersion(r.orgID, channel.UUID, version, content, "")
+	channelVersion, err := r.con.Versions.AddChannelVersion(r.orgID, channelUUID, version, content, "")
 	if err != nil {
 		// Remove channel if channelVersion could not be created
-		removeChannel, channelRemoveErr := r.con.Channels.RemoveChannel(r.orgID, channel.UUID)
+		removeChannel, channelRemoveErr := r.con.Channels.RemoveChannel(r.orgID, channelUUID)
 		if channelRemoveErr != nil {
 			r.log.Error(channelRemoveErr, "Unable to remove channel after error")
 		} else if removeChannel.Success {
@@ -221,8 +236,13 @@ func (r *ClusterManager) CreateBlueprint(cluster string, blueprint *v1alpha1.Blu
 	}
 
 	// create subscription
-	_, err = r.con.Subscriptions.AddSubscription(r.orgID, channelName, channel.UUID, channelVersion.VersionUUID, []string{groupName})
+	_, err = r.con.Subscriptions.AddSubscription(r.orgID, channelName, channelUUID, channelVersion.VersionUUID, []string{groupName})
 	if err != nil {
+		// The subscription may have been created although its response was lost
+		if channel, getErr := r.con.Channels.ChannelByName(r.orgID, channelName); getErr == nil && channel != nil && len(channel.Subscriptions) > 0 {
+			r.log.Info("Subscription has been created despite the error", "error", err.Error())
+			return nil
+		}
 		// Remove channelVersion and channel if the subscription could not be created
 		removeChannelVersion, versionRemoveErr := r.con.Versions.RemoveChannelVersion(r.orgID, channelVersion.VersionUUID)
 		if versionRemoveErr != nil {
@@ -230,7 +250,7 @@ func (r *ClusterManager) CreateBlueprint(cluster string, blueprint *v1alpha1.Blu
 		} else if removeChannelVersion.Success {
 			r.log.Info("Rolled back channel version after error")
 		}
-		removeChannel, channelRemoveErr := r.con.Channels.RemoveChannel(r.orgID, channel.UUID)
+		removeChannel, channelRemoveErr := r.con.Channels.RemoveChannel(r.orgID, channelUUID)
 		if channelRemoveErr != nil {
 			r.log.Error(channelRemoveErr, "Unable to remove channel after error")
 		} else if removeChannel.Success {
@@ -245,6 +265,7 @@ func (r *ClusterManager) CreateBlueprint(cluster string, blueprint *v1alpha1.Blu
 
 func (r *ClusterManager) UpdateBlueprint(cluster string, blueprint *v1alpha1.Blueprint) error {
 	channelName := channelName(cluster, blueprint.Name)
+	blueprint = watchedBlueprint(blueprint)
 
 	content, err := yaml.Marshal(blueprint)
 	if err != nil {
@@ -296,11 +317,18 @@ func (r *ClusterManager) UpdateBlueprint(cluster string, blueprint *v1alpha1.Blu
 	return nil
 }
 
+// DeleteBlueprint removes the subscription, the versions and the channel of a blueprint. The group of the
+// cluster is removed as well once no other blueprint is deployed to the cluster.
+// Deleting a blueprint that does not exist any more is not an error.
 func (r *ClusterManager) DeleteBlueprint(cluster string, namespace string, name string) error {
 	channelName := channelName(cluster, name)
 	channel, err := r.con.Channels.ChannelByName(r.orgID, channelName)
 	if err != nil {
-		return err
+		if !isChannelNotFound(err) {
+			return err
+		}
+		r.log.Info("Channel of blueprint does not exist any more", "channel", channelName)
+		return r.removeUnusedGroup(cluster)
 	}
 	for _, s := range channel.Subscriptions {
 		subscription, err := r.con.Subscriptions.RemoveSubscription(r.orgID, s.UUID)
@@ -328,9 +356,66 @@ func (r *ClusterManager) DeleteBlueprint(cluster string, namespace string, name
 	if removeChannel.Success {
 		r.log.Info("Successfully deleted channel " + removeChannel.UUID)
 	}
+	return r.removeUnusedGroup(cluster)
+}
+
+// removeUnusedGroup removes the group of a cluster once no subscription deploys to the group any more
+func (r *ClusterManager) removeUnusedGroup(cluster string) error {
+	groupName := getGroupName(cluster)
+	subscriptions, err := r.con.Subscriptions.Subscriptions(r.orgID)
+	if err != nil {
+		return errors.Wrap(err, "error while fetching subscriptions")
+	}
+	for _, s := range subscriptions {
+		for _, g := range s.Groups {
+			if g == groupName {
+				r.log.V(1).Info("Group is still in use", "group", groupName, "subscription", s.Name)
+				return nil
+			}
+		}
+	}
+
+	group, err := r.con.Groups.GroupByName(r.orgID, groupName)
+	if err != nil {
+		if isGroupNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if group == nil {
+		return nil
+	}
+	removeGroup, err := r.con.Groups.RemoveGroup(r.orgID, group.UUID)
+	if err != nil {
+		return err
+	}
+	if removeGroup.Success {
+		r.log.Info("Successfully deleted unused group " + groupName)
+	}
 	return nil
 }
 
+// watchedBlueprint returns a copy of the blueprint that is labeled such that the watch-keeper of the cluster
+// reports it to Razee including its status
+func watchedBlueprint(blueprint *v1alpha1.Blueprint) *v1alpha1.Blueprint {
+	blueprint = blueprint.DeepCopy()
+	if blueprint.Labels == nil {
+		blueprint.Labels = map[string]string{}
+	}
+	blueprint.Labels[watchResourceLabel] = watchResourceLevel
+	return blueprint
+}
+
+// Razee reports a group that does not exist with different messages depending on its version
+func isGroupNotFound(err error) bool {
+	return err.Error() == "Cannot destructure property 'req_id' of 'context' as it is undefined." ||
+		strings.Contains(err.Error(), "could not find the group")
+}
+
+func isChannelNotFound(err error) bool {
+	return strings.HasPrefix(err.Error(), "Query channelByName error.")
+}
+
 // The channel name should be per cluster and plotter so it cannot be based on
 // the namespace that is random for every blueprint
 func channelName(cluster string, name string) string {
@@ -342,8 +427,8 @@ func NewRazeeLocalManager(url string, login string, password string, clusterGrou
 	if err != nil {
 		return nil, err
 	}
-	con, _ := client.New(url, http.DefaultClient, localAuth)
 	logger := ctrl.Log.WithName("RazeeManager")
+	con, _ := client.New(url, newRetryingClient(DefaultBackoff, logger), localAuth)
 	me, err := con.Users.Me()
 	if err != nil {
 		return nil, err
@@ -368,8 +453,8 @@ func NewRazeeOAuthManager(url string, apiKey string, clusterGroup string) (multi
 	if err != nil {
 		return nil, err
 	}
-	con, _ := client.New(url, http.DefaultClient, auth)
 	logger := ctrl.Log.WithName("RazeeManager")
+	con, _ := client.New(url, newRetryingClient(DefaultBackoff, logger), auth)
 	me, err := con.Users.Me()
 	if err != nil {
 		return nil, err
@@ -397,7 +482,8 @@ func NewSatConfManager(apikey string, clusterGroup string) (multicluster.Cluster
 	if iamClient == nil {
 		return nil, errors.New("the IAMClient returned nil for IBM Cloud Satellite Config")
 	}
-	con, _ := client.New("https://config.satellite.cloud.ibm.com/graphql", http.DefaultClient, iamClient.Client)
+	logger := ctrl.Log.WithName("RazeeManager")
+	con, _ := client.New("https://config.satellite.cloud.ibm.com/graphql", newRetryingClient(DefaultBackoff, logger), iamClient.Client)
 
 	me, err := con.Users.Me()
 	if err != nil {
@@ -408,8 +494,6 @@ func NewSatConfManager(apikey string, clusterGroup string) (multicluster.Cluster
 		return nil, errors.New("could not retrieve login information of Razee")
 	}
 
-	logger := ctrl.Log.WithName("RazeeManager")
-
 	logger.Info("Initializing Razee with IBM Satellite Config", "orgId", me.OrgId, "clusterGroup", clusterGroup)
 
 	return &ClusterManager{
@@ -0,0 +1,178 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package razee
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/pkg/multicluster"
+	"github.com/IBM/satcon-client-go/client"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var _ multicluster.ClusterManager = &ClusterManager{}
+
+// noAuth does not authenticate requests to the fake Razee API
+type noAuth struct{}
+
+func (noAuth) Authenticate(request *http.Request) error {
+	return nil
+}
+
+var testBackoff = wait.Backoff{Duration: 10 * time.Millisecond, Factor: 2, Steps: 4}
+
+func newTestManager(g *gomega.WithT, url string) *ClusterManager {
+	log := ctrl.Log.WithName("RazeeManager")
+	con, err := client.New(url, newRetryingClient(testBackoff, log), noAuth{})
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	return &ClusterManager{orgID: "org", con: con, log: log}
+}
+
+func testBlueprint(name string) *v1alpha1.Blueprint {
+	return &v1alpha1.Blueprint{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Blueprint",
+			APIVersion: "app.fybrik.io/v1alpha1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fybrik-blueprints",
+		},
+	}
+}
+
+// TestBlueprintLifecycle creates, updates, reads and deletes blueprints and checks that the subscriptions,
+// channels and the group of a cluster are removed once no blueprint uses them any more.
+func TestBlueprintLifecycle(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	fake := newFakeRazee(map[string]string{"cluster1": "cluster1-id"})
+	server := fake.start()
+	defer server.Close()
+	manager := newTestManager(g, server.URL)
+
+	g.Expect(manager.CreateBlueprint("cluster1", testBlueprint("plotter-a"))).To(gomega.Succeed())
+	g.Expect(manager.CreateBlueprint("cluster1", testBlueprint("plotter-b"))).To(gomega.Succeed())
+	g.Expect(fake.groups).To(gomega.HaveLen(1))
+	g.Expect(fake.groups).To(gomega.HaveKey("fybrik-cluster1"))
+	g.Expect(fake.channels).To(gomega.HaveLen(2))
+	g.Expect(fake.subscriptions).To(gomega.HaveLen(2))
+
+	// an update adds a version and moves the subscription to it
+	g.Expect(manager.UpdateBlueprint("cluster1", testBlueprint("plotter-a"))).To(gomega.Succeed())
+	channel := fake.channels[channelName("cluster1", "plotter-a")]
+	g.Expect(channel.Versions).To(gomega.HaveLen(2))
+
+	// the blueprint is read from the resources reported by the cluster
+	g.Expect(manager.GetBlueprint("cluster1", "fybrik-blueprints", "plotter-a")).To(gomega.BeNil())
+	reported := testBlueprint("plotter-a")
+	reported.Labels = map[string]string{watchResourceLabel: watchResourceLevel}
+	reported.Status.ObservedState.Ready = true
+	content, err := json.Marshal(reported)
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	fake.resources["cluster1-id"+createBluePrintSelfLink("fybrik-blueprints", "plotter-a")] = string(content)
+	blueprint, err := manager.GetBlueprint("cluster1", "fybrik-blueprints", "plotter-a")
+	g.Expect(err).ToNot(gomega.HaveOccurred())
+	g.Expect(blueprint).ToNot(gomega.BeNil())
+	g.Expect(blueprint.Status.ObservedState.Ready).To(gomega.BeTrue())
+
+	// the group is kept while another blueprint is deployed to the cluster
+	g.Expect(manager.DeleteBlueprint("cluster1", "fybrik-blueprints", "plotter-a")).To(gomega.Succeed())
+	g.Expect(fake.channels).To(gomega.HaveLen(1))
+	g.Expect(fake.subscriptions).To(gomega.HaveLen(1))
+	g.Expect(fake.groups).To(gomega.HaveLen(1))
+
+	g.Expect(manager.DeleteBlueprint("cluster1", "fybrik-blueprints", "plotter-b")).To(gomega.Succeed())
+	g.Expect(fake.channels).To(gomega.BeEmpty())
+	g.Expect(fake.subscriptions).To(gomega.BeEmpty())
+	g.Expect(fake.groups).To(gomega.BeEmpty())
+
+	// deleting a blueprint again is not an error
+	g.Expect(manager.DeleteBlueprint("cluster1", "fybrik-blueprints", "plotter-b")).To(gomega.Succeed())
+}
+
+// TestWatchedBlueprint checks that blueprints are reported including their status without changing the original
+func TestWatchedBlueprint(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	blueprint := testBlueprint("plotter")
+	watched := watchedBlueprint(blueprint)
+	g.Expect(watched.Labels).To(gomega.HaveKeyWithValue(watchResourceLabel, watchResourceLevel))
+	g.Expect(blueprint.Labels).To(gomega.BeEmpty())
+}
+
+// TestRetryTransientFailures checks that requests are retried while the Razee API is unavailable
+func TestRetryTransientFailures(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	fake := newFakeRazee(map[string]string{"cluster1": "cluster1-id"})
+	server := fake.start()
+	defer server.Close()
+	manager := newTestManager(g, server.URL)
+
+	fake.unavailable = 2
+	g.Expect(manager.CreateBlueprint("cluster1", testBlueprint("plotter"))).To(gomega.Succeed())
+	g.Expect(fake.subscriptions).To(gomega.HaveLen(1))
+
+	// requests fail once the retries are exhausted
+	fake.unavailable = testBackoff.Steps
+	requests := fake.requests
+	g.Expect(manager.UpdateBlueprint("cluster1", testBlueprint("plotter"))).ToNot(gomega.Succeed())
+	g.Expect(fake.requests - requests).To(gomega.Equal(testBackoff.Steps))
+}
+
+// TestRetryOnlyUnprocessedMutations checks that mutations are only repeated if Razee did not process them
+func TestRetryOnlyUnprocessedMutations(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	requests := 0
+	status := http.StatusBadGateway
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	httpClient := newRetryingClient(testBackoff, ctrl.Log.WithName("RazeeManager"))
+	post := func(query string) {
+		resp, err := httpClient.Post(server.URL, "application/json", strings.NewReader(`{"query": "`+query+`"}`))
+		g.Expect(err).ToNot(gomega.HaveOccurred())
+		_ = resp.Body.Close()
+	}
+
+	// a bad gateway may have forwarded the mutation to Razee
+	post("mutation { addChannel(orgId: \\\"org\\\", name: \\\"channel\\\") { uuid } }")
+	g.Expect(requests).To(gomega.Equal(1))
+
+	requests = 0
+	post("query { channelByName(orgId: \\\"org\\\", name: \\\"channel\\\") { uuid } }")
+	g.Expect(requests).To(gomega.Equal(testBackoff.Steps))
+
+	// an unavailable API did not process the mutation
+	requests = 0
+	status = http.StatusServiceUnavailable
+	post("mutation { addChannel(orgId: \\\"org\\\", name: \\\"channel\\\") { uuid } }")
+	g.Expect(requests).To(gomega.Equal(testBackoff.Steps))
+}
+
+// TestCompleteChannelCreation checks that the creation of a blueprint whose channel remained without subscription
+// is completed rather than repeated
+func TestCompleteChannelCreation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	fake := newFakeRazee(map[string]string{"cluster1": "cluster1-id"})
+	server := fake.start()
+	defer server.Close()
+	manager := newTestManager(g, server.URL)
+
+	name := channelName("cluster1", "plotter")
+	fake.channels[name] = &fakeChannel{UUID: "channel-0", Name: name, Versions: map[string]string{"version-0": "0"}}
+	g.Expect(manager.CreateBlueprint("cluster1", testBlueprint("plotter"))).To(gomega.Succeed())
+	g.Expect(fake.channels).To(gomega.HaveLen(1))
+	g.Expect(fake.channels[name].Versions).To(gomega.HaveLen(2))
+	g.Expect(fake.subscriptions).To(gomega.HaveLen(1))
+}
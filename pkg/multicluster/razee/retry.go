@@ -0,0 +1,118 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package razee
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// DefaultBackoff is the backoff with which requests to the Razee API are retried after transient failures
+var DefaultBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    5,
+	Cap:      10 * time.Second,
+}
+
+// retryTransport retries requests to the Razee API that fail because of network errors or because the API is
+// temporarily unavailable, e.g. while razeedash-api is restarted.
+// A mutation may have been applied although its response was lost, thus mutations are only retried if the API
+// rejected them without processing them. Callers check the state of Razee before they repeat a failed mutation.
+type retryTransport struct {
+	next    http.RoundTripper
+	backoff wait.Backoff
+	log     logr.Logger
+}
+
+// Returns an http client whose requests are retried with the given backoff after transient failures
+func newRetryingClient(backoff wait.Backoff, log logr.Logger) *http.Client {
+	return &http.Client{Transport: &retryTransport{next: http.DefaultTransport, backoff: backoff, log: log}}
+}
+
+// Returns true if the response status indicates a failure that may disappear when the request is repeated
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Returns true if the response status indicates that the request was rejected without being processed
+func isRejectedStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// Returns true if the body of a GraphQL request holds a mutation.
+// Requests that cannot be parsed are treated as mutations.
+func isMutation(body []byte) bool {
+	request := struct {
+		Query string `json:"query"`
+	}{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		return true
+	}
+	return strings.HasPrefix(strings.TrimSpace(request.Query), "mutation")
+}
+
+// Returns true if a request with the given outcome should be repeated
+func shouldRetry(resp *http.Response, err error, mutation bool) bool {
+	switch {
+	case err != nil:
+		return !mutation
+	case mutation:
+		return isRejectedStatus(resp.StatusCode)
+	default:
+		return isTransientStatus(resp.StatusCode)
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The body is buffered so that it can be sent again
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	mutation := isMutation(body)
+
+	backoff := t.backoff
+	for {
+		attempt := req.Clone(req.Context())
+		if body != nil {
+			attempt.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		resp, err := t.next.RoundTrip(attempt)
+		if !shouldRetry(resp, err, mutation) || backoff.Steps <= 1 {
+			return resp, err
+		}
+		if err != nil {
+			t.log.V(1).Info("Request to Razee failed. Retrying...", "error", err.Error())
+		} else {
+			t.log.V(1).Info("Razee is temporarily unavailable. Retrying...", "status", resp.StatusCode)
+			_, _ = ioutil.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff.Step()):
+		}
+	}
+}
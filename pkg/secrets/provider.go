@@ -0,0 +1,161 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"fybrik.io/fybrik/pkg/vault"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// ProviderEnv selects the secret provider from which modules obtain the credentials of data stores.
+	// Vault is used if it is not set.
+	ProviderEnv string = "SECRET_PROVIDER"
+	// FileRootEnv overrides the directory under which the file provider expects the credentials to be mounted
+	FileRootEnv string = "SECRET_PROVIDER_FILE_ROOT"
+	// DefaultFileRoot is the directory under which the file provider expects the credentials to be mounted
+	DefaultFileRoot string = "/var/run/fybrik/credentials"
+	// NamespacesEnv is a comma separated list of the namespaces, besides the namespace of the application, from which
+	// the kubernetes provider may project Secrets
+	NamespacesEnv string = "SECRET_PROVIDER_NAMESPACES"
+)
+
+// Provider creates the references with which modules obtain the credentials of data stores.
+// The credentials are either stored in a Kubernetes Secret, e.g. for provisioned storage, or identified by the
+// secret path that a data catalog returns for a dataset.
+type Provider interface {
+	// Name returns the name of the provider
+	Name() app.SecretProvider
+	// ForSecret returns the reference to the credentials that are stored in the given Kubernetes Secret
+	ForSecret(secret types.NamespacedName) app.CredentialReference
+	// ForPath returns the reference to the credentials with the given secret path as returned by data catalogs
+	ForPath(secretPath string) (app.CredentialReference, error)
+}
+
+// VaultProvider lets modules read the credentials from Vault
+type VaultProvider struct {
+	// Address of Vault
+	Address string
+	// Role with which modules log into Vault
+	Role string
+}
+
+// Name returns the name of the provider
+func (p *VaultProvider) Name() app.SecretProvider {
+	return app.VaultSecretProvider
+}
+
+// ForSecret returns the reference to the credentials that are stored in the given Kubernetes Secret.
+// Modules read them with the vault-plugin-secrets-kubernetes-reader plugin.
+func (p *VaultProvider) ForSecret(secret types.NamespacedName) app.CredentialReference {
+	reference, _ := p.ForPath(vault.PathForReadingKubeSecret(secret.Namespace, secret.Name))
+	return reference
+}
+
+// ForPath returns the reference to the credentials with the given Vault secret path
+func (p *VaultProvider) ForPath(secretPath string) (app.CredentialReference, error) {
+	reference := app.CredentialReference{
+		Provider: app.VaultSecretProvider,
+		Vault: &app.Vault{
+			SecretPath: secretPath,
+			Role:       p.Role,
+			Address:    p.Address,
+		},
+	}
+	reference.SecretNamespace, reference.SecretName, _ = vault.ParseKubeSecretPath(secretPath)
+	return reference, nil
+}
+
+// KubernetesProvider projects the Kubernetes Secrets that hold the credentials into the namespace of the modules
+type KubernetesProvider struct{}
+
+// Name returns the name of the provider
+func (p *KubernetesProvider) Name() app.SecretProvider {
+	return app.KubernetesSecretProvider
+}
+
+// ForSecret returns the reference to the credentials that are stored in the given Kubernetes Secret
+func (p *KubernetesProvider) ForSecret(secret types.NamespacedName) app.CredentialReference {
+	return app.CredentialReference{
+		Provider:        app.KubernetesSecretProvider,
+		SecretName:      secret.Name,
+		SecretNamespace: secret.Namespace,
+	}
+}
+
+// ForPath returns the reference to the credentials with the given secret path, which has to identify a Kubernetes Secret
+func (p *KubernetesProvider) ForPath(secretPath string) (app.CredentialReference, error) {
+	namespace, name, ok := vault.ParseKubeSecretPath(secretPath)
+	if !ok {
+		return app.CredentialReference{}, fmt.Errorf("the credentials at %s are not stored in a Kubernetes Secret", secretPath)
+	}
+	return p.ForSecret(types.NamespacedName{Namespace: namespace, Name: name}), nil
+}
+
+// FileProvider lets modules read the credentials from files that are mounted into them, e.g. by the Secrets Store
+// CSI driver. The credentials of a Kubernetes Secret are expected in the directory <root>/<namespace>/<name>.
+type FileProvider struct {
+	// Root is the directory under which the credentials are mounted
+	Root string
+}
+
+// Name returns the name of the provider
+func (p *FileProvider) Name() app.SecretProvider {
+	return app.FileSecretProvider
+}
+
+// ForSecret returns the reference to the credentials that are stored in the given Kubernetes Secret
+func (p *FileProvider) ForSecret(secret types.NamespacedName) app.CredentialReference {
+	return app.CredentialReference{
+		Provider:        app.FileSecretProvider,
+		SecretName:      secret.Name,
+		SecretNamespace: secret.Namespace,
+		Path:            path.Join(p.Root, secret.Namespace, secret.Name),
+	}
+}
+
+// ForPath returns the reference to the credentials with the given secret path, which has to identify a Kubernetes Secret
+func (p *FileProvider) ForPath(secretPath string) (app.CredentialReference, error) {
+	namespace, name, ok := vault.ParseKubeSecretPath(secretPath)
+	if !ok {
+		return app.CredentialReference{}, fmt.Errorf("the credentials at %s are not stored in a Kubernetes Secret", secretPath)
+	}
+	return p.ForSecret(types.NamespacedName{Namespace: namespace, Name: name}), nil
+}
+
+// NewProvider returns the provider with the given name. The Vault provider hands the given Vault address and role
+// to the modules. The root directory of the file provider is read from the environment.
+func NewProvider(name string, vaultAddress string, vaultRole string) (Provider, error) {
+	switch app.SecretProvider(name) {
+	case "", app.VaultSecretProvider:
+		return &VaultProvider{Address: vaultAddress, Role: vaultRole}, nil
+	case app.KubernetesSecretProvider:
+		return &KubernetesProvider{}, nil
+	case app.FileSecretProvider:
+		root := os.Getenv(FileRootEnv)
+		if root == "" {
+			root = DefaultFileRoot
+		}
+		return &FileProvider{Root: root}, nil
+	}
+	return nil, fmt.Errorf("unknown secret provider: %s", name)
+}
+
+// AllowedNamespaces returns the namespaces, besides the namespace of the application, from which the kubernetes
+// provider may project Secrets, as read from the environment
+func AllowedNamespaces() []string {
+	var namespaces []string
+	for _, namespace := range strings.Split(os.Getenv(NamespacesEnv), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
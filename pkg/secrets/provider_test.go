@@ -0,0 +1,69 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package secrets
+
+import (
+	"testing"
+
+	app "fybrik.io/fybrik/manager/apis/app/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var secret = types.NamespacedName{Namespace: "fybrik-notebook-sample", Name: "paysim-csv"}
+
+const secretPath = "/v1/kubernetes-secrets/paysim-csv?namespace=fybrik-notebook-sample"
+
+func TestVaultProvider(t *testing.T) {
+	provider, err := NewProvider("", "http://vault.fybrik-system:8200", "module")
+	assert.Nil(t, err)
+	assert.Equal(t, app.VaultSecretProvider, provider.Name())
+
+	reference := provider.ForSecret(secret)
+	assert.Equal(t, app.VaultSecretProvider, reference.Provider)
+	assert.Equal(t, &app.Vault{SecretPath: secretPath, Role: "module", Address: "http://vault.fybrik-system:8200"}, reference.Vault)
+	assert.Equal(t, secret.Name, reference.SecretName)
+	assert.Equal(t, secret.Namespace, reference.SecretNamespace)
+
+	// any secret path can be read from Vault
+	reference, err = provider.ForPath("/v1/secret/data/credentials")
+	assert.Nil(t, err)
+	assert.Equal(t, "/v1/secret/data/credentials", reference.Vault.SecretPath)
+	assert.Empty(t, reference.SecretName)
+}
+
+func TestKubernetesProvider(t *testing.T) {
+	provider, err := NewProvider("kubernetes", "", "")
+	assert.Nil(t, err)
+
+	expected := app.CredentialReference{
+		Provider:        app.KubernetesSecretProvider,
+		SecretName:      secret.Name,
+		SecretNamespace: secret.Namespace,
+	}
+	assert.Equal(t, expected, provider.ForSecret(secret))
+	reference, err := provider.ForPath("http://vault.fybrik-system:8200" + secretPath)
+	assert.Nil(t, err)
+	assert.Equal(t, expected, reference)
+
+	// only credentials in Kubernetes Secrets can be projected
+	_, err = provider.ForPath("/v1/secret/data/credentials")
+	assert.NotNil(t, err)
+}
+
+func TestFileProvider(t *testing.T) {
+	provider, err := NewProvider("file", "", "")
+	assert.Nil(t, err)
+
+	reference, err := provider.ForPath(secretPath)
+	assert.Nil(t, err)
+	assert.Equal(t, app.FileSecretProvider, reference.Provider)
+	assert.Equal(t, DefaultFileRoot+"/fybrik-notebook-sample/paysim-csv", reference.Path)
+	assert.Nil(t, reference.Vault)
+}
+
+func TestUnknownProvider(t *testing.T) {
+	_, err := NewProvider("keyring", "", "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,344 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"fybrik.io/fybrik/pkg/taxonomy/model"
+)
+
+// Kind classifies a change between two versions of a taxonomy
+type Kind string
+
+const (
+	// Additive changes accept everything that was accepted before
+	Additive Kind = "additive"
+	// Breaking changes may reject resources that were accepted before
+	Breaking Kind = "breaking"
+	// EnumNarrowing changes remove values from an enumeration, which rejects the resources that use these values
+	EnumNarrowing Kind = "enum-narrowing"
+)
+
+// Change is a difference between two versions of a taxonomy
+type Change struct {
+	Kind Kind `json:"kind"`
+	// Path of the changed schema, e.g. definitions.Interface.properties.protocol
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// IsBreaking returns true if the change may reject resources that were accepted before
+func (c Change) IsBreaking() bool {
+	return c.Kind == Breaking || c.Kind == EnumNarrowing
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("[%s] %s: %s", c.Kind, c.Path, c.Message)
+}
+
+// HasBreakingChanges returns true if any of the changes may reject resources that were accepted before
+func HasBreakingChanges(changes []Change) bool {
+	for _, change := range changes {
+		if change.IsBreaking() {
+			return true
+		}
+	}
+	return false
+}
+
+// differ collects the changes between two documents
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(kind Kind, path string, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{Kind: kind, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Documents compares two compiled taxonomy documents and returns the changes from the old to the new one.
+// Definitions are compared by name and references are compared by the name of the definition they point to.
+// Changes of descriptions and titles are ignored.
+func Documents(oldDoc, newDoc *model.Document) []Change {
+	d := &differ{}
+	d.compareSchemas("definitions", oldDoc.Definitions, newDoc.Definitions, "definition")
+	return d.changes
+}
+
+// compareSchemas compares two maps of schemas such as definitions or properties
+func (d *differ) compareSchemas(path string, oldSchemas, newSchemas model.Schemas, noun string) {
+	for _, name := range sortedKeys(oldSchemas, newSchemas) {
+		oldSchema, inOld := oldSchemas[name]
+		newSchema, inNew := newSchemas[name]
+		switch {
+		case !inNew:
+			d.add(Breaking, path+"."+name, "%s removed", noun)
+		case !inOld:
+			d.add(Additive, path+"."+name, "%s added", noun)
+		default:
+			d.compare(path+"."+name, oldSchema, newSchema)
+		}
+	}
+}
+
+// compare compares two schemas or references
+//nolint:gocyclo
+func (d *differ) compare(path string, oldSchema, newSchema *model.SchemaRef) {
+	if oldSchema == nil || newSchema == nil {
+		switch {
+		case oldSchema == nil && newSchema != nil:
+			d.add(Breaking, path, "schema added")
+		case oldSchema != nil && newSchema == nil:
+			d.add(Additive, path, "schema removed")
+		}
+		return
+	}
+	if oldSchema.Ref != "" || newSchema.Ref != "" {
+		if oldSchema.RefName() != newSchema.RefName() || oldSchema.Ref == "" || newSchema.Ref == "" {
+			d.add(Breaking, path, "reference changed from %q to %q", oldSchema.Ref, newSchema.Ref)
+		}
+		return
+	}
+	oldS, newS := &oldSchema.Schema, &newSchema.Schema
+
+	switch {
+	case oldS.Type == newS.Type:
+		// unchanged
+	case newS.Type == "":
+		d.add(Additive, path, "type %q removed", oldS.Type)
+	case oldS.Type == "":
+		d.add(Breaking, path, "type %q added", newS.Type)
+	default:
+		d.add(Breaking, path, "type changed from %q to %q", oldS.Type, newS.Type)
+	}
+
+	d.compareProperties(path, oldS, newS)
+	d.compareAdditionalProperties(path, oldS.AdditionalProperties, newS.AdditionalProperties)
+	d.compareEnum(path, oldS.Enum, newS.Enum)
+	if oldS.Items != nil || newS.Items != nil {
+		d.compare(path+".items", oldS.Items, newS.Items)
+	}
+
+	// a value has to match one of the alternatives of oneOf and anyOf but all the schemas of allOf
+	d.compareComposition(path+".oneOf", oldS.OneOf, newS.OneOf, false)
+	d.compareComposition(path+".anyOf", oldS.AnyOf, newS.AnyOf, false)
+	d.compareComposition(path+".allOf", oldS.AllOf, newS.AllOf, true)
+	if !reflect.DeepEqual(oldS.Not, newS.Not) {
+		d.add(Breaking, path+".not", "schema changed")
+	}
+
+	d.compareString(path, "format", oldS.Format, newS.Format)
+	d.compareString(path, "pattern", oldS.Pattern, newS.Pattern)
+	d.compareLowerBound(path, "minLength", nonZero(oldS.MinLength), nonZero(newS.MinLength))
+	d.compareUpperBound(path, "maxLength", uint64Value(oldS.MaxLength), uint64Value(newS.MaxLength))
+	d.compareLowerBound(path, "minProperties", nonZero(oldS.MinProps), nonZero(newS.MinProps))
+	d.compareUpperBound(path, "maxProperties", uint64Value(oldS.MaxProps), uint64Value(newS.MaxProps))
+	d.compareLowerBound(path, "minItems", nonZero(oldS.MinItems), nonZero(newS.MinItems))
+	d.compareUpperBound(path, "maxItems", uint64Value(oldS.MaxItems), uint64Value(newS.MaxItems))
+	d.compareLowerBound(path, "minimum", oldS.Min, newS.Min)
+	d.compareUpperBound(path, "maximum", oldS.Max, newS.Max)
+	d.compareFlag(path, "exclusiveMinimum", oldS.ExclusiveMin, newS.ExclusiveMin)
+	d.compareFlag(path, "exclusiveMaximum", oldS.ExclusiveMax, newS.ExclusiveMax)
+	d.compareFlag(path, "uniqueItems", oldS.UniqueItems, newS.UniqueItems)
+	if !reflect.DeepEqual(oldS.MultipleOf, newS.MultipleOf) {
+		if newS.MultipleOf == nil {
+			d.add(Additive, path, "multipleOf removed")
+		} else {
+			d.add(Breaking, path, "multipleOf changed")
+		}
+	}
+}
+
+func (d *differ) compareProperties(path string, oldS, newS *model.Schema) {
+	d.compareSchemas(path+".properties", oldS.Properties, newS.Properties, "property")
+
+	oldRequired := toSet(oldS.Required)
+	newRequired := toSet(newS.Required)
+	for _, name := range newS.Required {
+		if !oldRequired[name] {
+			d.add(Breaking, path+".required", "property %q is required", name)
+		}
+	}
+	for _, name := range oldS.Required {
+		if !newRequired[name] {
+			d.add(Additive, path+".required", "property %q is optional", name)
+		}
+	}
+}
+
+// compareAdditionalProperties compares the additionalProperties fields. Additional properties are allowed if
+// the field is not set.
+func (d *differ) compareAdditionalProperties(path string, oldProps, newProps *model.AdditionalPropertiesType) {
+	path += ".additionalProperties"
+	oldAllowed := oldProps == nil || oldProps.IsAllowed()
+	newAllowed := newProps == nil || newProps.IsAllowed()
+	switch {
+	case oldAllowed && newAllowed:
+		// unchanged
+	case newAllowed:
+		d.add(Additive, path, "additional properties allowed")
+	case oldAllowed:
+		d.add(Breaking, path, "additional properties restricted")
+	case oldProps.Schema != nil && newProps.Schema != nil:
+		d.compare(path, oldProps.Schema, newProps.Schema)
+	case newProps.Schema != nil:
+		d.add(Additive, path, "additional properties allowed with a schema")
+	case oldProps.Schema != nil:
+		d.add(Breaking, path, "additional properties disallowed")
+	}
+}
+
+func (d *differ) compareEnum(path string, oldEnum, newEnum []interface{}) {
+	path += ".enum"
+	switch {
+	case oldEnum == nil && newEnum == nil:
+		return
+	case oldEnum == nil:
+		d.add(EnumNarrowing, path, "values restricted to %v", newEnum)
+		return
+	case newEnum == nil:
+		d.add(Additive, path, "values no longer restricted")
+		return
+	}
+	for _, value := range newEnum {
+		if !containsValue(oldEnum, value) {
+			d.add(Additive, path, "value %v added", value)
+		}
+	}
+	for _, value := range oldEnum {
+		if !containsValue(newEnum, value) {
+			d.add(EnumNarrowing, path, "value %v removed", value)
+		}
+	}
+}
+
+// compareComposition compares the schemas of oneOf, anyOf or allOf by their position.
+// Adding a schema to allOf or removing an alternative from oneOf or anyOf is breaking.
+func (d *differ) compareComposition(path string, oldRefs, newRefs model.SchemaRefs, all bool) {
+	for i := 0; i < len(oldRefs) || i < len(newRefs); i++ {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= len(newRefs):
+			if all {
+				d.add(Additive, itemPath, "schema removed")
+			} else {
+				d.add(Breaking, itemPath, "alternative removed")
+			}
+		case i >= len(oldRefs):
+			if all {
+				d.add(Breaking, itemPath, "schema added")
+			} else {
+				d.add(Additive, itemPath, "alternative added")
+			}
+		default:
+			d.compare(itemPath, oldRefs[i], newRefs[i])
+		}
+	}
+}
+
+// compareString compares a constraint such as a pattern, which is not enforced if it is empty
+func (d *differ) compareString(path string, name string, oldValue, newValue string) {
+	switch {
+	case oldValue == newValue:
+		// unchanged
+	case newValue == "":
+		d.add(Additive, path, "%s %q removed", name, oldValue)
+	case oldValue == "":
+		d.add(Breaking, path, "%s %q added", name, newValue)
+	default:
+		d.add(Breaking, path, "%s changed from %q to %q", name, oldValue, newValue)
+	}
+}
+
+// compareLowerBound compares a lower bound such as minLength, which is not enforced if it is nil
+func (d *differ) compareLowerBound(path string, name string, oldValue, newValue *float64) {
+	switch {
+	case oldValue == nil && newValue == nil:
+		// unchanged
+	case newValue == nil:
+		d.add(Additive, path, "%s %v removed", name, *oldValue)
+	case oldValue == nil:
+		d.add(Breaking, path, "%s %v added", name, *newValue)
+	case *newValue > *oldValue:
+		d.add(Breaking, path, "%s increased from %v to %v", name, *oldValue, *newValue)
+	case *newValue < *oldValue:
+		d.add(Additive, path, "%s decreased from %v to %v", name, *oldValue, *newValue)
+	}
+}
+
+// compareUpperBound compares an upper bound such as maxLength, which is not enforced if it is nil
+func (d *differ) compareUpperBound(path string, name string, oldValue, newValue *float64) {
+	switch {
+	case oldValue == nil && newValue == nil:
+		// unchanged
+	case newValue == nil:
+		d.add(Additive, path, "%s %v removed", name, *oldValue)
+	case oldValue == nil:
+		d.add(Breaking, path, "%s %v added", name, *newValue)
+	case *newValue < *oldValue:
+		d.add(Breaking, path, "%s decreased from %v to %v", name, *oldValue, *newValue)
+	case *newValue > *oldValue:
+		d.add(Additive, path, "%s increased from %v to %v", name, *oldValue, *newValue)
+	}
+}
+
+// compareFlag compares a constraint that is enforced if it is true
+func (d *differ) compareFlag(path string, name string, oldValue, newValue bool) {
+	switch {
+	case newValue && !oldValue:
+		d.add(Breaking, path, "%s enabled", name)
+	case oldValue && !newValue:
+		d.add(Additive, path, "%s disabled", name)
+	}
+}
+
+func sortedKeys(maps ...model.Schemas) []string {
+	set := map[string]bool{}
+	for _, m := range maps {
+		for key := range m {
+			set[key] = true
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func uint64Value(value *uint64) *float64 {
+	if value == nil {
+		return nil
+	}
+	result := float64(*value)
+	return &result
+}
+
+// nonZero returns nil for a bound of zero, which is not enforced
+func nonZero(value uint64) *float64 {
+	if value == 0 {
+		return nil
+	}
+	return uint64Value(&value)
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	taxonomyio "fybrik.io/fybrik/pkg/taxonomy/io"
+	"fybrik.io/fybrik/pkg/taxonomy/model"
+	. "github.com/onsi/gomega"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	baseTaxonomy      = "../../../charts/fybrik/files/taxonomy/taxonomy.json"
+	applicationSchema = "../../../charts/fybrik/files/taxonomy/fybrik_application.json"
+	validApplication  = "../../../manager/testdata/unittests/fybrikapplication-validForBase.yaml"
+)
+
+func document(g *WithT, content string) *model.Document {
+	doc := &model.Document{}
+	g.Expect(yaml.Unmarshal([]byte(content), doc)).To(Succeed())
+	return doc
+}
+
+func TestDocumentsUnchanged(t *testing.T) {
+	g := NewGomegaWithT(t)
+	doc, err := taxonomyio.ReadDocumentFromFile(baseTaxonomy)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(Documents(doc, doc)).To(BeEmpty())
+}
+
+func TestDocumentsAdditive(t *testing.T) {
+	g := NewGomegaWithT(t)
+	oldDoc := document(g, `{"definitions": {
+		"Protocol": {"type": "string", "enum": ["s3"]},
+		"Interface": {"type": "object", "required": ["protocol"], "additionalProperties": false,
+			"properties": {"protocol": {"$ref": "#/definitions/Protocol"}}}}}`)
+	newDoc := document(g, `{"definitions": {
+		"Protocol": {"type": "string", "enum": ["s3", "db2"]},
+		"Dataformat": {"type": "string"},
+		"Interface": {"type": "object",
+			"properties": {"protocol": {"$ref": "#/definitions/Protocol"}, "dataformat": {"$ref": "#/definitions/Dataformat"}}}}}`)
+
+	changes := Documents(oldDoc, newDoc)
+	g.Expect(HasBreakingChanges(changes)).To(BeFalse())
+	g.Expect(changes).To(ConsistOf(
+		Change{Kind: Additive, Path: "definitions.Dataformat", Message: "definition added"},
+		Change{Kind: Additive, Path: "definitions.Interface.properties.dataformat", Message: "property added"},
+		Change{Kind: Additive, Path: "definitions.Interface.required", Message: "property \"protocol\" is optional"},
+		Change{Kind: Additive, Path: "definitions.Interface.additionalProperties", Message: "additional properties allowed"},
+		Change{Kind: Additive, Path: "definitions.Protocol.enum", Message: "value db2 added"},
+	))
+}
+
+func TestDocumentsBreaking(t *testing.T) {
+	g := NewGomegaWithT(t)
+	oldDoc := document(g, `{"definitions": {
+		"Protocol": {"type": "string", "enum": ["s3", "db2"]},
+		"Dataformat": {"type": "string"},
+		"Action": {"type": "object", "properties": {"name": {"type": "string"}}},
+		"Interface": {"type": "object",
+			"properties": {"protocol": {"$ref": "#/definitions/Protocol"}, "dataformat": {"$ref": "#/definitions/Dataformat"}}}}}`)
+	newDoc := document(g, `{"definitions": {
+		"Protocol": {"type": "string", "enum": ["s3"]},
+		"Action": {"type": "object", "properties": {"name": {"type": "string", "maxLength": 10}}},
+		"Interface": {"type": "object", "required": ["protocol"],
+			"properties": {"protocol": {"$ref": "#/definitions/Protocol"}}}}}`)
+
+	changes := Documents(oldDoc, newDoc)
+	g.Expect(HasBreakingChanges(changes)).To(BeTrue())
+	g.Expect(changes).To(ConsistOf(
+		Change{Kind: Breaking, Path: "definitions.Action.properties.name", Message: "maxLength 10 added"},
+		Change{Kind: Breaking, Path: "definitions.Dataformat", Message: "definition removed"},
+		Change{Kind: Breaking, Path: "definitions.Interface.properties.dataformat", Message: "property removed"},
+		Change{Kind: Breaking, Path: "definitions.Interface.required", Message: "property \"protocol\" is required"},
+		Change{Kind: EnumNarrowing, Path: "definitions.Protocol.enum", Message: "value db2 removed"},
+	))
+}
+
+func TestValidateResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "resources")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	content, err := ioutil.ReadFile(validApplication)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "application.yaml"), content, 0600)).To(Succeed())
+
+	doc, err := taxonomyio.ReadDocumentFromFile(baseTaxonomy)
+	g.Expect(err).NotTo(HaveOccurred())
+	invalid, err := ValidateResources(doc, applicationSchema, dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(invalid).To(BeEmpty())
+
+	// the application uses a protocol that the new taxonomy does not allow
+	doc.Definitions["Protocol"].Enum = []interface{}{"s3"}
+	invalid, err = ValidateResources(doc, applicationSchema, dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(invalid).To(HaveLen(1))
+	g.Expect(invalid[0].File).To(Equal(filepath.Join(dir, "application.yaml")))
+	g.Expect(invalid[0].Errors).NotTo(BeEmpty())
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package diff
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"emperror.dev/errors"
+	taxonomyio "fybrik.io/fybrik/pkg/taxonomy/io"
+	"fybrik.io/fybrik/pkg/taxonomy/model"
+	"fybrik.io/fybrik/pkg/taxonomy/validate"
+	"sigs.k8s.io/yaml"
+)
+
+// taxonomyFileName is the name with which resource schemas refer to the taxonomy
+const taxonomyFileName = "taxonomy.json"
+
+// InvalidResource is an existing resource that is rejected by a taxonomy
+type InvalidResource struct {
+	// File that holds the resource
+	File   string   `json:"file"`
+	Errors []string `json:"errors"`
+}
+
+// ValidateResources validates the resources in the JSON and YAML files of a directory against the given resource
+// schema, e.g. fybrik_application.json, in which references to taxonomy.json resolve to the given taxonomy.
+// Every file is expected to hold a single resource. The resources that are rejected are returned.
+func ValidateResources(taxonomy *model.Document, resourceSchema string, dir string) ([]InvalidResource, error) {
+	schemaDir, err := ioutil.TempDir("", "taxonomy")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(schemaDir)
+
+	// the resource schema refers to the taxonomy relative to its own location
+	if err = taxonomyio.WriteDocumentToFile(taxonomy, filepath.Join(schemaDir, taxonomyFileName)); err != nil {
+		return nil, errors.Wrap(err, "could not write the taxonomy")
+	}
+	content, err := ioutil.ReadFile(filepath.Clean(resourceSchema))
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read the resource schema")
+	}
+	schemaPath := filepath.Join(schemaDir, filepath.Base(resourceSchema))
+	if err = ioutil.WriteFile(schemaPath, content, 0600); err != nil {
+		return nil, errors.Wrap(err, "could not write the resource schema")
+	}
+	// the schema is compiled once for all the resources
+	validator := validate.NewValidator(0)
+	if err = validator.Load(schemaPath); err != nil {
+		return nil, err
+	}
+
+	files, err := resourceFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	var invalid []InvalidResource
+	for _, file := range files {
+		content, err := ioutil.ReadFile(filepath.Clean(file))
+		if err != nil {
+			return nil, err
+		}
+		resourceJSON, err := yaml.YAMLToJSON(content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not parse resource %s", file)
+		}
+		allErrs, err := validator.Validate(resourceJSON, schemaPath)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not validate resource %s", file)
+		}
+		if len(allErrs) == 0 {
+			continue
+		}
+		resource := InvalidResource{File: file}
+		for _, fieldErr := range allErrs {
+			resource.Errors = append(resource.Errors, fieldErr.Error())
+		}
+		invalid = append(invalid, resource)
+	}
+	return invalid, nil
+}
+
+// resourceFiles returns the JSON and YAML files in a directory and its subdirectories in lexical order
+func resourceFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json", ".yaml", ".yml":
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"fmt"
+)
+
+// Mode selects how payloads that do not conform to the taxonomy are handled
+type Mode string
+
+const (
+	// Disabled skips the validation of payloads
+	Disabled Mode = ""
+	// Flag reports payloads that do not conform to the taxonomy but accepts them
+	Flag Mode = "flag"
+	// Reject fails the processing of payloads that do not conform to the taxonomy
+	Reject Mode = "reject"
+)
+
+// ParseMode returns the validation mode with the given name. An empty name or "disabled" disable the validation.
+func ParseMode(name string) (Mode, error) {
+	switch Mode(name) {
+	case Disabled, "disabled":
+		return Disabled, nil
+	case Flag, Reject:
+		return Mode(name), nil
+	}
+	return Disabled, fmt.Errorf("unknown taxonomy validation mode: %s", name)
+}
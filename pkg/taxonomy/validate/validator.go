@@ -0,0 +1,135 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"emperror.dev/errors"
+	"github.com/xeipuuv/gojsonschema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DefaultReloadInterval is the interval in which the validator checks whether the taxonomy files changed
+const DefaultReloadInterval = 10 * time.Second
+
+// DefaultValidator is the validator shared by the webhooks and the connectors of the manager
+var DefaultValidator = NewValidator(DefaultReloadInterval)
+
+// compiledSchema is a schema together with the state of the files from which it was compiled
+type compiledSchema struct {
+	schema      *gojsonschema.Schema
+	fingerprint string
+	checked     time.Time
+}
+
+// Validator validates payloads against taxonomy schemas such as fybrik_application.json.
+// A schema is compiled on first use together with the taxonomy.json that it refers to, and is compiled again when
+// the JSON files in its directory change, e.g. when the ConfigMap in which the taxonomy is mounted is updated.
+// A Validator is safe for concurrent use.
+type Validator struct {
+	mutex          sync.Mutex
+	reloadInterval time.Duration
+	schemas        map[string]*compiledSchema
+	now            func() time.Time
+}
+
+// NewValidator creates a validator that checks for changes of the taxonomy files at most once in the given interval.
+// A non-positive interval disables the reload, so schemas are compiled only once.
+func NewValidator(reloadInterval time.Duration) *Validator {
+	return &Validator{
+		reloadInterval: reloadInterval,
+		schemas:        map[string]*compiledSchema{},
+		now:            time.Now,
+	}
+}
+
+// Load compiles the given schema if it is not compiled yet or if its files changed.
+// An error is returned if the schema or the taxonomy it refers to is missing or invalid.
+func (v *Validator) Load(schemaFile string) error {
+	_, err := v.schema(schemaFile)
+	return err
+}
+
+// Validate validates the given payload JSON against the given schema and returns the fields that do not conform to it.
+// An error is returned if the schema can not be loaded or if the payload is not valid JSON.
+func (v *Validator) Validate(payloadJSON []byte, schemaFile string) ([]*field.Error, error) {
+	schema, err := v.schema(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(payloadJSON))
+	if err != nil {
+		return nil, err
+	}
+	var allErrs []*field.Error
+	for _, desc := range result.Errors() {
+		allErrs = append(allErrs, field.Invalid(field.NewPath(desc.Field()), desc.Value(), desc.Description()))
+	}
+	return allErrs, nil
+}
+
+// schema returns the compiled schema, compiling it again if its files changed
+func (v *Validator) schema(schemaFile string) (*gojsonschema.Schema, error) {
+	path, err := filepath.Abs(schemaFile)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	now := v.now()
+	compiled, found := v.schemas[path]
+	if found && (v.reloadInterval <= 0 || now.Sub(compiled.checked) < v.reloadInterval) {
+		return compiled.schema, nil
+	}
+
+	fingerprint, err := directoryFingerprint(filepath.Dir(path))
+	if err != nil {
+		delete(v.schemas, path)
+		return nil, errors.Wrapf(err, "could not read taxonomy %s", schemaFile)
+	}
+	if found && fingerprint == compiled.fingerprint {
+		compiled.checked = now
+		return compiled.schema, nil
+	}
+
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewReferenceLoader("file://" + path))
+	if err != nil {
+		// a changed taxonomy that is invalid must not be silently replaced by the previous one
+		delete(v.schemas, path)
+		return nil, errors.Wrapf(err, "could not load taxonomy %s", schemaFile)
+	}
+	v.schemas[path] = &compiledSchema{schema: schema, fingerprint: fingerprint, checked: now}
+	return schema, nil
+}
+
+// directoryFingerprint summarizes the names, sizes and modification times of the JSON files in a directory.
+// Symbolic links are followed, so that the fingerprint changes when Kubernetes swaps the files of a mounted ConfigMap.
+func directoryFingerprint(dir string) (string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := os.Stat(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", entry.Name(), info.Size(), info.ModTime().UnixNano()))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ","), nil
+}
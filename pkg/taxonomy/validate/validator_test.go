@@ -0,0 +1,79 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+const (
+	resourceSchema = `{"type": "object", "properties": {"protocol": {"$ref": "taxonomy.json#/definitions/Protocol"}}}`
+	openTaxonomy   = `{"definitions": {"Protocol": {"type": "string"}}}`
+	s3Taxonomy     = `{"definitions": {"Protocol": {"type": "string", "enum": ["s3"]}}}`
+)
+
+func writeTaxonomy(g *WithT, dir string, taxonomy string) {
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "resource.json"), []byte(resourceSchema), 0600)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "taxonomy.json"), []byte(taxonomy), 0600)).To(Succeed())
+}
+
+func TestValidatorReloadsChangedTaxonomy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "taxonomy")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	writeTaxonomy(g, dir, openTaxonomy)
+	schema := filepath.Join(dir, "resource.json")
+
+	now := time.Now()
+	validator := NewValidator(time.Minute)
+	validator.now = func() time.Time { return now }
+	allErrs, err := validator.Validate([]byte(`{"protocol": "kafka"}`), schema)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(allErrs).To(BeEmpty())
+
+	// the compiled schema is used until the reload interval passes
+	writeTaxonomy(g, dir, s3Taxonomy)
+	allErrs, err = validator.Validate([]byte(`{"protocol": "kafka"}`), schema)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(allErrs).To(BeEmpty())
+
+	now = now.Add(2 * time.Minute)
+	allErrs, err = validator.Validate([]byte(`{"protocol": "kafka"}`), schema)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(allErrs).To(HaveLen(1))
+	allErrs, err = validator.Validate([]byte(`{"protocol": "s3"}`), schema)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(allErrs).To(BeEmpty())
+}
+
+func TestValidatorFailsOnInvalidTaxonomy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "taxonomy")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	schema := filepath.Join(dir, "resource.json")
+
+	validator := NewValidator(0)
+	_, err = validator.Validate([]byte(`{"protocol": "s3"}`), schema)
+	g.Expect(err).To(HaveOccurred())
+
+	writeTaxonomy(g, dir, `{"definitions": {"Protocol": {"type": 5}}}`)
+	g.Expect(validator.Load(schema)).NotTo(Succeed())
+
+	writeTaxonomy(g, dir, openTaxonomy)
+	g.Expect(validator.Load(schema)).To(Succeed())
+}
+
+func TestTaxonomyCheckFailsOnMissingTaxonomy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	_, err := TaxonomyCheck([]byte(`{}`), "../../../test/taxonomy/missing.json")
+	g.Expect(err).To(HaveOccurred())
+}
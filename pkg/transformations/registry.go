@@ -0,0 +1,205 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package transformations holds the registry of transformation plugins of the data movers.
+// A plugin implements the enforcement action of a policy manager with a given ID, either with an action
+// that is built into the data mover or with a jar that is loaded from a container image or a URL.
+package transformations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+	"sigs.k8s.io/yaml"
+)
+
+// PluginsDirEnv is the environment variable pointing to a directory of plugin definitions that are
+// loaded into the default registry
+const PluginsDirEnv = "TRANSFORMATION_PLUGINS_DIR"
+
+// ImagePluginsPath is the directory of a plugin image that holds the jars of the plugin
+const ImagePluginsPath = "/plugins"
+
+// MountPath is the directory of a transfer pod to which the jars of the plugins are copied
+const MountPath = "/opt/fybrik/plugins"
+
+// Plugin describes how an enforcement action is performed by the data movers
+type Plugin struct {
+	// ID of the enforcement action that the plugin implements, e.g. redact-ID
+	ID string `json:"id"`
+
+	// Action that is built into the data mover and implements the plugin, e.g. RedactColumns.
+	// Either an action or an image or jar has to be specified.
+	// +optional
+	Action string `json:"action,omitempty"`
+
+	// Container image that holds the jar of the plugin in its /plugins directory
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Jar of the plugin. Either a URL or a path relative to the /plugins directory of the image.
+	// +optional
+	Jar string `json:"jar,omitempty"`
+
+	// Class in the jar that implements the plugin
+	// +optional
+	Class string `json:"class,omitempty"`
+
+	// Argument of the enforcement action that holds the columns that are transformed, e.g. column_name
+	// +optional
+	ColumnsArgument string `json:"columnsArgument,omitempty"`
+
+	// Default values of the options of the transformation
+	// +optional
+	Defaults map[string]string `json:"defaults,omitempty"`
+
+	// JSON schema of the options of the transformation
+	// +optional
+	Schema json.RawMessage `json:"schema,omitempty"`
+
+	schema *gojsonschema.Schema
+}
+
+// IsBuiltin returns true if the plugin is implemented by an action that is built into the data mover
+func (p *Plugin) IsBuiltin() bool {
+	return p.Action != ""
+}
+
+// Registry maps the IDs of enforcement actions to plugins
+type Registry struct {
+	mutex   sync.RWMutex
+	plugins map[string]*Plugin
+}
+
+// NewRegistry returns a registry that only holds the built-in plugins
+func NewRegistry() *Registry {
+	registry := &Registry{plugins: map[string]*Plugin{}}
+	for _, plugin := range builtinPlugins() {
+		if err := registry.Register(plugin); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+// DefaultRegistry is the registry that is used by the webhooks and the controllers of the manager
+var DefaultRegistry = NewRegistry()
+
+// The actions that are built into the data movers are available under their own name.
+// The legacy IDs redact-ID and removed-ID of the default policy managers are mapped onto them.
+func builtinPlugins() []*Plugin {
+	plugins := []*Plugin{{
+		ID:              "redact-ID",
+		Action:          "RedactColumns",
+		ColumnsArgument: "column_name",
+		Defaults:        map[string]string{"redactValue": "XXXXXX"},
+	}, {
+		ID:              "removed-ID",
+		Action:          "RemoveColumns",
+		ColumnsArgument: "column_name",
+	}}
+	for _, action := range []string{"RemoveColumns", "EncryptColumns", "DigestColumns", "RedactColumns", "SampleRows", "FilterRows"} {
+		plugins = append(plugins, &Plugin{ID: action, Action: action, ColumnsArgument: "columns"})
+	}
+	return plugins
+}
+
+// Register adds a plugin to the registry or replaces the plugin with the same ID
+func (r *Registry) Register(plugin *Plugin) error {
+	if plugin.ID == "" {
+		return fmt.Errorf("plugin without an id")
+	}
+	if plugin.IsBuiltin() == (plugin.Image != "" || plugin.Jar != "") {
+		return fmt.Errorf("plugin %s must specify either a built-in action or an image or jar", plugin.ID)
+	}
+	if len(plugin.Schema) > 0 {
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(plugin.Schema))
+		if err != nil {
+			return fmt.Errorf("invalid schema of plugin %s: %v", plugin.ID, err)
+		}
+		plugin.schema = schema
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.plugins[plugin.ID] = plugin
+	return nil
+}
+
+// Get returns the plugin with the given ID or nil if there is no such plugin
+func (r *Registry) Get(id string) *Plugin {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.plugins[id]
+}
+
+// IDs returns the sorted IDs of all registered plugins
+func (r *Registry) IDs() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	ids := make([]string, 0, len(r.plugins))
+	for id := range r.plugins {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ValidateOptions validates the options of a transformation against the schema of the plugin with the given ID.
+// It returns a description of every violation of the schema.
+func (r *Registry) ValidateOptions(id string, options map[string]string) ([]string, error) {
+	plugin := r.Get(id)
+	if plugin == nil {
+		return nil, fmt.Errorf("unknown transformation plugin %s", id)
+	}
+	if plugin.schema == nil {
+		return nil, nil
+	}
+	if options == nil {
+		options = map[string]string{}
+	}
+	result, err := plugin.schema.Validate(gojsonschema.NewGoLoader(options))
+	if err != nil {
+		return nil, err
+	}
+	var violations []string
+	for _, desc := range result.Errors() {
+		violations = append(violations, desc.String())
+	}
+	return violations, nil
+}
+
+// LoadDirectory registers the plugins that are defined in the yaml and json files of a directory.
+// Every file holds a list of plugins.
+func (r *Registry) LoadDirectory(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if file.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+		var plugins []*Plugin
+		if err := yaml.Unmarshal(content, &plugins); err != nil {
+			return fmt.Errorf("could not read plugins from %s: %v", file.Name(), err)
+		}
+		for _, plugin := range plugins {
+			if err := r.Register(plugin); err != nil {
+				return fmt.Errorf("could not register plugins from %s: %v", file.Name(), err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 IBM Corp.
+// SPDX-License-Identifier: Apache-2.0
+
+package transformations
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+const maskingPlugins = `
+- id: mask-ID
+  image: registry.example.com/plugins/masking:1.0
+  jar: masking.jar
+  class: com.example.transformations.Mask
+  columnsArgument: column_name
+  defaults:
+    maskChar: "*"
+  schema:
+    type: object
+    properties:
+      maskChar:
+        type: string
+        maxLength: 1
+      keep:
+        type: string
+        pattern: "^[0-9]+$"
+    additionalProperties: false
+`
+
+// The built-in actions and the legacy action IDs are registered by default
+func TestBuiltinPlugins(t *testing.T) {
+	g := NewGomegaWithT(t)
+	registry := NewRegistry()
+
+	redact := registry.Get("redact-ID")
+	g.Expect(redact).ToNot(BeNil())
+	g.Expect(redact.IsBuiltin()).To(BeTrue())
+	g.Expect(redact.Action).To(Equal("RedactColumns"))
+	g.Expect(registry.Get("FilterRows")).ToNot(BeNil())
+	g.Expect(registry.Get("unknown-ID")).To(BeNil())
+
+	violations, err := registry.ValidateOptions("redact-ID", map[string]string{"anything": "goes"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(violations).To(BeEmpty())
+	_, err = registry.ValidateOptions("unknown-ID", nil)
+	g.Expect(err).To(HaveOccurred())
+}
+
+// Plugins are loaded from a directory and their options are validated against their schema
+func TestLoadDirectory(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "plugins")
+	g.Expect(err).ToNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "masking.yaml"), []byte(maskingPlugins), 0600)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte("not a plugin"), 0600)).To(Succeed())
+
+	registry := NewRegistry()
+	g.Expect(registry.LoadDirectory(dir)).To(Succeed())
+	mask := registry.Get("mask-ID")
+	g.Expect(mask).ToNot(BeNil())
+	g.Expect(mask.IsBuiltin()).To(BeFalse())
+	g.Expect(mask.Defaults).To(HaveKeyWithValue("maskChar", "*"))
+
+	violations, err := registry.ValidateOptions("mask-ID", map[string]string{"maskChar": "#", "keep": "4"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(violations).To(BeEmpty())
+
+	violations, err = registry.ValidateOptions("mask-ID", map[string]string{"maskChar": "##", "color": "red"})
+	g.Expect(err).ToNot(HaveOccurred())
+	g.Expect(violations).To(HaveLen(2))
+}
+
+// Plugins must either name a built-in action or an image or jar, and have a valid schema
+func TestRegisterInvalidPlugin(t *testing.T) {
+	g := NewGomegaWithT(t)
+	registry := NewRegistry()
+
+	g.Expect(registry.Register(&Plugin{ID: "empty-ID"})).ToNot(Succeed())
+	g.Expect(registry.Register(&Plugin{ID: "both-ID", Action: "RedactColumns", Image: "plugin:1.0"})).ToNot(Succeed())
+	g.Expect(registry.Register(&Plugin{ID: "schema-ID", Jar: "https://example.com/plugin.jar",
+		Schema: []byte(`{"type": 5}`)})).ToNot(Succeed())
+	g.Expect(registry.Get("schema-ID")).To(BeNil())
+}
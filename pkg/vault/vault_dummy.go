@@ -8,32 +8,69 @@ import (
 	"errors"
 )
 
+// DummyIdentity holds the arguments with which a policy has been linked to an identity
+type DummyIdentity struct {
+	PolicyName       string
+	BoundedNamespace string
+	ServiceAccount   string
+	TTL              string
+}
+
 // Dummy implementation for testing
 type Dummy struct {
 	values map[string]string
+	// policy name -> policy
+	policies map[string]string
+	// identity path -> linked policy
+	identities map[string]DummyIdentity
 }
 
 // NewDummyConnection returns a new Dummy object
 func NewDummyConnection() *Dummy {
-	return &Dummy{values: make(map[string]string)}
+	return &Dummy{
+		values:     make(map[string]string),
+		policies:   make(map[string]string),
+		identities: make(map[string]DummyIdentity),
+	}
 }
 
 func (c *Dummy) LinkPolicyToIdentity(identity string, policyName string, boundedNamespace string, serviceAccount string, auth string, ttl string) error {
+	c.identities["auth/"+auth+"/"+identity] = DummyIdentity{
+		PolicyName:       policyName,
+		BoundedNamespace: boundedNamespace,
+		ServiceAccount:   serviceAccount,
+		TTL:              ttl,
+	}
 	return nil
 }
 
 func (c *Dummy) RemovePolicyFromIdentity(identity string, policyName string, auth string) error {
+	delete(c.identities, "auth/"+auth+"/"+identity)
 	return nil
 }
 
 func (c *Dummy) WritePolicy(policyName string, policy string) error {
+	c.policies[policyName] = policy
 	return nil
 }
 
 func (c *Dummy) DeletePolicy(policyName string) error {
+	delete(c.policies, policyName)
 	return nil
 }
 
+// GetPolicy returns the policy with the given name and whether it exists
+func (c *Dummy) GetPolicy(policyName string) (string, bool) {
+	policy, exists := c.policies[policyName]
+	return policy, exists
+}
+
+// GetIdentity returns the policy link of the given identity and whether it exists
+func (c *Dummy) GetIdentity(identity string, auth string) (DummyIdentity, bool) {
+	link, exists := c.identities["auth/"+auth+"/"+identity]
+	return link, exists
+}
+
 func (c *Dummy) Mount(path string) error {
 	return nil
 }
@@ -3,7 +3,11 @@
 
 package vault
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
 
 // The path of the Vault plugin to use to retrieve dataset credentials stored in kubernetes secret.
 // vault-plugin-secrets-kubernetes-reader plugin is used for this purpose and is enabled
@@ -27,3 +31,24 @@ func PathForReadingKubeSecret(secretNamespace string, secretName string) string
 	secretPath := fmt.Sprintf("%s%s?namespace=%s", pluginPath, secretName, secretNamespace)
 	return secretPath
 }
+
+// ParseKubeSecretPath returns the namespace and the name of the kubernetes secret that is read with the given Vault
+// secret path, which may be prefixed with the Vault address. It is the inverse of PathForReadingKubeSecret and returns
+// false if the path does not use the vault-plugin-secrets-kubernetes-reader plugin.
+func ParseKubeSecretPath(secretPath string) (string, string, bool) {
+	u, err := url.Parse(secretPath)
+	if err != nil {
+		return "", "", false
+	}
+	pluginPath := "/v1/" + vaultPluginPath + "/"
+	index := strings.Index(u.Path, pluginPath)
+	if index < 0 {
+		return "", "", false
+	}
+	secretName := u.Path[index+len(pluginPath):]
+	secretNamespace := u.Query().Get("namespace")
+	if secretName == "" || strings.Contains(secretName, "/") || secretNamespace == "" {
+		return "", "", false
+	}
+	return secretNamespace, secretName, true
+}